@@ -8,7 +8,14 @@ import (
 type APIKeySpec struct {
 	// ExpirationSeconds is the requested duration of validity of the API key.
 	// +optional
+	// +kubebuilder:validation:Minimum=1
 	ExpirationSeconds *int64 `json:"expirationSeconds"`
+
+	// Owner identifies the team or user this API key belongs to. Challenge instances requested with this API key are
+	// attributed to this owner.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	Owner string `json:"owner,omitempty"`
 }
 
 // APIKeyStatus defines the observed state of APIKey.
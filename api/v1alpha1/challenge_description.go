@@ -1,11 +1,15 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // ChallengeDescriptionSpec defines the desired state of ChallengeDescription.
+// +kubebuilder:validation:XValidation:rule="((has(self.flag) && size(self.flag) > 0 ? 1 : 0) + (has(self.flagSecretRef) ? 1 : 0) + (has(self.flagHash) ? 1 : 0)) <= 1",message="at most one of flag, flagSecretRef and flagHash may be set"
+// +kubebuilder:validation:XValidation:rule="(has(self.flagGeneration) && self.flagGeneration.mode != 'Static') || (has(self.flag) && size(self.flag) > 0) || has(self.flagSecretRef) || has(self.flagHash) || (has(self.flags) && size(self.flags) > 0) || (has(self.stages) && size(self.stages) > 0)",message="a flag is required unless flags are generated per instance"
 type ChallengeDescriptionSpec struct {
 	// Title is the name of the challenge
 	// +kubebuilder:validation:Required
@@ -31,10 +35,97 @@ type ChallengeDescriptionSpec struct {
 	// +kubebuilder:validation:Optional
 	Hints []ChallengeHint `json:"hints"`
 
-	// Flag is the flag the user is expected to get.
-	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:MinLength=1
-	Flag string `json:"flag"`
+	// Flag is the flag the user is expected to get in plain text. This field is only kept for compatibility. Prefer
+	// FlagSecretRef or FlagHash, because the plain text flag is visible to everybody who can read the challenge
+	// description. ClusterChallengeDescriptions do not support it.
+	// +kubebuilder:validation:Optional
+	Flag string `json:"flag,omitempty"`
+
+	// FlagSecretRef references the secret key which holds the flag the user is expected to get. The secret must reside
+	// in the namespace of the challenge description.
+	// +kubebuilder:validation:Optional
+	FlagSecretRef *corev1.SecretKeySelector `json:"flagSecretRef,omitempty"`
+
+	// FlagHash is the salted hash of the flag the user is expected to get. A flag which is only known as hash can be
+	// verified, but it can not be provided to the challenge workload.
+	// +kubebuilder:validation:Optional
+	FlagHash *FlagHash `json:"flagHash,omitempty"`
+
+	// Flags provide additional flags which are accepted for the challenge. This allows for several valid answers or
+	// answers which are matched case-insensitively or by regular expression. Flags generated per instance are not
+	// combined with these flags.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=32
+	Flags []FlagMatcher `json:"flags,omitempty"`
+
+	// Stages split the challenge into milestones. Every stage has its own flags and points, which allows for partial
+	// credit on long challenges. The progress of every challenge instance is recorded per stage.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=16
+	// +listType=map
+	// +listMapKey=name
+	Stages []ChallengeStage `json:"stages,omitempty"`
+
+	// FlagGeneration configures how the flag of every challenge instance is determined. When not provided, every
+	// challenge instance uses the static flag.
+	// +kubebuilder:validation:Optional
+	FlagGeneration *FlagGeneration `json:"flagGeneration,omitempty"`
+
+	// MaxInstancesPerOwner limits the number of concurrently running instances of this challenge per owner. When not
+	// provided, only the operator wide limit applies.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	MaxInstancesPerOwner *int `json:"maxInstancesPerOwner,omitempty"`
+
+	// ExtensionSeconds is the duration every requested extension adds to the expiration of a challenge instance. When
+	// not provided, a default of 15 minutes is used.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	ExtensionSeconds *int64 `json:"extensionSeconds,omitempty"`
+
+	// MaxExtensions is the number of extensions a challenge instance can use. Further extension requests are ignored.
+	// When not provided, the number of extensions is only bounded by MaxLifetimeSeconds.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	MaxExtensions *int `json:"maxExtensions,omitempty"`
+
+	// MaxLifetimeSeconds is the hard maximum lifetime of a challenge instance measured from its creation. Neither the
+	// requested expiration nor extensions can push the expiration beyond it.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	MaxLifetimeSeconds *int64 `json:"maxLifetimeSeconds,omitempty"`
+
+	// NetworkAccess grants the instances of this challenge network access beyond the default isolation. By default,
+	// instances can only talk to themselves, resolve DNS names and receive traffic from the ingress namespace.
+	// +kubebuilder:validation:Optional
+	NetworkAccess *NetworkAccess `json:"networkAccess,omitempty"`
+
+	// Resources is the resource budget of every instance of this challenge. Fields which are not provided fall back
+	// to the defaults of the operator.
+	// +kubebuilder:validation:Optional
+	Resources *ResourceBudget `json:"resources,omitempty"`
+
+	// Exposure exposes a service of the manifests to players under a host name which is generated for every instance.
+	// +kubebuilder:validation:Optional
+	Exposure *Exposure `json:"exposure,omitempty"`
+
+	// PortExposure exposes a TCP port of the manifests to players on a port which is allocated for every instance from
+	// the port range of the operator.
+	// +kubebuilder:validation:Optional
+	PortExposure *PortExposure `json:"portExposure,omitempty"`
+
+	// ConnectionInfo is a template which is rendered into the connection info of every challenge instance, for example
+	// "nc {{ .Host }} {{ .Port }}". The template has access to the endpoints discovered for the instance, but not to
+	// the flag, because the connection info is shown to the players.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=1024
+	ConnectionInfo string `json:"connectionInfo,omitempty"`
+
+	// PodSecurityLevel is the Pod Security Standard which is enforced in the namespace of every instance of this
+	// challenge. When not provided, the default of the operator applies. The level can not exceed the maximum level
+	// configured for the operator.
+	// +kubebuilder:validation:Optional
+	PodSecurityLevel PodSecurityLevel `json:"podSecurityLevel,omitempty"`
 
 	// Manifests provide the Kubernetes manifests which should be created when a new instance of the challenge is
 	// requested. The manifests are placed in a dedicated namespace. The namespace provided in those manifests is
@@ -57,6 +148,281 @@ type ChallengeHint struct {
 	Cost int `json:"cost"`
 }
 
+// ChallengeStage describes a single milestone of a challenge.
+type ChallengeStage struct {
+	// Name identifies the stage within the challenge.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Description is the content of the stage.
+	// +kubebuilder:validation:Optional
+	Description string `json:"description,omitempty"`
+
+	// Flags are the flags which complete the stage.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=8
+	Flags []FlagMatcher `json:"flags"`
+
+	// Points is the number of points which are added upon completing the stage. Flags can override the points.
+	// +kubebuilder:default=0
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	Points int `json:"points"`
+
+	// Hints provides a list of hints to help completing the stage.
+	// +kubebuilder:validation:Optional
+	Hints []ChallengeHint `json:"hints,omitempty"`
+}
+
+// PodSecurityLevel is one of the levels of the Pod Security Standards.
+// +kubebuilder:validation:Enum=privileged;baseline;restricted
+type PodSecurityLevel string
+
+const (
+	// PodSecurityLevelPrivileged is the unrestricted level, which allows for known privilege escalations.
+	PodSecurityLevelPrivileged PodSecurityLevel = "privileged"
+
+	// PodSecurityLevelBaseline prevents known privilege escalations while allowing the default pod configuration.
+	PodSecurityLevelBaseline PodSecurityLevel = "baseline"
+
+	// PodSecurityLevelRestricted enforces current pod hardening best practices.
+	PodSecurityLevelRestricted PodSecurityLevel = "restricted"
+)
+
+// NetworkAccess describes the network access which is granted to challenge instances beyond the default isolation.
+type NetworkAccess struct {
+	// Internet allows egress to all addresses outside of the private and link-local address ranges.
+	// +kubebuilder:validation:Optional
+	Internet bool `json:"internet,omitempty"`
+
+	// EgressNamespaces lists the namespaces the instances are allowed to connect to.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=16
+	// +kubebuilder:validation:items:MinLength=1
+	EgressNamespaces []string `json:"egressNamespaces,omitempty"`
+
+	// IngressNamespaces lists the namespaces which are allowed to connect to the instances in addition to the ingress
+	// namespace of the operator.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=16
+	// +kubebuilder:validation:items:MinLength=1
+	IngressNamespaces []string `json:"ingressNamespaces,omitempty"`
+}
+
+// Exposure describes a service which is exposed to players through an Ingress, a Gateway API HTTPRoute or the web proxy
+// of the operator. The host name is generated by the operator for every instance, so instances of the same challenge do
+// not collide.
+type Exposure struct {
+	// ServiceName is the name of the service from the manifests which is exposed.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	ServiceName string `json:"serviceName"`
+
+	// Port is the port of the service which is exposed.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+
+	// Protocol is the protocol players use to connect to the service. With HTTPS, TLS is terminated by the ingress
+	// controller or the gateway with its own certificate.
+	// +kubebuilder:default=HTTP
+	// +kubebuilder:validation:Optional
+	Protocol ExposureProtocol `json:"protocol,omitempty"`
+}
+
+// ExposureProtocol is the protocol players use to connect to an exposed service.
+// +kubebuilder:validation:Enum=HTTP;HTTPS
+type ExposureProtocol string
+
+const (
+	// ExposureProtocolHTTP exposes the service with plain HTTP.
+	ExposureProtocolHTTP ExposureProtocol = "HTTP"
+
+	// ExposureProtocolHTTPS exposes the service with HTTPS.
+	ExposureProtocolHTTPS ExposureProtocol = "HTTPS"
+)
+
+// PortExposure describes a TCP port which is exposed to players through a service of type NodePort or LoadBalancer, or
+// through the TCP gateway of the operator. The external port is allocated by the operator for every instance, so
+// instances of the same challenge do not collide.
+type PortExposure struct {
+	// Type is the type of the service which is generated for the instance. Services of type ClusterIP do not receive
+	// an external port and are only reachable through the TCP gateway of the operator.
+	// +kubebuilder:default=NodePort
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=NodePort;LoadBalancer;ClusterIP
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// Selector selects the pods of the manifests which receive the connections.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinProperties=1
+	Selector map[string]string `json:"selector"`
+
+	// TargetPort is the port of the pods which receives the connections.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	TargetPort int32 `json:"targetPort"`
+}
+
+// ResourceBudget limits the resources a single challenge instance can consume. The budget is enforced with a
+// ResourceQuota and a LimitRange in the namespace of the instance.
+type ResourceBudget struct {
+	// CPU is the sum of the CPU limits of all containers.
+	// +kubebuilder:validation:Optional
+	CPU *resource.Quantity `json:"cpu,omitempty"`
+
+	// Memory is the sum of the memory limits of all containers.
+	// +kubebuilder:validation:Optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+
+	// Storage is the sum of the storage requests of all persistent volume claims.
+	// +kubebuilder:validation:Optional
+	Storage *resource.Quantity `json:"storage,omitempty"`
+
+	// Pods is the number of pods.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	Pods *int64 `json:"pods,omitempty"`
+
+	// LoadBalancers is the number of services of type LoadBalancer.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	LoadBalancers *int64 `json:"loadBalancers,omitempty"`
+
+	// ContainerCPU is the CPU limit of containers which do not declare one.
+	// +kubebuilder:validation:Optional
+	ContainerCPU *resource.Quantity `json:"containerCPU,omitempty"`
+
+	// ContainerMemory is the memory limit of containers which do not declare one.
+	// +kubebuilder:validation:Optional
+	ContainerMemory *resource.Quantity `json:"containerMemory,omitempty"`
+}
+
+// FlagHashAlgorithm is the hash algorithm of a flag hash.
+// +kubebuilder:validation:Enum=SHA256
+type FlagHashAlgorithm string
+
+const (
+	// FlagHashAlgorithmSHA256 hashes the salt followed by the flag with SHA-256.
+	FlagHashAlgorithmSHA256 FlagHashAlgorithm = "SHA256"
+)
+
+// FlagHash is the salted hash of a flag.
+type FlagHash struct {
+	// Algorithm is the hash algorithm which was used to calculate the hash.
+	// +kubebuilder:default=SHA256
+	// +kubebuilder:validation:Optional
+	Algorithm FlagHashAlgorithm `json:"algorithm"`
+
+	// Salt is put in front of the flag before calculating the hash.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=8
+	Salt string `json:"salt"`
+
+	// Hash is the hex encoded hash of the salt followed by the flag.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[0-9a-f]{64}$`
+	Hash string `json:"hash"`
+}
+
+// FlagMatcherType is the way a flag matcher compares a submitted flag.
+// +kubebuilder:validation:Enum=static;caseInsensitive;regex
+type FlagMatcherType string
+
+const (
+	// FlagMatcherTypeStatic accepts a submitted flag which is identical to the value.
+	FlagMatcherTypeStatic FlagMatcherType = "static"
+
+	// FlagMatcherTypeCaseInsensitive accepts a submitted flag which is identical to the value when ignoring case.
+	FlagMatcherTypeCaseInsensitive FlagMatcherType = "caseInsensitive"
+
+	// FlagMatcherTypeRegex accepts a submitted flag which completely matches the regular expression in the value. The
+	// regular expression uses the RE2 syntax.
+	FlagMatcherTypeRegex FlagMatcherType = "regex"
+)
+
+// FlagMatcher describes a single flag which is accepted for a challenge. Invalid regular expressions are rejected by the
+// admission webhook.
+// +kubebuilder:validation:XValidation:rule="has(self.value) != has(self.valueSecretRef)",message="exactly one of value and valueSecretRef must be set"
+type FlagMatcher struct {
+	// Type is the way the submitted flag is compared.
+	// +kubebuilder:default=static
+	// +kubebuilder:validation:Optional
+	Type FlagMatcherType `json:"type"`
+
+	// Value is the accepted flag or the regular expression for type regex.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=1024
+	Value string `json:"value,omitempty"`
+
+	// ValueSecretRef references the secret key which holds the value. The secret must reside in the namespace of the
+	// challenge description. Regular expressions in secrets are only validated when a flag is submitted.
+	// +kubebuilder:validation:Optional
+	ValueSecretRef *corev1.SecretKeySelector `json:"valueSecretRef,omitempty"`
+
+	// Points is the number of points awarded when this flag is submitted. The value of the challenge is used when not
+	// provided.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	Points *int `json:"points,omitempty"`
+}
+
+// FlagGenerationMode is the way the flag of a challenge instance is determined.
+// +kubebuilder:validation:Enum=Static;Random;HMAC
+type FlagGenerationMode string
+
+const (
+	// FlagGenerationModeStatic uses the static flag of the challenge description for every challenge instance.
+	FlagGenerationModeStatic FlagGenerationMode = "Static"
+
+	// FlagGenerationModeRandom generates a random flag for every challenge instance.
+	FlagGenerationModeRandom FlagGenerationMode = "Random"
+
+	// FlagGenerationModeHMAC derives the flag of every challenge instance from the HMAC of the instance UID.
+	FlagGenerationModeHMAC FlagGenerationMode = "HMAC"
+)
+
+// +kubebuilder:validation:XValidation:rule="self.mode != 'HMAC' || has(self.hmacKeySecretRef)",message="hmacKeySecretRef is required for mode HMAC"
+
+// FlagGeneration configures how the flag of every challenge instance is determined.
+type FlagGeneration struct {
+	// Mode is the way the flag of a challenge instance is determined.
+	// +kubebuilder:default=Static
+	// +kubebuilder:validation:Optional
+	Mode FlagGenerationMode `json:"mode"`
+
+	// Format is the format of the generated flag. The placeholder %s is replaced with the generated value.
+	// +kubebuilder:default="CTF{%s}"
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:XValidation:rule="self.contains('%s')",message="format must contain the placeholder %s"
+	Format string `json:"format"`
+
+	// Prefix is put in front of the generated value, separated by an underscore.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9]*$`
+	Prefix string `json:"prefix,omitempty"`
+
+	// Length is the number of bytes of the generated value. The value is hex encoded, resulting in twice as many
+	// characters.
+	// +kubebuilder:default=16
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=8
+	// +kubebuilder:validation:Maximum=32
+	Length int `json:"length"`
+
+	// HMACKeySecretRef references the secret key which holds the key for mode HMAC. The secret must reside in the
+	// namespace of the challenge description.
+	// +kubebuilder:validation:Optional
+	HMACKeySecretRef *corev1.SecretKeySelector `json:"hmacKeySecretRef,omitempty"`
+}
+
 // ChallengeDescriptionStatus defines the observed state of ChallengeDescription.
 type ChallengeDescriptionStatus struct{}
 
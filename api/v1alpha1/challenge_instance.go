@@ -2,17 +2,100 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // ChallengeInstanceSpec defines the desired state of ChallengeInstance.
+// +kubebuilder:validation:XValidation:rule="!(has(self.challengeDescriptionName) && size(self.challengeDescriptionName) > 0 && has(self.challengeDescriptionRef))",message="at most one of challengeDescriptionName and challengeDescriptionRef may be set"
+// +kubebuilder:validation:XValidation:rule="has(self.challengeDescriptionRef) == has(oldSelf.challengeDescriptionRef)",message="challengeDescriptionRef is immutable"
+// +kubebuilder:validation:XValidation:rule="!has(oldSelf.owner) || (has(self.owner) && self.owner == oldSelf.owner)",message="owner can not be changed once it is set"
+// +kubebuilder:validation:XValidation:rule="!has(oldSelf.apiKeyName) || (has(self.apiKeyName) && self.apiKeyName == oldSelf.apiKeyName)",message="apiKeyName can not be changed once it is set"
 type ChallengeInstanceSpec struct {
 	// ExpirationSeconds is the requested duration of validity of the Challenge instance.
 	// +optional
+	// +kubebuilder:validation:Minimum=1
 	ExpirationSeconds *int64 `json:"expirationSeconds"`
 
-	// ChallengeDescriptionName is the name of the ChallengeDescription this challenge instance is related to.
+	// Extensions is the number of lifetime extensions requested for this challenge instance. Increase it by one to push
+	// the expiration forward by the extension duration of the challenge description. It can not be decreased.
+	// The field is always serialized and defaulted, because the validation rule is skipped when the field is absent.
+	// +kubebuilder:default=0
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:XValidation:rule="self >= oldSelf",message="extensions can not be decreased"
+	Extensions int `json:"extensions"`
+
+	// ChallengeDescriptionName is the name of the ChallengeDescription in the same namespace this challenge instance
+	// is related to. It is a shorthand for a ChallengeDescriptionRef of kind ChallengeDescription.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="challengeDescriptionName is immutable"
+	ChallengeDescriptionName string `json:"challengeDescriptionName,omitempty"`
+
+	// ChallengeDescriptionRef references the ChallengeDescription in the same namespace or the
+	// ClusterChallengeDescription this challenge instance is related to.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="challengeDescriptionRef is immutable"
+	ChallengeDescriptionRef *ChallengeDescriptionReference `json:"challengeDescriptionRef,omitempty"`
+
+	// Owner identifies the team or user who requested the challenge instance. When not provided, the owner of the
+	// APIKey referenced by APIKeyName is used. The number of concurrently running instances per owner can be limited.
+	// Challenge instances of a ChallengeInstancePool have no owner until they are claimed by setting the owner or the
+	// APIKeyName. The owner can not be changed or removed once it is set.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	Owner string `json:"owner,omitempty"`
+
+	// APIKeyName is the name of the APIKey in the same namespace the challenge instance was requested with. It can not
+	// be changed or removed once it is set.
+	// +kubebuilder:validation:Optional
+	APIKeyName string `json:"apiKeyName,omitempty"`
+}
+
+const (
+	// ChallengeDescriptionKind is the kind of a ChallengeDescription.
+	ChallengeDescriptionKind = "ChallengeDescription"
+
+	// ClusterChallengeDescriptionKind is the kind of a ClusterChallengeDescription.
+	ClusterChallengeDescriptionKind = "ClusterChallengeDescription"
+)
+
+// ChallengeDescriptionReference references a ChallengeDescription in the namespace of the referencing resource or a
+// ClusterChallengeDescription.
+type ChallengeDescriptionReference struct {
+	// Kind is the kind of the referenced challenge description.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=ChallengeDescription
+	// +kubebuilder:validation:Enum=ChallengeDescription;ClusterChallengeDescription
+	Kind string `json:"kind,omitempty"`
+
+	// Name is the name of the referenced challenge description.
 	// +kubebuilder:validation:Required
-	ChallengeDescriptionName string `json:"challengeDescriptionName"`
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// ChallengeInstanceEndpoint is an endpoint players can connect to.
+type ChallengeInstanceEndpoint struct {
+	// Kind is the kind of the object the endpoint was discovered from.
+	Kind string `json:"kind"`
+
+	// Name is the name of the object the endpoint was discovered from.
+	Name string `json:"name"`
+
+	// Host is the host name or IP address of the endpoint.
+	Host string `json:"host"`
+
+	// Port is the port of the endpoint.
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// Protocol is the protocol of the endpoint, for example TCP, UDP, HTTP or HTTPS.
+	// +optional
+	Protocol string `json:"protocol,omitempty"`
+
+	// URL is the URL of HTTP endpoints.
+	// +optional
+	URL string `json:"url,omitempty"`
 }
 
 // ChallengeInstanceStatus defines the observed state of ChallengeInstance.
@@ -20,12 +103,292 @@ type ChallengeInstanceStatus struct {
 	// ExpirationTimestamp is the time of expiration of the challenge instance.
 	// +optional
 	ExpirationTimestamp metav1.Time `json:"expirationTimestamp"`
+
+	// ExtensionsUsed is the number of lifetime extensions which were applied to the expiration timestamp.
+	// +optional
+	ExtensionsUsed int `json:"extensionsUsed,omitempty"`
+
+	// Namespace is the name of the namespace the workload of the challenge instance is placed in. The name is
+	// generated by the operator.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// FlagSecretRef references the secret key which holds the flag of this challenge instance. The secret resides in
+	// the namespace of the challenge instance workload and can be mounted by the challenge.
+	// +optional
+	FlagSecretRef *SecretKeyReference `json:"flagSecretRef,omitempty"`
+
+	// Stages record the progress of the challenge instance for challenges with stages. Only completed stages are
+	// listed.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Stages []ChallengeInstanceStageStatus `json:"stages,omitempty"`
+
+	// Inventory lists all objects which were applied from the manifests of the challenge description. Objects which
+	// are listed here but are no longer part of the manifests are deleted.
+	// +optional
+	// +listType=atomic
+	Inventory []InventoryEntry `json:"inventory,omitempty"`
+
+	// Hostname is the host name which was generated for the exposure of the challenge description.
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+
+	// ClaimTimestamp is the time the challenge instance was claimed from its pool. The maximum lifetime of claimed
+	// challenge instances starts at this point in time instead of the creation.
+	// +optional
+	ClaimTimestamp metav1.Time `json:"claimTimestamp,omitempty"`
+
+	// AllocatedPort is the external port which was allocated for the port exposure of the challenge description.
+	// +optional
+	AllocatedPort int32 `json:"allocatedPort,omitempty"`
+
+	// Endpoints lists the endpoints players can connect to. They are discovered from the services, ingresses and
+	// HTTP routes which were applied from the manifests of the challenge description or generated for its exposure.
+	// +optional
+	// +listType=atomic
+	Endpoints []ChallengeInstanceEndpoint `json:"endpoints,omitempty"`
+
+	// ConnectionInfo tells players how to connect to the challenge instance. It is rendered from the connection info
+	// template of the challenge description.
+	// +optional
+	ConnectionInfo string `json:"connectionInfo,omitempty"`
+
+	// Phase is a high level summary of where the challenge instance is in its lifecycle. It is derived from the
+	// conditions.
+	// +optional
+	Phase ChallengeInstancePhase `json:"phase,omitempty"`
+
+	// ObservedGeneration is the most recent generation of the challenge instance which was observed by the operator.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions provide the detailed state of the challenge instance.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ChallengeInstanceStageStatus records the progress of a single stage of a challenge instance.
+type ChallengeInstanceStageStatus struct {
+	// Name is the name of the stage.
+	Name string `json:"name"`
+
+	// CompletionTimestamp is the time the stage was completed.
+	CompletionTimestamp metav1.Time `json:"completionTimestamp"`
+
+	// PointsAwarded is the number of points awarded for completing the stage.
+	PointsAwarded int `json:"pointsAwarded"`
+
+	// FlagSubmissionName is the name of the FlagSubmission which completed the stage.
+	FlagSubmissionName string `json:"flagSubmissionName"`
+}
+
+// InventoryEntry references an object which was applied for a challenge instance.
+type InventoryEntry struct {
+	// APIVersion is the API version of the object.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind is the kind of the object.
+	Kind string `json:"kind"`
+
+	// Namespace is the namespace of the object.
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the object.
+	Name string `json:"name"`
+
+	// UID is the UID of the object. Objects with a different UID were not created by the operator and are never
+	// deleted.
+	UID types.UID `json:"uid"`
 }
 
+// SecretKeyReference references a key of a secret in a specific namespace.
+type SecretKeyReference struct {
+	// Namespace is the namespace of the secret.
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the secret.
+	Name string `json:"name"`
+
+	// Key is the key of the secret.
+	Key string `json:"key"`
+}
+
+// ChallengeInstancePhase is a high level summary of the lifecycle of a challenge instance.
+// +kubebuilder:validation:Enum=Pending;Provisioning;Ready;Degraded;Expiring;Terminating
+type ChallengeInstancePhase string
+
+const (
+	// ChallengeInstancePhasePending means that the operator did not yet start provisioning the instance.
+	ChallengeInstancePhasePending ChallengeInstancePhase = "Pending"
+
+	// ChallengeInstancePhaseProvisioning means that the operator is provisioning the instance.
+	ChallengeInstancePhaseProvisioning ChallengeInstancePhase = "Provisioning"
+
+	// ChallengeInstancePhaseReady means that the instance is fully provisioned and ready to be used.
+	ChallengeInstancePhaseReady ChallengeInstancePhase = "Ready"
+
+	// ChallengeInstancePhaseDegraded means that provisioning the instance failed.
+	ChallengeInstancePhaseDegraded ChallengeInstancePhase = "Degraded"
+
+	// ChallengeInstancePhaseExpiring means that the instance reached its expiration and is being removed.
+	ChallengeInstancePhaseExpiring ChallengeInstancePhase = "Expiring"
+
+	// ChallengeInstancePhaseTerminating means that the instance was deleted and is being removed.
+	ChallengeInstancePhaseTerminating ChallengeInstancePhase = "Terminating"
+)
+
+const (
+	// ChallengeInstanceConditionNamespaceReady signals if the namespace of the instance exists.
+	ChallengeInstanceConditionNamespaceReady = "NamespaceReady"
+
+	// ChallengeInstanceConditionNetworkIsolated signals if the network policies of the instance namespace are in place.
+	ChallengeInstanceConditionNetworkIsolated = "NetworkIsolated"
+
+	// ChallengeInstanceConditionResourcesLimited signals if the resource quota and limit range of the instance namespace
+	// are in place.
+	ChallengeInstanceConditionResourcesLimited = "ResourcesLimited"
+
+	// ChallengeInstanceConditionFlagReady signals if the flag secret of the instance exists.
+	ChallengeInstanceConditionFlagReady = "FlagReady"
+
+	// ChallengeInstanceConditionManifestsApplied signals if all manifests of the challenge description were applied.
+	ChallengeInstanceConditionManifestsApplied = "ManifestsApplied"
+
+	// ChallengeInstanceConditionExposed signals if the exposure of the challenge description is in place.
+	ChallengeInstanceConditionExposed = "Exposed"
+
+	// ChallengeInstanceConditionPortAllocated signals if the port exposure of the challenge description is in place.
+	ChallengeInstanceConditionPortAllocated = "PortAllocated"
+
+	// ChallengeInstanceConditionWorkloadsReady signals if all objects created from the manifests reached their desired
+	// state.
+	ChallengeInstanceConditionWorkloadsReady = "WorkloadsReady"
+
+	// ChallengeInstanceConditionReady signals if the instance is fully provisioned and ready to be used.
+	ChallengeInstanceConditionReady = "Ready"
+
+	// ChallengeInstanceConditionDegraded signals if provisioning the instance failed.
+	ChallengeInstanceConditionDegraded = "Degraded"
+
+	// ChallengeInstanceConditionExpiring signals if the instance reached its expiration.
+	ChallengeInstanceConditionExpiring = "Expiring"
+)
+
+const (
+	// ChallengeInstanceReasonNamespaceCreated is used when the namespace of the instance exists.
+	ChallengeInstanceReasonNamespaceCreated = "NamespaceCreated"
+
+	// ChallengeInstanceReasonNamespaceFailed is used when the namespace of the instance could not be created.
+	ChallengeInstanceReasonNamespaceFailed = "NamespaceFailed"
+
+	// ChallengeInstanceReasonNamespaceTerminating is used when the namespace of the instance is being deleted.
+	ChallengeInstanceReasonNamespaceTerminating = "NamespaceTerminating"
+
+	// ChallengeInstanceReasonNetworkPoliciesApplied is used when all network policies of the instance were applied.
+	ChallengeInstanceReasonNetworkPoliciesApplied = "NetworkPoliciesApplied"
+
+	// ChallengeInstanceReasonNetworkPoliciesFailed is used when the network policies of the instance could not be
+	// applied.
+	ChallengeInstanceReasonNetworkPoliciesFailed = "NetworkPoliciesFailed"
+
+	// ChallengeInstanceReasonResourceLimitsApplied is used when the resource quota and limit range were applied.
+	ChallengeInstanceReasonResourceLimitsApplied = "ResourceLimitsApplied"
+
+	// ChallengeInstanceReasonResourceLimitsFailed is used when the resource quota or limit range could not be applied.
+	ChallengeInstanceReasonResourceLimitsFailed = "ResourceLimitsFailed"
+
+	// ChallengeInstanceReasonFlagCreated is used when the flag secret of the instance exists.
+	ChallengeInstanceReasonFlagCreated = "FlagCreated"
+
+	// ChallengeInstanceReasonFlagNotProvided is used when the flag is not available in plain text and can not be
+	// provided to the workload.
+	ChallengeInstanceReasonFlagNotProvided = "FlagNotProvided"
+
+	// ChallengeInstanceReasonFlagFailed is used when the flag secret of the instance could not be created.
+	ChallengeInstanceReasonFlagFailed = "FlagFailed"
+
+	// ChallengeInstanceReasonManifestsApplied is used when all manifests were applied.
+	ChallengeInstanceReasonManifestsApplied = "ManifestsApplied"
+
+	// ChallengeInstanceReasonManifestsFailed is used when at least one manifest could not be applied.
+	ChallengeInstanceReasonManifestsFailed = "ManifestsFailed"
+
+	// ChallengeInstanceReasonExposureApplied is used when the ingress or HTTP route of the exposure was applied.
+	ChallengeInstanceReasonExposureApplied = "ExposureApplied"
+
+	// ChallengeInstanceReasonExposureProxied is used when the exposure is served by the web proxy of the operator and no
+	// ingress or HTTP route is generated.
+	ChallengeInstanceReasonExposureProxied = "ExposureProxied"
+
+	// ChallengeInstanceReasonExposureNotRequested is used when the challenge description does not request an exposure.
+	ChallengeInstanceReasonExposureNotRequested = "ExposureNotRequested"
+
+	// ChallengeInstanceReasonExposureFailed is used when the exposure could not be applied.
+	ChallengeInstanceReasonExposureFailed = "ExposureFailed"
+
+	// ChallengeInstanceReasonPortAllocated is used when a port was allocated and the service of the port exposure was
+	// applied.
+	ChallengeInstanceReasonPortAllocated = "PortAllocated"
+
+	// ChallengeInstanceReasonGatewayOnly is used when the service of the port exposure is only reachable through the
+	// TCP gateway and no external port is allocated.
+	ChallengeInstanceReasonGatewayOnly = "GatewayOnly"
+
+	// ChallengeInstanceReasonPortNotRequested is used when the challenge description does not request a port exposure.
+	ChallengeInstanceReasonPortNotRequested = "PortNotRequested"
+
+	// ChallengeInstanceReasonPortAllocationFailed is used when no port could be allocated or the service of the port
+	// exposure could not be applied.
+	ChallengeInstanceReasonPortAllocationFailed = "PortAllocationFailed"
+
+	// ChallengeInstanceReasonWorkloadsReady is used when all objects reached their desired state.
+	ChallengeInstanceReasonWorkloadsReady = "WorkloadsReady"
+
+	// ChallengeInstanceReasonWorkloadsProgressing is used when at least one object is still working towards its desired
+	// state.
+	ChallengeInstanceReasonWorkloadsProgressing = "WorkloadsProgressing"
+
+	// ChallengeInstanceReasonWorkloadsFailed is used when at least one object failed to reach its desired state.
+	ChallengeInstanceReasonWorkloadsFailed = "WorkloadsFailed"
+
+	// ChallengeInstanceReasonDescriptionNotFound is used when the referenced challenge description does not exist.
+	ChallengeInstanceReasonDescriptionNotFound = "ChallengeDescriptionNotFound"
+
+	// ChallengeInstanceReasonProvisioning is used when the instance is still being provisioned.
+	ChallengeInstanceReasonProvisioning = "Provisioning"
+
+	// ChallengeInstanceReasonReady is used when the instance is ready to be used.
+	ChallengeInstanceReasonReady = "Ready"
+
+	// ChallengeInstanceReasonDegraded is used when provisioning the instance failed.
+	ChallengeInstanceReasonDegraded = "Degraded"
+
+	// ChallengeInstanceReasonHealthy is used when provisioning the instance did not fail.
+	ChallengeInstanceReasonHealthy = "Healthy"
+
+	// ChallengeInstanceReasonActive is used when the instance did not yet reach its expiration.
+	ChallengeInstanceReasonActive = "Active"
+
+	// ChallengeInstanceReasonExpired is used when the instance reached its expiration.
+	ChallengeInstanceReasonExpired = "Expired"
+
+	// ChallengeInstanceReasonUnclaimed is used when the instance waits in its pool to be claimed. The expiration does
+	// not start before the instance is claimed.
+	ChallengeInstanceReasonUnclaimed = "Unclaimed"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Owner",type="string",JSONPath=".spec.owner"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
 // +kubebuilder:printcolumn:name="Expiration",type="string",format="date-time",JSONPath=".status.expirationTimestamp"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:printcolumn:name="Connection",type="string",JSONPath=".status.connectionInfo",priority=1
 
 // ChallengeInstance is the Schema for the challengeinstances API.
 type ChallengeInstance struct {
@@ -0,0 +1,89 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ChallengeInstancePoolSpec defines the desired state of ChallengeInstancePool.
+// +kubebuilder:validation:XValidation:rule="(has(self.challengeDescriptionName) && size(self.challengeDescriptionName) > 0) != has(self.challengeDescriptionRef)",message="exactly one of challengeDescriptionName and challengeDescriptionRef must be set"
+type ChallengeInstancePoolSpec struct {
+	// ChallengeDescriptionName is the name of the ChallengeDescription in the same namespace the challenge instances of
+	// the pool are created from. It is a shorthand for a ChallengeDescriptionRef of kind ChallengeDescription.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="challengeDescriptionName is immutable"
+	ChallengeDescriptionName string `json:"challengeDescriptionName,omitempty"`
+
+	// ChallengeDescriptionRef references the ChallengeDescription in the same namespace or the
+	// ClusterChallengeDescription the challenge instances of the pool are created from.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="challengeDescriptionRef is immutable"
+	ChallengeDescriptionRef *ChallengeDescriptionReference `json:"challengeDescriptionRef,omitempty"`
+
+	// Size is the number of unclaimed challenge instances the pool keeps around. Claimed challenge instances leave the
+	// pool and are replaced.
+	// +kubebuilder:validation:Minimum=0
+	Size int32 `json:"size"`
+
+	// MaxSurge is the number of challenge instances which are provisioned at the same time while the pool is refilled.
+	// This keeps the load on the cluster in check when many challenge instances are claimed at once.
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	MaxSurge int32 `json:"maxSurge,omitempty"`
+
+	// ExpirationSeconds is the duration of validity of the challenge instances of the pool. The expiration starts when
+	// a challenge instance is claimed.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	ExpirationSeconds *int64 `json:"expirationSeconds,omitempty"`
+}
+
+// ChallengeInstancePoolStatus defines the observed state of ChallengeInstancePool.
+type ChallengeInstancePoolStatus struct {
+	// Ready is the number of unclaimed challenge instances which are ready to be claimed.
+	// +optional
+	Ready int32 `json:"ready"`
+
+	// Provisioning is the number of unclaimed challenge instances which are not yet ready.
+	// +optional
+	Provisioning int32 `json:"provisioning"`
+
+	// ObservedGeneration is the most recent generation of the pool which was observed by the operator.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// ChallengeInstancePoolLabelName is the label which marks unclaimed challenge instances of a pool. The value is the
+// name of the pool. The label is removed when the challenge instance is claimed.
+const ChallengeInstancePoolLabelName = "ctf.backbone81/challenge-instance-pool"
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Size",type="integer",JSONPath=".spec.size"
+// +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.ready"
+// +kubebuilder:printcolumn:name="Provisioning",type="integer",JSONPath=".status.provisioning"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ChallengeInstancePool is the Schema for the challengeinstancepools API. It keeps a number of challenge instances of
+// a challenge description provisioned, so that players do not need to wait for a challenge instance to start. Players
+// claim a ready challenge instance of the pool by setting its owner or API key.
+type ChallengeInstancePool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ChallengeInstancePoolSpec   `json:"spec,omitempty"`
+	Status ChallengeInstancePoolStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ChallengeInstancePoolList contains a list of ChallengeInstancePool.
+type ChallengeInstancePoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ChallengeInstancePool `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ChallengeInstancePool{}, &ChallengeInstancePoolList{})
+}
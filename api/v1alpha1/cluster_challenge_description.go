@@ -0,0 +1,57 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterChallengeDescriptionSpec defines the desired state of ClusterChallengeDescription. The plain text field flag is
+// not supported, because cluster challenge descriptions are readable in all namespaces. The static flag is provided
+// through flagSecretRef or flagHash instead.
+// +kubebuilder:validation:XValidation:rule="!has(self.flag) || size(self.flag) == 0",message="flag is not supported, use flagSecretRef with secretNamespace or flagHash instead"
+// +kubebuilder:validation:XValidation:rule="has(self.secretNamespace) || !has(self.flagSecretRef)",message="secretNamespace is required when flagSecretRef is set"
+// +kubebuilder:validation:XValidation:rule="has(self.secretNamespace) || !has(self.flagGeneration) || !has(self.flagGeneration.hmacKeySecretRef)",message="secretNamespace is required when flagGeneration.hmacKeySecretRef is set"
+// +kubebuilder:validation:XValidation:rule="has(self.secretNamespace) || !has(self.flags) || self.flags.all(f, !has(f.valueSecretRef))",message="secretNamespace is required when flags reference secrets"
+// +kubebuilder:validation:XValidation:rule="has(self.secretNamespace) || !has(self.stages) || self.stages.all(s, s.flags.all(f, !has(f.valueSecretRef)))",message="secretNamespace is required when the flags of stages reference secrets"
+type ClusterChallengeDescriptionSpec struct {
+	ChallengeDescriptionSpec `json:",inline"`
+
+	// SecretNamespace is the namespace of all secrets referenced by this challenge description. It is required when
+	// flags or keys are provided through secrets.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MinLength=1
+	SecretNamespace string `json:"secretNamespace,omitempty"`
+}
+
+// ClusterChallengeDescriptionStatus defines the observed state of ClusterChallengeDescription.
+type ClusterChallengeDescriptionStatus struct{}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Title",type="string",JSONPath=".spec.title"
+// +kubebuilder:printcolumn:name="Category",type="string",JSONPath=".spec.category"
+// +kubebuilder:printcolumn:name="Value",type="integer",JSONPath=".spec.value"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ClusterChallengeDescription is the Schema for the clusterchallengedescriptions API. It describes a challenge which
+// can be instantiated from every namespace.
+type ClusterChallengeDescription struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterChallengeDescriptionSpec   `json:"spec,omitempty"`
+	Status ClusterChallengeDescriptionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterChallengeDescriptionList contains a list of ClusterChallengeDescription.
+type ClusterChallengeDescriptionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterChallengeDescription `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterChallengeDescription{}, &ClusterChallengeDescriptionList{})
+}
@@ -0,0 +1,96 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FlagSubmissionSpec defines the desired state of FlagSubmission.
+// +kubebuilder:validation:XValidation:rule="[has(self.challengeInstanceName), has(self.challengeDescriptionName), has(self.challengeDescriptionRef)].filter(x, x).size() == 1",message="exactly one of challengeInstanceName, challengeDescriptionName and challengeDescriptionRef must be set"
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+type FlagSubmissionSpec struct {
+	// ChallengeInstanceName is the name of the ChallengeInstance the flag is submitted for. The ChallengeInstance must
+	// reside in the same namespace as the FlagSubmission.
+	// +kubebuilder:validation:Optional
+	ChallengeInstanceName string `json:"challengeInstanceName,omitempty"`
+
+	// ChallengeDescriptionName is the name of the ChallengeDescription the flag is submitted for. The
+	// ChallengeDescription must reside in the same namespace as the FlagSubmission. Only challenges with a static flag
+	// can be verified through the ChallengeDescription.
+	// +kubebuilder:validation:Optional
+	ChallengeDescriptionName string `json:"challengeDescriptionName,omitempty"`
+
+	// ChallengeDescriptionRef references the ChallengeDescription in the same namespace or the
+	// ClusterChallengeDescription the flag is submitted for. Only challenges with a static flag can be verified through
+	// the challenge description.
+	// +kubebuilder:validation:Optional
+	ChallengeDescriptionRef *ChallengeDescriptionReference `json:"challengeDescriptionRef,omitempty"`
+
+	// Flag is the candidate flag which is to be verified.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Flag string `json:"flag"`
+}
+
+// FlagSubmissionStatus defines the observed state of FlagSubmission.
+type FlagSubmissionStatus struct {
+	// Result is the outcome of the verification. It is empty until the flag was verified.
+	// +optional
+	Result FlagSubmissionResult `json:"result,omitempty"`
+
+	// EvaluationTimestamp is the time the flag was verified.
+	// +optional
+	EvaluationTimestamp metav1.Time `json:"evaluationTimestamp"`
+
+	// PointsAwarded is the number of points awarded for the submission.
+	// +optional
+	PointsAwarded int `json:"pointsAwarded"`
+
+	// Stage is the name of the stage the flag completed. It is empty when the flag does not belong to a stage.
+	// +optional
+	Stage string `json:"stage,omitempty"`
+
+	// Message provides details about the result.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// FlagSubmissionResult is the outcome of verifying a flag submission.
+// +kubebuilder:validation:Enum=Correct;Incorrect
+type FlagSubmissionResult string
+
+const (
+	// FlagSubmissionResultCorrect means that the submitted flag matches the expected flag.
+	FlagSubmissionResultCorrect FlagSubmissionResult = "Correct"
+
+	// FlagSubmissionResultIncorrect means that the submitted flag does not match the expected flag.
+	FlagSubmissionResultIncorrect FlagSubmissionResult = "Incorrect"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Result",type="string",JSONPath=".status.result"
+// +kubebuilder:printcolumn:name="Stage",type="string",JSONPath=".status.stage"
+// +kubebuilder:printcolumn:name="Points",type="integer",JSONPath=".status.pointsAwarded"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// FlagSubmission is the Schema for the flagsubmissions API.
+type FlagSubmission struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FlagSubmissionSpec   `json:"spec,omitempty"`
+	Status FlagSubmissionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FlagSubmissionList contains a list of FlagSubmission.
+type FlagSubmissionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FlagSubmission `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FlagSubmission{}, &FlagSubmissionList{})
+}
@@ -5,6 +5,8 @@
 package v1alpha1
 
 import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -162,6 +164,21 @@ func (in *ChallengeDescriptionList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChallengeDescriptionReference) DeepCopyInto(out *ChallengeDescriptionReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChallengeDescriptionReference.
+func (in *ChallengeDescriptionReference) DeepCopy() *ChallengeDescriptionReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ChallengeDescriptionReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChallengeDescriptionSpec) DeepCopyInto(out *ChallengeDescriptionSpec) {
 	*out = *in
@@ -170,6 +187,75 @@ func (in *ChallengeDescriptionSpec) DeepCopyInto(out *ChallengeDescriptionSpec)
 		*out = make([]ChallengeHint, len(*in))
 		copy(*out, *in)
 	}
+	if in.FlagSecretRef != nil {
+		in, out := &in.FlagSecretRef, &out.FlagSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FlagHash != nil {
+		in, out := &in.FlagHash, &out.FlagHash
+		*out = new(FlagHash)
+		**out = **in
+	}
+	if in.Flags != nil {
+		in, out := &in.Flags, &out.Flags
+		*out = make([]FlagMatcher, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]ChallengeStage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FlagGeneration != nil {
+		in, out := &in.FlagGeneration, &out.FlagGeneration
+		*out = new(FlagGeneration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxInstancesPerOwner != nil {
+		in, out := &in.MaxInstancesPerOwner, &out.MaxInstancesPerOwner
+		*out = new(int)
+		**out = **in
+	}
+	if in.ExtensionSeconds != nil {
+		in, out := &in.ExtensionSeconds, &out.ExtensionSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxExtensions != nil {
+		in, out := &in.MaxExtensions, &out.MaxExtensions
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxLifetimeSeconds != nil {
+		in, out := &in.MaxLifetimeSeconds, &out.MaxLifetimeSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.NetworkAccess != nil {
+		in, out := &in.NetworkAccess, &out.NetworkAccess
+		*out = new(NetworkAccess)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(ResourceBudget)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Exposure != nil {
+		in, out := &in.Exposure, &out.Exposure
+		*out = new(Exposure)
+		**out = **in
+	}
+	if in.PortExposure != nil {
+		in, out := &in.PortExposure, &out.PortExposure
+		*out = new(PortExposure)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Manifests != nil {
 		in, out := &in.Manifests, &out.Manifests
 		*out = make([]runtime.RawExtension, len(*in))
@@ -246,6 +332,21 @@ func (in *ChallengeInstance) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChallengeInstanceEndpoint) DeepCopyInto(out *ChallengeInstanceEndpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChallengeInstanceEndpoint.
+func (in *ChallengeInstanceEndpoint) DeepCopy() *ChallengeInstanceEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(ChallengeInstanceEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChallengeInstanceList) DeepCopyInto(out *ChallengeInstanceList) {
 	*out = *in
@@ -278,6 +379,105 @@ func (in *ChallengeInstanceList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChallengeInstancePool) DeepCopyInto(out *ChallengeInstancePool) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChallengeInstancePool.
+func (in *ChallengeInstancePool) DeepCopy() *ChallengeInstancePool {
+	if in == nil {
+		return nil
+	}
+	out := new(ChallengeInstancePool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ChallengeInstancePool) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChallengeInstancePoolList) DeepCopyInto(out *ChallengeInstancePoolList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ChallengeInstancePool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChallengeInstancePoolList.
+func (in *ChallengeInstancePoolList) DeepCopy() *ChallengeInstancePoolList {
+	if in == nil {
+		return nil
+	}
+	out := new(ChallengeInstancePoolList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ChallengeInstancePoolList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChallengeInstancePoolSpec) DeepCopyInto(out *ChallengeInstancePoolSpec) {
+	*out = *in
+	if in.ChallengeDescriptionRef != nil {
+		in, out := &in.ChallengeDescriptionRef, &out.ChallengeDescriptionRef
+		*out = new(ChallengeDescriptionReference)
+		**out = **in
+	}
+	if in.ExpirationSeconds != nil {
+		in, out := &in.ExpirationSeconds, &out.ExpirationSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChallengeInstancePoolSpec.
+func (in *ChallengeInstancePoolSpec) DeepCopy() *ChallengeInstancePoolSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ChallengeInstancePoolSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChallengeInstancePoolStatus) DeepCopyInto(out *ChallengeInstancePoolStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChallengeInstancePoolStatus.
+func (in *ChallengeInstancePoolStatus) DeepCopy() *ChallengeInstancePoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChallengeInstancePoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChallengeInstanceSpec) DeepCopyInto(out *ChallengeInstanceSpec) {
 	*out = *in
@@ -286,6 +486,11 @@ func (in *ChallengeInstanceSpec) DeepCopyInto(out *ChallengeInstanceSpec) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.ChallengeDescriptionRef != nil {
+		in, out := &in.ChallengeDescriptionRef, &out.ChallengeDescriptionRef
+		*out = new(ChallengeDescriptionReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChallengeInstanceSpec.
@@ -298,10 +503,56 @@ func (in *ChallengeInstanceSpec) DeepCopy() *ChallengeInstanceSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChallengeInstanceStageStatus) DeepCopyInto(out *ChallengeInstanceStageStatus) {
+	*out = *in
+	in.CompletionTimestamp.DeepCopyInto(&out.CompletionTimestamp)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChallengeInstanceStageStatus.
+func (in *ChallengeInstanceStageStatus) DeepCopy() *ChallengeInstanceStageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChallengeInstanceStageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChallengeInstanceStatus) DeepCopyInto(out *ChallengeInstanceStatus) {
 	*out = *in
 	in.ExpirationTimestamp.DeepCopyInto(&out.ExpirationTimestamp)
+	if in.FlagSecretRef != nil {
+		in, out := &in.FlagSecretRef, &out.FlagSecretRef
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]ChallengeInstanceStageStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Inventory != nil {
+		in, out := &in.Inventory, &out.Inventory
+		*out = make([]InventoryEntry, len(*in))
+		copy(*out, *in)
+	}
+	in.ClaimTimestamp.DeepCopyInto(&out.ClaimTimestamp)
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]ChallengeInstanceEndpoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChallengeInstanceStatus.
@@ -313,3 +564,417 @@ func (in *ChallengeInstanceStatus) DeepCopy() *ChallengeInstanceStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChallengeStage) DeepCopyInto(out *ChallengeStage) {
+	*out = *in
+	if in.Flags != nil {
+		in, out := &in.Flags, &out.Flags
+		*out = make([]FlagMatcher, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Hints != nil {
+		in, out := &in.Hints, &out.Hints
+		*out = make([]ChallengeHint, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChallengeStage.
+func (in *ChallengeStage) DeepCopy() *ChallengeStage {
+	if in == nil {
+		return nil
+	}
+	out := new(ChallengeStage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterChallengeDescription) DeepCopyInto(out *ClusterChallengeDescription) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterChallengeDescription.
+func (in *ClusterChallengeDescription) DeepCopy() *ClusterChallengeDescription {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterChallengeDescription)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterChallengeDescription) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterChallengeDescriptionList) DeepCopyInto(out *ClusterChallengeDescriptionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterChallengeDescription, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterChallengeDescriptionList.
+func (in *ClusterChallengeDescriptionList) DeepCopy() *ClusterChallengeDescriptionList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterChallengeDescriptionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterChallengeDescriptionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterChallengeDescriptionSpec) DeepCopyInto(out *ClusterChallengeDescriptionSpec) {
+	*out = *in
+	in.ChallengeDescriptionSpec.DeepCopyInto(&out.ChallengeDescriptionSpec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterChallengeDescriptionSpec.
+func (in *ClusterChallengeDescriptionSpec) DeepCopy() *ClusterChallengeDescriptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterChallengeDescriptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterChallengeDescriptionStatus) DeepCopyInto(out *ClusterChallengeDescriptionStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterChallengeDescriptionStatus.
+func (in *ClusterChallengeDescriptionStatus) DeepCopy() *ClusterChallengeDescriptionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterChallengeDescriptionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Exposure) DeepCopyInto(out *Exposure) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Exposure.
+func (in *Exposure) DeepCopy() *Exposure {
+	if in == nil {
+		return nil
+	}
+	out := new(Exposure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlagGeneration) DeepCopyInto(out *FlagGeneration) {
+	*out = *in
+	if in.HMACKeySecretRef != nil {
+		in, out := &in.HMACKeySecretRef, &out.HMACKeySecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlagGeneration.
+func (in *FlagGeneration) DeepCopy() *FlagGeneration {
+	if in == nil {
+		return nil
+	}
+	out := new(FlagGeneration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlagHash) DeepCopyInto(out *FlagHash) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlagHash.
+func (in *FlagHash) DeepCopy() *FlagHash {
+	if in == nil {
+		return nil
+	}
+	out := new(FlagHash)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlagMatcher) DeepCopyInto(out *FlagMatcher) {
+	*out = *in
+	if in.ValueSecretRef != nil {
+		in, out := &in.ValueSecretRef, &out.ValueSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Points != nil {
+		in, out := &in.Points, &out.Points
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlagMatcher.
+func (in *FlagMatcher) DeepCopy() *FlagMatcher {
+	if in == nil {
+		return nil
+	}
+	out := new(FlagMatcher)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlagSubmission) DeepCopyInto(out *FlagSubmission) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlagSubmission.
+func (in *FlagSubmission) DeepCopy() *FlagSubmission {
+	if in == nil {
+		return nil
+	}
+	out := new(FlagSubmission)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FlagSubmission) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlagSubmissionList) DeepCopyInto(out *FlagSubmissionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FlagSubmission, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlagSubmissionList.
+func (in *FlagSubmissionList) DeepCopy() *FlagSubmissionList {
+	if in == nil {
+		return nil
+	}
+	out := new(FlagSubmissionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FlagSubmissionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlagSubmissionSpec) DeepCopyInto(out *FlagSubmissionSpec) {
+	*out = *in
+	if in.ChallengeDescriptionRef != nil {
+		in, out := &in.ChallengeDescriptionRef, &out.ChallengeDescriptionRef
+		*out = new(ChallengeDescriptionReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlagSubmissionSpec.
+func (in *FlagSubmissionSpec) DeepCopy() *FlagSubmissionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FlagSubmissionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlagSubmissionStatus) DeepCopyInto(out *FlagSubmissionStatus) {
+	*out = *in
+	in.EvaluationTimestamp.DeepCopyInto(&out.EvaluationTimestamp)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlagSubmissionStatus.
+func (in *FlagSubmissionStatus) DeepCopy() *FlagSubmissionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FlagSubmissionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InventoryEntry) DeepCopyInto(out *InventoryEntry) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InventoryEntry.
+func (in *InventoryEntry) DeepCopy() *InventoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(InventoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkAccess) DeepCopyInto(out *NetworkAccess) {
+	*out = *in
+	if in.EgressNamespaces != nil {
+		in, out := &in.EgressNamespaces, &out.EgressNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IngressNamespaces != nil {
+		in, out := &in.IngressNamespaces, &out.IngressNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkAccess.
+func (in *NetworkAccess) DeepCopy() *NetworkAccess {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PortExposure) DeepCopyInto(out *PortExposure) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PortExposure.
+func (in *PortExposure) DeepCopy() *PortExposure {
+	if in == nil {
+		return nil
+	}
+	out := new(PortExposure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceBudget) DeepCopyInto(out *ResourceBudget) {
+	*out = *in
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = new(int64)
+		**out = **in
+	}
+	if in.LoadBalancers != nil {
+		in, out := &in.LoadBalancers, &out.LoadBalancers
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ContainerCPU != nil {
+		in, out := &in.ContainerCPU, &out.ContainerCPU
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.ContainerMemory != nil {
+		in, out := &in.ContainerMemory, &out.ContainerMemory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceBudget.
+func (in *ResourceBudget) DeepCopy() *ResourceBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeyReference) DeepCopyInto(out *SecretKeyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyReference.
+func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
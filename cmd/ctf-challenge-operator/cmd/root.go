@@ -1,20 +1,40 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/access"
 	"github.com/backbone81/ctf-challenge-operator/internal/controller"
+	apikeycontroller "github.com/backbone81/ctf-challenge-operator/internal/controller/apikey"
+	challengeinstancecontroller "github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstance"
+	"github.com/backbone81/ctf-challenge-operator/internal/portallocator"
+	"github.com/backbone81/ctf-challenge-operator/internal/tcpgateway"
 	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+	"github.com/backbone81/ctf-challenge-operator/internal/webhook"
+	"github.com/backbone81/ctf-challenge-operator/internal/webhook/challengedescription"
+	"github.com/backbone81/ctf-challenge-operator/internal/webproxy"
 )
 
 var (
@@ -29,6 +49,52 @@ var (
 
 	kubernetesClientQPS   float32
 	kubernetesClientBurst int
+
+	migratePlaintextFlags bool
+
+	namespacePrefix        string
+	podSecurityLevel       string
+	maxPodSecurityLevel    string
+	ingressNamespace       string
+	dnsNamespace           string
+	dnsPodSelector         string
+	nodeAddress            string
+	exposureDomain         string
+	exposureMode           string
+	ingressClassName       string
+	gatewayName            string
+	gatewayNamespace       string
+	portRange              string
+	defaultCPU             string
+	defaultMemory          string
+	defaultStorage         string
+	defaultPods            int64
+	defaultLoadBalancers   int64
+	defaultContainerCPU    string
+	defaultContainerMemory string
+
+	webhookEnabled           bool
+	webhookPort              int
+	webhookCertDir           string
+	webhookSelfSignedCert    bool
+	webhookCertSecretName    string
+	webhookServiceName       string
+	webhookServiceNamespace  string
+	webhookConfigurationName string
+	maxInstancesPerOwner     int
+	allowedManifestKinds     []string
+
+	challengeInstanceExpirationBounds utils.ExpirationBounds
+	apiKeyExpirationBounds            utils.ExpirationBounds
+
+	tcpGatewayBindAddress string
+	tcpGatewayDomain      string
+	tcpGatewayCertDir     string
+
+	webProxyBindAddress    string
+	webProxyCertDir        string
+	webProxySessionKey     string
+	webProxySessionSeconds int
 )
 
 var rootCmd = &cobra.Command{
@@ -66,30 +132,315 @@ var rootCmd = &cobra.Command{
 					BindAddress: metricsBindAddress,
 				},
 				HealthProbeBindAddress: healthProbeBindAddress,
+				WebhookServer: ctrlwebhook.NewServer(ctrlwebhook.Options{
+					Port:    webhookPort,
+					CertDir: webhookCertDir,
+				}),
 			},
 		)
 		if err != nil {
 			return fmt.Errorf("setting up manager: %w", err)
 		}
 
+		if err := validatePodSecurityLevels(); err != nil {
+			return err
+		}
+		if err := validateNamespacePrefix(); err != nil {
+			return err
+		}
+		defaultResourceBudget, err := getDefaultResourceBudget()
+		if err != nil {
+			return err
+		}
+		networkPolicyConfig, err := getNetworkPolicyConfig()
+		if err != nil {
+			return err
+		}
+		exposureConfig, err := getExposureConfig()
+		if err != nil {
+			return err
+		}
+		portAllocator, err := getPortAllocator()
+		if err != nil {
+			return err
+		}
+		reconcilerOptions := []controller.ReconcilerOption{
+			controller.WithDefaultReconcilers(
+				mgr.GetEventRecorderFor("ctf-challenge-operator"),
+				namespacePrefix,
+				v1alpha1.PodSecurityLevel(podSecurityLevel),
+				v1alpha1.PodSecurityLevel(maxPodSecurityLevel),
+				networkPolicyConfig,
+				defaultResourceBudget,
+				nodeAddress,
+				exposureConfig,
+				portAllocator,
+			),
+		}
+		if migratePlaintextFlags {
+			reconcilerOptions = append(reconcilerOptions, controller.WithFlagMigrationReconciler())
+		}
 		reconciler := controller.NewReconciler(
 			utils.NewLoggingClient(mgr.GetClient(), logger),
-			controller.WithDefaultReconcilers(mgr.GetEventRecorderFor("ctf-challenge-operator")),
+			reconcilerOptions...,
 		)
 		if err := reconciler.SetupWithManager(mgr); err != nil {
 			return fmt.Errorf("setting up reconciler with manager: %w", err)
 		}
 
+		ctx := ctrl.SetupSignalHandler()
+		if len(tcpGatewayBindAddress) != 0 || len(webProxyBindAddress) != 0 {
+			if err := access.SetupIndexes(ctx, mgr.GetFieldIndexer()); err != nil {
+				return fmt.Errorf("setting up field indexes: %w", err)
+			}
+		}
+
+		if len(tcpGatewayBindAddress) != 0 {
+			if err := setupTCPGateway(mgr, logger); err != nil {
+				return err
+			}
+		}
+
+		if len(webProxyBindAddress) != 0 {
+			if err := setupWebProxy(mgr, logger); err != nil {
+				return err
+			}
+		}
+
+		if webhookEnabled {
+			if err := setupWebhook(ctx, mgr, logger); err != nil {
+				return err
+			}
+		}
+
 		if err := mgr.AddHealthzCheck("health", healthz.Ping); err != nil {
 			return fmt.Errorf("setting up health check: %w", err)
 		}
 		if err := mgr.AddReadyzCheck("ready", healthz.Ping); err != nil {
 			return fmt.Errorf("setting up ready check: %w", err)
 		}
-		return mgr.Start(ctrl.SetupSignalHandler())
+		return mgr.Start(ctx)
 	},
 }
 
+// validateNamespacePrefix verifies that the namespace prefix provided on the command line results in valid namespace
+// names when the UID of a challenge instance is appended.
+func validateNamespacePrefix() error {
+	namespaceName := challengeinstancecontroller.GetNamespaceName(namespacePrefix, &v1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			UID: "00000000-0000-0000-0000-000000000000",
+		},
+	})
+	if errs := validation.IsDNS1123Label(namespaceName); len(errs) != 0 {
+		return fmt.Errorf("invalid --namespace-prefix %q: %s", namespacePrefix, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// validatePodSecurityLevels verifies that the pod security levels provided on the command line are valid levels of the
+// Pod Security Standards.
+func validatePodSecurityLevels() error {
+	podSecurityLevels := []struct {
+		flagName string
+		value    string
+	}{
+		{"pod-security-level", podSecurityLevel},
+		{"max-pod-security-level", maxPodSecurityLevel},
+	}
+	for _, level := range podSecurityLevels {
+		switch v1alpha1.PodSecurityLevel(level.value) {
+		case v1alpha1.PodSecurityLevelPrivileged, v1alpha1.PodSecurityLevelBaseline, v1alpha1.PodSecurityLevelRestricted:
+		default:
+			return fmt.Errorf("invalid --%s %q: must be one of privileged, baseline or restricted", level.flagName, level.value)
+		}
+	}
+	return nil
+}
+
+// getDefaultResourceBudget returns the resource budget of challenge instances which is configured on the command line.
+// Empty quantities and negative counts leave the resource unlimited.
+func getDefaultResourceBudget() (v1alpha1.ResourceBudget, error) {
+	var result v1alpha1.ResourceBudget
+	quantities := []struct {
+		flagName string
+		value    string
+		target   **resource.Quantity
+	}{
+		{"default-cpu", defaultCPU, &result.CPU},
+		{"default-memory", defaultMemory, &result.Memory},
+		{"default-storage", defaultStorage, &result.Storage},
+		{"default-container-cpu", defaultContainerCPU, &result.ContainerCPU},
+		{"default-container-memory", defaultContainerMemory, &result.ContainerMemory},
+	}
+	for _, quantity := range quantities {
+		if len(quantity.value) == 0 {
+			continue
+		}
+		parsed, err := resource.ParseQuantity(quantity.value)
+		if err != nil {
+			return v1alpha1.ResourceBudget{}, fmt.Errorf("parsing --%s: %w", quantity.flagName, err)
+		}
+		*quantity.target = &parsed
+	}
+	if defaultPods >= 0 {
+		result.Pods = &defaultPods
+	}
+	if defaultLoadBalancers >= 0 {
+		result.LoadBalancers = &defaultLoadBalancers
+	}
+	return result, nil
+}
+
+// getNetworkPolicyConfig returns the traffic which is allowed for all challenge instances as configured on the command
+// line.
+func getNetworkPolicyConfig() (challengeinstancecontroller.NetworkPolicyConfig, error) {
+	dnsPodLabels, err := labels.ConvertSelectorToLabelsMap(dnsPodSelector)
+	if err != nil {
+		return challengeinstancecontroller.NetworkPolicyConfig{}, fmt.Errorf("parsing --dns-pod-selector: %w", err)
+	}
+	return challengeinstancecontroller.NetworkPolicyConfig{
+		IngressNamespace: ingressNamespace,
+		DNSNamespace:     dnsNamespace,
+		DNSPodLabels:     dnsPodLabels,
+	}, nil
+}
+
+// getExposureConfig returns the exposure of challenge instances which is configured on the command line. A leading
+// wildcard label of the domain is accepted for convenience.
+func getExposureConfig() (challengeinstancecontroller.ExposureConfig, error) {
+	result := challengeinstancecontroller.ExposureConfig{
+		Domain:           strings.TrimPrefix(exposureDomain, "*."),
+		Mode:             challengeinstancecontroller.ExposureMode(exposureMode),
+		IngressClassName: ingressClassName,
+		GatewayName:      gatewayName,
+		GatewayNamespace: gatewayNamespace,
+	}
+	switch result.Mode {
+	case challengeinstancecontroller.ExposureModeIngress:
+	case challengeinstancecontroller.ExposureModeProxy:
+	case challengeinstancecontroller.ExposureModeGateway:
+		if len(result.GatewayName) == 0 {
+			return challengeinstancecontroller.ExposureConfig{}, errors.New("--gateway-name is required with --exposure-mode gateway")
+		}
+	default:
+		return challengeinstancecontroller.ExposureConfig{}, fmt.Errorf("invalid --exposure-mode %q: must be one of ingress, gateway or proxy", exposureMode)
+	}
+	return result, nil
+}
+
+// getPortAllocator returns the allocator for the port range which is configured on the command line. It returns nil
+// when no port range is configured.
+func getPortAllocator() (*portallocator.Allocator, error) {
+	if len(portRange) == 0 {
+		return nil, nil
+	}
+	parsedPortRange, err := portallocator.ParseRange(portRange)
+	if err != nil {
+		return nil, fmt.Errorf("parsing --port-range: %w", err)
+	}
+	return portallocator.New(parsedPortRange), nil
+}
+
+// setupTCPGateway registers the TCP gateway with the manager.
+func setupTCPGateway(mgr ctrl.Manager, logger logr.Logger) error {
+	domain := tcpGatewayDomain
+	if len(domain) == 0 {
+		domain = exposureDomain
+	}
+	gateway, err := tcpgateway.New(
+		utils.NewLoggingClient(mgr.GetClient(), logger),
+		logger.WithName("tcp-gateway"),
+		tcpgateway.Options{
+			BindAddress: tcpGatewayBindAddress,
+			Domain:      domain,
+			CertDir:     tcpGatewayCertDir,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("setting up TCP gateway: %w", err)
+	}
+	if err := mgr.Add(gateway); err != nil {
+		return fmt.Errorf("setting up TCP gateway with manager: %w", err)
+	}
+	return nil
+}
+
+// setupWebProxy registers the web proxy with the manager.
+func setupWebProxy(mgr ctrl.Manager, logger logr.Logger) error {
+	if len(exposureDomain) == 0 {
+		return errors.New("--exposure-domain is required with --web-proxy-bind-address")
+	}
+	proxy, err := webproxy.New(
+		utils.NewLoggingClient(mgr.GetClient(), logger),
+		logger.WithName("web-proxy"),
+		webproxy.Options{
+			BindAddress:     webProxyBindAddress,
+			Domain:          strings.TrimPrefix(exposureDomain, "*."),
+			CertDir:         webProxyCertDir,
+			SessionKey:      []byte(webProxySessionKey),
+			SessionDuration: time.Duration(webProxySessionSeconds) * time.Second,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("setting up web proxy: %w", err)
+	}
+	if err := mgr.Add(proxy); err != nil {
+		return fmt.Errorf("setting up web proxy with manager: %w", err)
+	}
+	return nil
+}
+
+// setupWebhook registers the admission webhooks with the manager. When requested, a self-signed certificate is
+// provided to the webhook server beforehand.
+func setupWebhook(ctx context.Context, mgr ctrl.Manager, logger logr.Logger) error {
+	if err := challengeInstanceExpirationBounds.Validate(challengeInstanceExpirationBounds.DefaultSeconds); err != nil {
+		return fmt.Errorf("validating default expiration of challenge instances: %w", err)
+	}
+	if err := apiKeyExpirationBounds.Validate(apiKeyExpirationBounds.DefaultSeconds); err != nil {
+		return fmt.Errorf("validating default expiration of API keys: %w", err)
+	}
+
+	groupKinds := make([]schema.GroupKind, 0, len(allowedManifestKinds))
+	for _, allowedManifestKind := range allowedManifestKinds {
+		groupKinds = append(groupKinds, schema.ParseGroupKind(allowedManifestKind))
+	}
+
+	if webhookSelfSignedCert {
+		// The cache of the manager is not yet running, so we need a client which talks to the API server directly.
+		k8sClient, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme()})
+		if err != nil {
+			return fmt.Errorf("setting up kubernetes client: %w", err)
+		}
+		if err := webhook.EnsureCertificate(ctx, utils.NewLoggingClient(k8sClient, logger), webhook.CertificateOptions{
+			CertDir:                  webhookCertDir,
+			SecretNamespace:          webhookServiceNamespace,
+			SecretName:               webhookCertSecretName,
+			ServiceNamespace:         webhookServiceNamespace,
+			ServiceName:              webhookServiceName,
+			WebhookConfigurationName: webhookConfigurationName,
+		}); err != nil {
+			return fmt.Errorf("setting up webhook certificate: %w", err)
+		}
+	}
+
+	admissionWebhook := webhook.NewWebhook(
+		utils.NewLoggingClient(mgr.GetClient(), logger),
+		webhook.WithDefaultWebhooks(
+			maxInstancesPerOwner,
+			groupKinds,
+			challengeInstanceExpirationBounds,
+			apiKeyExpirationBounds,
+		),
+	)
+	if err := admissionWebhook.SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("setting up webhook with manager: %w", err)
+	}
+	if err := mgr.AddReadyzCheck("webhook", mgr.GetWebhookServer().StartedChecker()); err != nil {
+		return fmt.Errorf("setting up webhook ready check: %w", err)
+	}
+	return nil
+}
+
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
@@ -117,6 +468,11 @@ func init() {
 
 	initControllerRuntime()
 	initKubernetesClient()
+	initFlagMigration()
+	initChallengeInstance()
+	initWebhook()
+	initTCPGateway()
+	initWebProxy()
 }
 
 func initControllerRuntime() {
@@ -169,6 +525,317 @@ func initKubernetesClient() {
 	)
 }
 
+func initFlagMigration() {
+	rootCmd.PersistentFlags().BoolVar(
+		&migratePlaintextFlags,
+		"migrate-plaintext-flags",
+		false,
+		"Move plain text flags of ChallengeDescriptions into secrets and reference those secrets instead.",
+	)
+}
+
+func initChallengeInstance() {
+	rootCmd.PersistentFlags().StringVar(
+		&namespacePrefix,
+		"namespace-prefix",
+		challengeinstancecontroller.DefaultNamespacePrefix,
+		"The prefix of the namespace names of ChallengeInstances. The UID of the ChallengeInstance is appended.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&podSecurityLevel,
+		"pod-security-level",
+		string(challengeinstancecontroller.DefaultPodSecurityLevel),
+		"The Pod Security Standard which is enforced in the namespaces of ChallengeInstances, unless the "+
+			"ChallengeDescription declares otherwise. One of privileged, baseline or restricted.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&maxPodSecurityLevel,
+		"max-pod-security-level",
+		string(challengeinstancecontroller.DefaultMaxPodSecurityLevel),
+		"The most permissive Pod Security Standard a ChallengeDescription can request. More permissive requests "+
+			"are lowered to this level. One of privileged, baseline or restricted.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&ingressNamespace,
+		"ingress-namespace",
+		challengeinstancecontroller.DefaultIngressNamespace,
+		"The namespace which is allowed to connect to all ChallengeInstances, usually the namespace of the ingress "+
+			"controller. Empty denies all ingress from other namespaces.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&dnsNamespace,
+		"dns-namespace",
+		challengeinstancecontroller.DefaultDNSNamespace,
+		"The namespace of the cluster DNS. ChallengeInstances are only allowed to send DNS lookups to the cluster DNS. "+
+			"Empty allows the DNS pods in all namespaces.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&dnsPodSelector,
+		"dns-pod-selector",
+		labels.FormatLabels(challengeinstancecontroller.DefaultDNSPodLabels),
+		"The labels of the cluster DNS pods as a comma separated list of key=value pairs. Empty allows all pods in "+
+			"the DNS namespace.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&nodeAddress,
+		"node-address",
+		"",
+		"The host name or IP address under which node ports of ChallengeInstances are reachable by players. Empty "+
+			"uses the external or internal IP address of a node.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&exposureDomain,
+		"exposure-domain",
+		"",
+		"The wildcard domain under which host names are generated for ChallengeInstances whose ChallengeDescription "+
+			"requests an exposure, for example chal.example.org. Empty disables the exposure.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&exposureMode,
+		"exposure-mode",
+		string(challengeinstancecontroller.ExposureModeIngress),
+		"The kind of object which is generated for the exposure of ChallengeInstances. One of ingress, gateway or "+
+			"proxy. With proxy, no object is generated and ChallengeInstances are reached through the web proxy.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&ingressClassName,
+		"ingress-class-name",
+		"",
+		"The ingress class of the generated ingresses. Empty uses the default ingress class of the cluster.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&gatewayName,
+		"gateway-name",
+		"",
+		"The name of the Gateway the generated HTTPRoutes are attached to. Required with --exposure-mode gateway.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&gatewayNamespace,
+		"gateway-namespace",
+		"",
+		"The namespace of the Gateway the generated HTTPRoutes are attached to. Empty uses the namespace of the "+
+			"ChallengeInstance.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&portRange,
+		"port-range",
+		"",
+		"The range of external ports which are allocated for ChallengeInstances whose ChallengeDescription requests "+
+			"a port exposure, for example 31000-31999. Node ports must be within the node port range of the "+
+			"cluster. Empty disables the port exposure.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&defaultCPU,
+		"default-cpu",
+		"2",
+		"The sum of CPU limits of all containers of a ChallengeInstance, unless the ChallengeDescription declares "+
+			"otherwise. Empty disables the limit.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&defaultMemory,
+		"default-memory",
+		"2Gi",
+		"The sum of memory limits of all containers of a ChallengeInstance, unless the ChallengeDescription "+
+			"declares otherwise. Empty disables the limit.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&defaultStorage,
+		"default-storage",
+		"5Gi",
+		"The sum of storage requests of all persistent volume claims of a ChallengeInstance, unless the "+
+			"ChallengeDescription declares otherwise. Empty disables the limit.",
+	)
+	rootCmd.PersistentFlags().Int64Var(
+		&defaultPods,
+		"default-pods",
+		10,
+		"The number of pods of a ChallengeInstance, unless the ChallengeDescription declares otherwise. Negative "+
+			"values disable the limit.",
+	)
+	rootCmd.PersistentFlags().Int64Var(
+		&defaultLoadBalancers,
+		"default-load-balancers",
+		0,
+		"The number of services of type LoadBalancer of a ChallengeInstance, unless the ChallengeDescription "+
+			"declares otherwise. Negative values disable the limit.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&defaultContainerCPU,
+		"default-container-cpu",
+		"500m",
+		"The CPU limit of containers which do not declare one, unless the ChallengeDescription declares otherwise. "+
+			"Empty disables the default.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&defaultContainerMemory,
+		"default-container-memory",
+		"256Mi",
+		"The memory limit of containers which do not declare one, unless the ChallengeDescription declares "+
+			"otherwise. Empty disables the default.",
+	)
+}
+
+func initWebhook() {
+	rootCmd.PersistentFlags().BoolVar(
+		&webhookEnabled,
+		"webhook-enabled",
+		false,
+		"Enable the admission webhooks.",
+	)
+	rootCmd.PersistentFlags().IntVar(
+		&webhookPort,
+		"webhook-port",
+		9443,
+		"The port the webhook server listens on.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&webhookCertDir,
+		"webhook-cert-dir",
+		filepath.Join(os.TempDir(), "k8s-webhook-server", "serving-certs"),
+		"The directory containing tls.crt and tls.key for the webhook server.",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&webhookSelfSignedCert,
+		"webhook-self-signed-cert",
+		false,
+		"Generate a self-signed certificate for the webhook server and inject it into the webhook configurations.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&webhookCertSecretName,
+		"webhook-cert-secret-name",
+		"ctf-challenge-operator-webhook-cert",
+		"The name of the secret in the webhook service namespace which stores the self-signed certificate.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&webhookServiceName,
+		"webhook-service-name",
+		"ctf-challenge-operator-webhook",
+		"The name of the service the Kubernetes API server uses to reach the webhook server.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&webhookServiceNamespace,
+		"webhook-service-namespace",
+		"ctf-challenge-operator",
+		"The namespace of the service the Kubernetes API server uses to reach the webhook server.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&webhookConfigurationName,
+		"webhook-configuration-name",
+		"ctf-challenge-operator",
+		"The name of the mutating and validating webhook configurations the self-signed certificate is injected into.",
+	)
+	rootCmd.PersistentFlags().IntVar(
+		&maxInstancesPerOwner,
+		"max-instances-per-owner",
+		0,
+		"The maximum number of concurrently running ChallengeInstances per owner across all challenges. Zero "+
+			"disables the limit. Requires the webhooks to be enabled.",
+	)
+
+	defaultAllowedManifestKinds := make([]string, 0, len(challengedescription.DefaultAllowedManifestKinds))
+	for _, groupKind := range challengedescription.DefaultAllowedManifestKinds {
+		defaultAllowedManifestKinds = append(defaultAllowedManifestKinds, strings.TrimSuffix(groupKind.Kind+"."+groupKind.Group, "."))
+	}
+	rootCmd.PersistentFlags().StringSliceVar(
+		&allowedManifestKinds,
+		"allowed-manifest-kinds",
+		defaultAllowedManifestKinds,
+		"The kinds in the form Kind.group which are allowed in the manifests of ChallengeDescriptions. An empty "+
+			"list allows all namespaced kinds. Requires the webhooks to be enabled.",
+	)
+	rootCmd.PersistentFlags().Int64Var(
+		&challengeInstanceExpirationBounds.DefaultSeconds,
+		"challenge-instance-default-expiration-seconds",
+		challengeinstancecontroller.DefaultExpirationSeconds,
+		"The expiration in seconds of ChallengeInstances which do not request one. Requires the webhooks to be enabled.",
+	)
+	rootCmd.PersistentFlags().Int64Var(
+		&challengeInstanceExpirationBounds.MinSeconds,
+		"challenge-instance-min-expiration-seconds",
+		60,
+		"The minimum expiration in seconds ChallengeInstances can request. Requires the webhooks to be enabled.",
+	)
+	rootCmd.PersistentFlags().Int64Var(
+		&challengeInstanceExpirationBounds.MaxSeconds,
+		"challenge-instance-max-expiration-seconds",
+		24*60*60,
+		"The maximum expiration in seconds ChallengeInstances can request. Requires the webhooks to be enabled.",
+	)
+	rootCmd.PersistentFlags().Int64Var(
+		&apiKeyExpirationBounds.DefaultSeconds,
+		"api-key-default-expiration-seconds",
+		apikeycontroller.DefaultExpirationSeconds,
+		"The expiration in seconds of APIKeys which do not request one. Requires the webhooks to be enabled.",
+	)
+	rootCmd.PersistentFlags().Int64Var(
+		&apiKeyExpirationBounds.MinSeconds,
+		"api-key-min-expiration-seconds",
+		60,
+		"The minimum expiration in seconds APIKeys can request. Requires the webhooks to be enabled.",
+	)
+	rootCmd.PersistentFlags().Int64Var(
+		&apiKeyExpirationBounds.MaxSeconds,
+		"api-key-max-expiration-seconds",
+		7*24*60*60,
+		"The maximum expiration in seconds APIKeys can request. Requires the webhooks to be enabled.",
+	)
+}
+
+func initTCPGateway() {
+	rootCmd.PersistentFlags().StringVar(
+		&tcpGatewayBindAddress,
+		"tcp-gateway-bind-address",
+		"",
+		"The address the TCP gateway binds to, for example :7000. The gateway forwards players to the port "+
+			"exposure of their ChallengeInstance. Empty disables the gateway.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&tcpGatewayDomain,
+		"tcp-gateway-domain",
+		"",
+		"The domain of the host names players connect to through the TCP gateway. Empty uses the exposure domain.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&tcpGatewayCertDir,
+		"tcp-gateway-cert-dir",
+		"",
+		"The directory which contains the wildcard certificate tls.crt and the private key tls.key for TLS "+
+			"connections to the TCP gateway. Empty only accepts plain TCP connections.",
+	)
+}
+
+func initWebProxy() {
+	rootCmd.PersistentFlags().StringVar(
+		&webProxyBindAddress,
+		"web-proxy-bind-address",
+		"",
+		"The address the web proxy binds to, for example :8443. The proxy forwards players to the exposure of their "+
+			"ChallengeInstance under the exposure domain. Use together with --exposure-mode=proxy. Empty disables "+
+			"the proxy.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&webProxyCertDir,
+		"web-proxy-cert-dir",
+		"",
+		"The directory which contains the wildcard certificate tls.crt and the private key tls.key for HTTPS. Empty "+
+			"serves plain HTTP, which requires TLS to be terminated in front of the proxy.",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&webProxySessionKey,
+		"web-proxy-session-key",
+		"",
+		"The key the session cookies of the web proxy are signed with. Should be provided through the environment. "+
+			"Empty generates a random key, which invalidates sessions on restart and does not share them between "+
+			"replicas.",
+	)
+	rootCmd.PersistentFlags().IntVar(
+		&webProxySessionSeconds,
+		"web-proxy-session-seconds",
+		int(webproxy.DefaultSessionDuration.Seconds()),
+		"The time in seconds a session cookie of the web proxy is valid for, unless the APIKey expires earlier.",
+	)
+}
+
 func bindFlagsToViper(cmd *cobra.Command) error {
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv()
@@ -179,7 +846,12 @@ func bindFlagsToViper(cmd *cobra.Command) error {
 
 	var resultErr error
 	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
-		if err := cmd.Flags().Set(flag.Name, fmt.Sprint(viper.Get(flag.Name))); err != nil {
+		value := viper.Get(flag.Name)
+		if values, ok := value.([]string); ok {
+			// Slices need to be provided in the same comma separated form which is used on the command line.
+			value = strings.Join(values, ",")
+		}
+		if err := cmd.Flags().Set(flag.Name, fmt.Sprint(value)); err != nil {
 			resultErr = errors.Join(resultErr, err)
 		}
 	})
@@ -0,0 +1,158 @@
+// Package access decides if a player may connect to a challenge instance. Players authenticate with the key of an
+// APIKey, and the owner of that APIKey needs to match the owner of the challenge instance.
+package access
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+)
+
+// ErrInvalidAPIKey is returned when no APIKey with the given key exists or the APIKey is expired.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// ErrForbidden is returned when the owner of the APIKey does not match the owner of the challenge instance.
+var ErrForbidden = errors.New("the API key does not belong to the owner of the challenge instance")
+
+// ErrInactive is returned when the challenge instance is being deleted or expired.
+var ErrInactive = errors.New("the challenge instance is not active")
+
+// ErrUnknownInstance is returned when no challenge instance is placed in the given namespace.
+var ErrUnknownInstance = errors.New("unknown challenge instance")
+
+// StatusNamespaceField is the name of the field index which maps the namespace of the workload to the challenge
+// instance.
+const StatusNamespaceField = "status.namespace"
+
+// SetupIndexes registers the field indexes FindChallengeInstance relies on.
+func SetupIndexes(ctx context.Context, fieldIndexer client.FieldIndexer) error {
+	return fieldIndexer.IndexField(ctx, &v1alpha1.ChallengeInstance{}, StatusNamespaceField, IndexStatusNamespace)
+}
+
+// IndexStatusNamespace returns the namespace of the workload of the given challenge instance for the field index
+// StatusNamespaceField.
+func IndexStatusNamespace(obj client.Object) []string {
+	challengeInstance, ok := obj.(*v1alpha1.ChallengeInstance)
+	if !ok || len(challengeInstance.Status.Namespace) == 0 {
+		return nil
+	}
+	return []string{challengeInstance.Status.Namespace}
+}
+
+// ParseHost returns the namespace of the challenge instance from the given host name, which is the name of the
+// namespace followed by the given domain. Host names without domain are taken as namespace directly.
+func ParseHost(host string, domain string) (string, bool) {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if len(domain) != 0 {
+		host = strings.TrimSuffix(host, "."+strings.ToLower(domain))
+	}
+	if len(host) == 0 || strings.Contains(host, ".") {
+		return "", false
+	}
+	return host, true
+}
+
+// FindChallengeInstance returns the challenge instance whose workload is placed in the given namespace.
+// ErrUnknownInstance is returned when there is no such challenge instance. The client needs to provide the field index
+// registered by SetupIndexes.
+func FindChallengeInstance(ctx context.Context, k8sClient client.Client, namespace string) (*v1alpha1.ChallengeInstance, error) {
+	var challengeInstanceList v1alpha1.ChallengeInstanceList
+	if err := k8sClient.List(ctx, &challengeInstanceList, client.MatchingFields{StatusNamespaceField: namespace}); err != nil {
+		return nil, err
+	}
+	if len(challengeInstanceList.Items) == 0 {
+		return nil, ErrUnknownInstance
+	}
+	return &challengeInstanceList.Items[0], nil
+}
+
+// FindAPIKey returns the APIKey in the given namespace which carries the given key. ErrInvalidAPIKey is returned when
+// there is no such APIKey or when it is expired.
+func FindAPIKey(ctx context.Context, k8sClient client.Client, namespace string, key string) (*v1alpha1.APIKey, error) {
+	var apiKeyList v1alpha1.APIKeyList
+	if err := k8sClient.List(ctx, &apiKeyList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	return MatchAPIKey(apiKeyList.Items, key, time.Now())
+}
+
+// MatchAPIKey returns the APIKey out of the given list which carries the given key and is not expired at the given
+// point in time. The keys are compared in constant time.
+func MatchAPIKey(apiKeys []v1alpha1.APIKey, key string, now time.Time) (*v1alpha1.APIKey, error) {
+	if len(key) == 0 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// Hashing both sides gives us values of equal length, so the comparison does not leak the length of the keys.
+	candidateSum := sha256.Sum256([]byte(key))
+	var result *v1alpha1.APIKey
+	for i := range apiKeys {
+		apiKey := &apiKeys[i]
+		if len(apiKey.Status.Key) == 0 {
+			continue
+		}
+		expectedSum := sha256.Sum256([]byte(apiKey.Status.Key))
+		if subtle.ConstantTimeCompare(expectedSum[:], candidateSum[:]) == 1 {
+			result = apiKey
+		}
+	}
+	if result == nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if err := CheckAPIKey(result, now); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CheckAPIKey returns ErrInvalidAPIKey when the given APIKey can not be used at the given point in time, because it
+// has no key yet, is being deleted or is expired.
+func CheckAPIKey(apiKey *v1alpha1.APIKey, now time.Time) error {
+	if len(apiKey.Status.Key) == 0 || !apiKey.DeletionTimestamp.IsZero() {
+		return ErrInvalidAPIKey
+	}
+	if !apiKey.Status.ExpirationTimestamp.IsZero() && !now.Before(apiKey.Status.ExpirationTimestamp.Time) {
+		return ErrInvalidAPIKey
+	}
+	return nil
+}
+
+// Authorize returns ErrForbidden when the given APIKey may not access the given challenge instance. An APIKey may
+// access all challenge instances of its owner. Challenge instances without owner can only be accessed with the APIKey
+// they were requested with.
+func Authorize(challengeInstance *v1alpha1.ChallengeInstance, apiKey *v1alpha1.APIKey) error {
+	if apiKey.Namespace != challengeInstance.Namespace {
+		return ErrForbidden
+	}
+	if len(challengeInstance.Spec.Owner) != 0 {
+		if apiKey.Spec.Owner != challengeInstance.Spec.Owner {
+			return ErrForbidden
+		}
+		return nil
+	}
+	if len(challengeInstance.Spec.APIKeyName) == 0 || challengeInstance.Spec.APIKeyName != apiKey.Name {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// IsActive returns true if the challenge instance can be connected to at the given point in time. Challenge instances
+// which are being deleted or expired can not be connected to.
+func IsActive(challengeInstance *v1alpha1.ChallengeInstance, now time.Time) bool {
+	if !challengeInstance.DeletionTimestamp.IsZero() {
+		return false
+	}
+	if meta.IsStatusConditionTrue(challengeInstance.Status.Conditions, v1alpha1.ChallengeInstanceConditionExpiring) {
+		return false
+	}
+	expirationTimestamp := challengeInstance.Status.ExpirationTimestamp
+	return expirationTimestamp.IsZero() || now.Before(expirationTimestamp.Time)
+}
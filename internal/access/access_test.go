@@ -0,0 +1,214 @@
+package access_test
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/access"
+)
+
+var _ = Describe("MatchAPIKey", func() {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	apiKeys := []v1alpha1.APIKey{
+		newAPIKey("first", "team-a", "key-a", now.Add(time.Hour)),
+		newAPIKey("second", "team-b", "key-b", now.Add(-time.Hour)),
+		newAPIKey("third", "team-c", "", now.Add(time.Hour)),
+	}
+
+	It("should return the API key with the given key", func() {
+		apiKey, err := access.MatchAPIKey(apiKeys, "key-a", now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(apiKey.Name).To(Equal("first"))
+	})
+
+	It("should reject unknown keys", func() {
+		_, err := access.MatchAPIKey(apiKeys, "key-x", now)
+		Expect(err).To(MatchError(access.ErrInvalidAPIKey))
+	})
+
+	It("should reject empty keys", func() {
+		_, err := access.MatchAPIKey(apiKeys, "", now)
+		Expect(err).To(MatchError(access.ErrInvalidAPIKey))
+	})
+
+	It("should reject expired keys", func() {
+		_, err := access.MatchAPIKey(apiKeys, "key-b", now)
+		Expect(err).To(MatchError(access.ErrInvalidAPIKey))
+	})
+})
+
+var _ = Describe("Authorize", func() {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	It("should allow API keys of the owner", func() {
+		apiKey := newAPIKey("first", "team-a", "key-a", now)
+		instance := newInstance("team-a", "")
+		Expect(access.Authorize(&instance, &apiKey)).To(Succeed())
+	})
+
+	It("should reject API keys of other owners", func() {
+		apiKey := newAPIKey("first", "team-b", "key-b", now)
+		instance := newInstance("team-a", "")
+		Expect(access.Authorize(&instance, &apiKey)).To(MatchError(access.ErrForbidden))
+	})
+
+	It("should reject API keys of other namespaces", func() {
+		apiKey := newAPIKey("first", "team-a", "key-a", now)
+		apiKey.Namespace = "other"
+		instance := newInstance("team-a", "")
+		Expect(access.Authorize(&instance, &apiKey)).To(MatchError(access.ErrForbidden))
+	})
+
+	It("should only allow the requesting API key for instances without owner", func() {
+		apiKey := newAPIKey("first", "", "key-a", now)
+		requestingInstance := newInstance("", "first")
+		Expect(access.Authorize(&requestingInstance, &apiKey)).To(Succeed())
+		otherInstance := newInstance("", "second")
+		Expect(access.Authorize(&otherInstance, &apiKey)).To(MatchError(access.ErrForbidden))
+		anonymousInstance := newInstance("", "")
+		Expect(access.Authorize(&anonymousInstance, &apiKey)).To(MatchError(access.ErrForbidden))
+	})
+})
+
+var _ = Describe("ParseHost", func() {
+	It("should return the namespace of host names within the domain", func() {
+		namespace, ok := access.ParseHost("CTF-x7k2p.Chal.Example.org.", "chal.example.org")
+		Expect(ok).To(BeTrue())
+		Expect(namespace).To(Equal("ctf-x7k2p"))
+	})
+
+	It("should accept the namespace without domain", func() {
+		namespace, ok := access.ParseHost("ctf-x7k2p", "chal.example.org")
+		Expect(ok).To(BeTrue())
+		Expect(namespace).To(Equal("ctf-x7k2p"))
+	})
+
+	It("should reject host names of other domains", func() {
+		_, ok := access.ParseHost("ctf-x7k2p.other.example.org", "chal.example.org")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should reject empty host names", func() {
+		_, ok := access.ParseHost(".chal.example.org", "chal.example.org")
+		Expect(ok).To(BeFalse())
+	})
+})
+
+var _ = Describe("IsActive", func() {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	It("should accept instances before their expiration", func() {
+		instance := newInstance("team-a", "")
+		instance.Status.ExpirationTimestamp = metav1.NewTime(now.Add(time.Minute))
+		Expect(access.IsActive(&instance, now)).To(BeTrue())
+	})
+
+	It("should reject expired instances", func() {
+		instance := newInstance("team-a", "")
+		instance.Status.ExpirationTimestamp = metav1.NewTime(now.Add(-time.Minute))
+		Expect(access.IsActive(&instance, now)).To(BeFalse())
+	})
+
+	It("should reject expiring instances", func() {
+		instance := newInstance("team-a", "")
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:   v1alpha1.ChallengeInstanceConditionExpiring,
+			Status: metav1.ConditionTrue,
+			Reason: v1alpha1.ChallengeInstanceReasonExpired,
+		})
+		Expect(access.IsActive(&instance, now)).To(BeFalse())
+	})
+
+	It("should reject instances which are being deleted", func() {
+		instance := newInstance("team-a", "")
+		deletionTimestamp := metav1.NewTime(now)
+		instance.DeletionTimestamp = &deletionTimestamp
+		Expect(access.IsActive(&instance, now)).To(BeFalse())
+	})
+})
+
+var _ = Describe("FindChallengeInstance", func() {
+	var k8sClient client.Client
+
+	BeforeEach(func() {
+		first := newInstance("team-a", "")
+		first.Name = "first"
+		first.Status.Namespace = "ctf-first"
+		second := newInstance("team-b", "")
+		second.Name = "second"
+		second.Status.Namespace = "ctf-second"
+		pending := newInstance("team-c", "")
+		pending.Name = "pending"
+
+		scheme := runtime.NewScheme()
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+		k8sClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithIndex(&v1alpha1.ChallengeInstance{}, access.StatusNamespaceField, access.IndexStatusNamespace).
+			WithObjects(&first, &second, &pending).
+			Build()
+	})
+
+	It("should return the instance owning the namespace", func() {
+		instance, err := access.FindChallengeInstance(context.Background(), k8sClient, "ctf-second")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.Name).To(Equal("second"))
+	})
+
+	It("should reject unknown namespaces", func() {
+		_, err := access.FindChallengeInstance(context.Background(), k8sClient, "ctf-unknown")
+		Expect(err).To(MatchError(access.ErrUnknownInstance))
+	})
+})
+
+var _ = Describe("IndexStatusNamespace", func() {
+	It("should index the namespace of the workload", func() {
+		instance := newInstance("team-a", "")
+		instance.Status.Namespace = "ctf-first"
+		Expect(access.IndexStatusNamespace(&instance)).To(Equal([]string{"ctf-first"}))
+	})
+
+	It("should not index instances without a namespace", func() {
+		instance := newInstance("team-a", "")
+		Expect(access.IndexStatusNamespace(&instance)).To(BeEmpty())
+	})
+})
+
+func newAPIKey(name string, owner string, key string, expirationTimestamp time.Time) v1alpha1.APIKey {
+	return v1alpha1.APIKey{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+		},
+		Spec: v1alpha1.APIKeySpec{
+			Owner: owner,
+		},
+		Status: v1alpha1.APIKeyStatus{
+			Key:                 key,
+			ExpirationTimestamp: metav1.NewTime(expirationTimestamp),
+		},
+	}
+}
+
+func newInstance(owner string, apiKeyName string) v1alpha1.ChallengeInstance {
+	return v1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "test",
+		},
+		Spec: v1alpha1.ChallengeInstanceSpec{
+			Owner:      owner,
+			APIKeyName: apiKeyName,
+		},
+	}
+}
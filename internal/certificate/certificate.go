@@ -0,0 +1,125 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+)
+
+const (
+	// DefaultValidity is the duration generated certificates are valid for.
+	DefaultValidity = 365 * 24 * time.Hour
+
+	// DefaultRenewBefore is the duration before the expiration of a certificate at which it is renewed.
+	DefaultRenewBefore = 30 * 24 * time.Hour
+)
+
+// Bundle is a self-signed certificate authority together with a serving certificate signed by it. All fields are PEM
+// encoded.
+type Bundle struct {
+	CACert []byte
+	Cert   []byte
+	Key    []byte
+}
+
+// Generate creates a new certificate authority and a serving certificate for the given DNS names, which are valid for
+// the given duration.
+func Generate(dnsNames []string, validity time.Duration) (Bundle, error) {
+	if len(dnsNames) == 0 {
+		return Bundle{}, errors.New("at least one DNS name is required")
+	}
+	// We backdate the certificates a bit to be tolerant against clock skew.
+	now := time.Now()
+	notBefore := now.Add(-time.Hour)
+	notAfter := now.Add(validity)
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return Bundle{}, fmt.Errorf("generating CA key: %w", err)
+	}
+	caTemplate, err := newTemplate(dnsNames[0]+" CA", notBefore, notAfter)
+	if err != nil {
+		return Bundle{}, err
+	}
+	caTemplate.IsCA = true
+	caTemplate.BasicConstraintsValid = true
+	caTemplate.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return Bundle{}, fmt.Errorf("creating CA certificate: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return Bundle{}, fmt.Errorf("generating key: %w", err)
+	}
+	template, err := newTemplate(dnsNames[0], notBefore, notAfter)
+	if err != nil {
+		return Bundle{}, err
+	}
+	template.DNSNames = dnsNames
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	der, err := x509.CreateCertificate(rand.Reader, template, caTemplate, &key.PublicKey, caKey)
+	if err != nil {
+		return Bundle{}, fmt.Errorf("creating certificate: %w", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return Bundle{}, fmt.Errorf("marshalling key: %w", err)
+	}
+	return Bundle{
+		CACert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		Cert:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		Key:    pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
+
+// NeedsRenewal reports if the given bundle is incomplete, does not cover all given DNS names or expires within the
+// given duration.
+func NeedsRenewal(bundle Bundle, dnsNames []string, renewBefore time.Duration) bool {
+	if len(bundle.CACert) == 0 || len(bundle.Key) == 0 {
+		return true
+	}
+	block, _ := pem.Decode(bundle.Cert)
+	if block == nil {
+		return true
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return true
+	}
+	if time.Now().Add(renewBefore).After(cert.NotAfter) {
+		return true
+	}
+	for _, dnsName := range dnsNames {
+		if !slices.Contains(cert.DNSNames, dnsName) {
+			return true
+		}
+	}
+	return false
+}
+
+func newTemplate(commonName string, notBefore time.Time, notAfter time.Time) (*x509.Certificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("generating serial number: %w", err)
+	}
+	return &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName: commonName,
+		},
+		NotBefore: notBefore,
+		NotAfter:  notAfter,
+	}, nil
+}
@@ -0,0 +1,59 @@
+package certificate_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/internal/certificate"
+)
+
+var _ = Describe("Certificate", func() {
+	dnsNames := []string{"webhook.test.svc", "webhook.test.svc.cluster.local"}
+
+	It("should generate a certificate signed by the certificate authority", func() {
+		bundle, err := certificate.Generate(dnsNames, time.Hour)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = tls.X509KeyPair(bundle.Cert, bundle.Key)
+		Expect(err).ToNot(HaveOccurred())
+
+		roots := x509.NewCertPool()
+		Expect(roots.AppendCertsFromPEM(bundle.CACert)).To(BeTrue())
+		block, _ := pem.Decode(bundle.Cert)
+		Expect(block).ToNot(BeNil())
+		cert, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).ToNot(HaveOccurred())
+		for _, dnsName := range dnsNames {
+			_, err = cert.Verify(x509.VerifyOptions{
+				DNSName: dnsName,
+				Roots:   roots,
+			})
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+
+	It("should reject missing DNS names", func() {
+		_, err := certificate.Generate(nil, time.Hour)
+		Expect(err).To(HaveOccurred())
+	})
+
+	DescribeTable("renewal",
+		func(validity time.Duration, requiredDNSNames []string, needsRenewal bool) {
+			bundle, err := certificate.Generate(dnsNames, validity)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(certificate.NeedsRenewal(bundle, requiredDNSNames, 24*time.Hour)).To(Equal(needsRenewal))
+		},
+		Entry("valid certificate", 48*time.Hour, dnsNames, false),
+		Entry("certificate about to expire", 12*time.Hour, dnsNames, true),
+		Entry("missing DNS name", 48*time.Hour, []string{"other.test.svc"}, true),
+	)
+
+	It("should renew an empty bundle", func() {
+		Expect(certificate.NeedsRenewal(certificate.Bundle{}, dnsNames, time.Hour)).To(BeTrue())
+	})
+})
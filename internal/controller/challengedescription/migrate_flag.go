@@ -0,0 +1,94 @@
+package challengedescription
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+// FlagSecretKey is the key of the flag in the secret created by the migration.
+const FlagSecretKey = "flag"
+
+// MigrateFlagReconciler is responsible for moving plain text flags of challenge descriptions into a secret. The
+// challenge description references the secret afterwards.
+type MigrateFlagReconciler struct {
+	utils.DefaultSubReconciler
+}
+
+func NewMigrateFlagReconciler(client client.Client) *MigrateFlagReconciler {
+	return &MigrateFlagReconciler{
+		DefaultSubReconciler: utils.NewDefaultSubReconciler(client),
+	}
+}
+
+func (r *MigrateFlagReconciler) Reconcile(ctx context.Context, challengeDescription *v1alpha1.ChallengeDescription) (ctrl.Result, error) {
+	if !challengeDescription.DeletionTimestamp.IsZero() {
+		// We do not migrate the flag when the resource is already being deleted.
+		return ctrl.Result{}, nil
+	}
+
+	if len(challengeDescription.Spec.Flag) == 0 {
+		// There is no plain text flag to migrate.
+		return ctrl.Result{}, nil
+	}
+
+	secret, err := r.reconcileFlagSecret(ctx, challengeDescription)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The patch fails when the challenge description was changed since it was read. This prevents us from dropping a
+	// flag which was changed in the meantime. The next reconcile migrates the current flag.
+	patch := client.MergeFromWithOptions(challengeDescription.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	challengeDescription.Spec.Flag = ""
+	challengeDescription.Spec.FlagSecretRef = &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{
+			Name: secret.Name,
+		},
+		Key: FlagSecretKey,
+	}
+	challengeDescription.Spec.FlagHash = nil
+	if err := r.GetClient().Patch(ctx, challengeDescription, patch); err != nil {
+		if apierrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// reconcileFlagSecret creates or updates the secret which holds the flag of the challenge description.
+func (r *MigrateFlagReconciler) reconcileFlagSecret(ctx context.Context, challengeDescription *v1alpha1.ChallengeDescription) (*corev1.Secret, error) {
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: challengeDescription.Namespace,
+			Name:      GetFlagSecretName(challengeDescription),
+		},
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.GetClient(), &secret, func() error {
+		if owner := metav1.GetControllerOf(&secret); !secret.CreationTimestamp.IsZero() && (owner == nil || owner.UID != challengeDescription.UID) {
+			return fmt.Errorf("secret %s/%s already exists and is not owned by the challenge description", secret.Namespace, secret.Name)
+		}
+		secret.Data = map[string][]byte{
+			FlagSecretKey: []byte(challengeDescription.Spec.Flag),
+		}
+		return controllerutil.SetControllerReference(challengeDescription, &secret, r.GetClient().Scheme())
+	}); err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// GetFlagSecretName returns the name of the secret the flag of the given challenge description is migrated to.
+func GetFlagSecretName(challengeDescription *v1alpha1.ChallengeDescription) string {
+	return challengeDescription.Name + "-flag"
+}
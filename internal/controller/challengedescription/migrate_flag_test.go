@@ -0,0 +1,200 @@
+package challengedescription_test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengedescription"
+	"github.com/backbone81/ctf-challenge-operator/internal/flag"
+	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+var _ = Describe("MigrateFlagReconciler", func() {
+	var reconciler *utils.Reconciler[*v1alpha1.ChallengeDescription]
+
+	BeforeEach(func() {
+		reconciler = challengedescription.NewReconciler(k8sClient, challengedescription.WithMigrateFlagReconciler())
+	})
+
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+	})
+
+	It("should move the plain text flag into a secret", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeDescription{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeDescriptionSpec{
+				Title:       "test",
+				Description: "test",
+				Flag:        "CTF{test}",
+				Manifests: []runtime.RawExtension{
+					{
+						Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`),
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Spec.Flag).To(BeEmpty())
+		Expect(instance.Spec.FlagSecretRef).ToNot(BeNil())
+		Expect(instance.Spec.FlagSecretRef.Name).To(Equal(challengedescription.GetFlagSecretName(&instance)))
+
+		var secret corev1.Secret
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Namespace,
+			Name:      instance.Spec.FlagSecretRef.Name,
+		}, &secret)).To(Succeed())
+		Expect(metav1.IsControlledBy(&secret, &instance)).To(BeTrue())
+
+		staticFlag, err := flag.GetStatic(ctx, k8sClient, &instance)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(staticFlag).To(Equal("CTF{test}"))
+	})
+
+	It("should not touch descriptions without plain text flag", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeDescription{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeDescriptionSpec{
+				Title:       "test",
+				Description: "test",
+				FlagHash: &v1alpha1.FlagHash{
+					Algorithm: v1alpha1.FlagHashAlgorithmSHA256,
+					Salt:      "01234567",
+					Hash:      flag.Hash("01234567", "CTF{test}"),
+				},
+				Manifests: []runtime.RawExtension{
+					{
+						Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`),
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+		resourceVersion := instance.ResourceVersion
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.ResourceVersion).To(Equal(resourceVersion))
+		Expect(instance.Spec.FlagSecretRef).To(BeNil())
+	})
+
+	It("should not migrate a flag which was changed in the meantime", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := newDescriptionWithFlag("CTF{old}")
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+		staleInstance := instance.DeepCopy()
+
+		instance.Spec.Flag = "CTF{new}"
+		Expect(k8sClient.Update(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := challengedescription.NewMigrateFlagReconciler(k8sClient).Reconcile(ctx, staleInstance)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Requeue).To(BeTrue())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Spec.Flag).To(Equal("CTF{new}"))
+		Expect(instance.Spec.FlagSecretRef).To(BeNil())
+
+		By("run the reconciler again")
+		_, err = reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		staticFlag, err := flag.GetStatic(ctx, k8sClient, &instance)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(staticFlag).To(Equal("CTF{new}"))
+	})
+
+	It("should keep the description valid when it has flag matchers and stages", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := newDescriptionWithFlag("CTF{test}")
+		instance.Spec.Flags = []v1alpha1.FlagMatcher{
+			{Type: v1alpha1.FlagMatcherTypeRegex, Value: `CTF\{[0-9]+\}`},
+		}
+		instance.Spec.Stages = []v1alpha1.ChallengeStage{
+			{
+				Name:   "first",
+				Points: 100,
+				Flags: []v1alpha1.FlagMatcher{
+					{Type: v1alpha1.FlagMatcherTypeCaseInsensitive, Value: "ctf{stage}"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Spec.Flag).To(BeEmpty())
+		Expect(instance.Spec.FlagSecretRef).ToNot(BeNil())
+		Expect(instance.Spec.Flags).To(HaveLen(1))
+		Expect(instance.Spec.Stages).To(HaveLen(1))
+	})
+
+	It("should reject a plain text flag together with a flag hash", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := newDescriptionWithFlag("CTF{test}")
+		instance.Spec.FlagHash = &v1alpha1.FlagHash{
+			Algorithm: v1alpha1.FlagHashAlgorithmSHA256,
+			Salt:      "01234567",
+			Hash:      flag.Hash("01234567", "CTF{test}"),
+		}
+
+		By("verify all postconditions")
+		Expect(k8sClient.Create(ctx, &instance)).ToNot(Succeed())
+	})
+})
+
+// newDescriptionWithFlag returns a challenge description with the given plain text flag without creating it.
+func newDescriptionWithFlag(plainTextFlag string) v1alpha1.ChallengeDescription {
+	return v1alpha1.ChallengeDescription{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeDescriptionSpec{
+			Title:       "test",
+			Description: "test",
+			Flag:        plainTextFlag,
+			Manifests: []runtime.RawExtension{
+				{
+					Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,28 @@
+package challengedescription
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+// +kubebuilder:rbac:groups=core.ctf.backbone81,resources=challengedescriptions,verbs=get;list;watch;update;patch
+
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+
+func NewReconciler(client client.Client, options ...utils.ReconcilerOption[*v1alpha1.ChallengeDescription]) *utils.Reconciler[*v1alpha1.ChallengeDescription] {
+	return utils.NewReconciler[*v1alpha1.ChallengeDescription](
+		client,
+		func() *v1alpha1.ChallengeDescription {
+			return &v1alpha1.ChallengeDescription{}
+		},
+		options...,
+	)
+}
+
+func WithMigrateFlagReconciler() utils.ReconcilerOption[*v1alpha1.ChallengeDescription] {
+	return func(reconciler *utils.Reconciler[*v1alpha1.ChallengeDescription]) {
+		reconciler.AppendSubReconciler(NewMigrateFlagReconciler(reconciler.GetClient()))
+	}
+}
@@ -0,0 +1,161 @@
+package challengedescription_test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/flag"
+)
+
+var _ = Describe("Validation", func() {
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+	})
+
+	DescribeTable("flag matchers",
+		func(ctx SpecContext, flagMatcher v1alpha1.FlagMatcher, valid bool) {
+			instance := v1alpha1.ChallengeDescription{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "test-",
+					Namespace:    corev1.NamespaceDefault,
+				},
+				Spec: v1alpha1.ChallengeDescriptionSpec{
+					Title:       "test",
+					Description: "test",
+					Flags: []v1alpha1.FlagMatcher{
+						flagMatcher,
+					},
+					Manifests: []runtime.RawExtension{
+						{
+							Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`),
+						},
+					},
+				},
+			}
+			if valid {
+				Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+			} else {
+				Expect(k8sClient.Create(ctx, &instance)).ToNot(Succeed())
+			}
+		},
+		Entry("static flag",
+			v1alpha1.FlagMatcher{Type: v1alpha1.FlagMatcherTypeStatic, Value: "CTF{test}"},
+			true,
+		),
+		Entry("valid regular expression",
+			v1alpha1.FlagMatcher{Type: v1alpha1.FlagMatcherTypeRegex, Value: `CTF\{[0-9]+\}`},
+			true,
+		),
+		Entry("missing value",
+			v1alpha1.FlagMatcher{Type: v1alpha1.FlagMatcherTypeStatic},
+			false,
+		),
+	)
+
+	DescribeTable("stages",
+		func(ctx SpecContext, stages []v1alpha1.ChallengeStage, valid bool) {
+			instance := v1alpha1.ChallengeDescription{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "test-",
+					Namespace:    corev1.NamespaceDefault,
+				},
+				Spec: v1alpha1.ChallengeDescriptionSpec{
+					Title:       "test",
+					Description: "test",
+					Stages:      stages,
+					Manifests: []runtime.RawExtension{
+						{
+							Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`),
+						},
+					},
+				},
+			}
+			if valid {
+				Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+			} else {
+				Expect(k8sClient.Create(ctx, &instance)).ToNot(Succeed())
+			}
+		},
+		Entry("single stage",
+			[]v1alpha1.ChallengeStage{
+				{Name: "first", Flags: []v1alpha1.FlagMatcher{{Type: v1alpha1.FlagMatcherTypeStatic, Value: "CTF{first}"}}},
+			},
+			true,
+		),
+		Entry("stage without flags",
+			[]v1alpha1.ChallengeStage{
+				{Name: "first"},
+			},
+			false,
+		),
+		Entry("duplicate stage names",
+			[]v1alpha1.ChallengeStage{
+				{Name: "first", Flags: []v1alpha1.FlagMatcher{{Type: v1alpha1.FlagMatcherTypeStatic, Value: "CTF{first}"}}},
+				{Name: "first", Flags: []v1alpha1.FlagMatcher{{Type: v1alpha1.FlagMatcherTypeStatic, Value: "CTF{second}"}}},
+			},
+			false,
+		),
+		Entry("no stages",
+			[]v1alpha1.ChallengeStage{},
+			false,
+		),
+	)
+
+	DescribeTable("static flags of cluster challenge descriptions",
+		func(ctx SpecContext, modify func(spec *v1alpha1.ClusterChallengeDescriptionSpec), valid bool) {
+			instance := v1alpha1.ClusterChallengeDescription{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "test-",
+				},
+				Spec: v1alpha1.ClusterChallengeDescriptionSpec{
+					ChallengeDescriptionSpec: v1alpha1.ChallengeDescriptionSpec{
+						Title:       "test",
+						Description: "test",
+						Manifests: []runtime.RawExtension{
+							{
+								Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`),
+							},
+						},
+					},
+				},
+			}
+			modify(&instance.Spec)
+			if valid {
+				Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+			} else {
+				Expect(k8sClient.Create(ctx, &instance)).To(MatchError(ContainSubstring("flag is not supported")))
+			}
+		},
+		Entry("plain text flag",
+			func(spec *v1alpha1.ClusterChallengeDescriptionSpec) {
+				spec.Flag = "CTF{test}"
+			},
+			false,
+		),
+		Entry("flag hash",
+			func(spec *v1alpha1.ClusterChallengeDescriptionSpec) {
+				spec.FlagHash = &v1alpha1.FlagHash{
+					Algorithm: v1alpha1.FlagHashAlgorithmSHA256,
+					Salt:      "01234567",
+					Hash:      flag.Hash("01234567", "CTF{test}"),
+				}
+			},
+			true,
+		),
+		Entry("flag secret",
+			func(spec *v1alpha1.ClusterChallengeDescriptionSpec) {
+				spec.SecretNamespace = corev1.NamespaceDefault
+				spec.FlagSecretRef = &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "test"},
+					Key:                  "flag",
+				}
+			},
+			true,
+		),
+	)
+})
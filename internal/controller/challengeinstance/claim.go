@@ -0,0 +1,71 @@
+package challengeinstance
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+// ClaimReconciler is responsible for releasing challenge instances from their pool once they are claimed. A challenge
+// instance is claimed by setting its owner or API key. The claim is recorded in the status, which starts the
+// expiration of the challenge instance.
+type ClaimReconciler struct {
+	utils.DefaultSubReconciler
+}
+
+func NewClaimReconciler(client client.Client) *ClaimReconciler {
+	return &ClaimReconciler{
+		DefaultSubReconciler: utils.NewDefaultSubReconciler(client),
+	}
+}
+
+func (r *ClaimReconciler) Reconcile(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) (ctrl.Result, error) {
+	if !challengeInstance.DeletionTimestamp.IsZero() {
+		// We do not claim the resource when the resource is already being deleted.
+		return ctrl.Result{}, nil
+	}
+
+	poolName, ok := challengeInstance.Labels[v1alpha1.ChallengeInstancePoolLabelName]
+	if !ok || IsUnclaimed(challengeInstance) {
+		return ctrl.Result{}, nil
+	}
+
+	// The claim is recorded before the challenge instance leaves the pool, so that it is not lost when the update
+	// below fails.
+	if challengeInstance.Status.ClaimTimestamp.IsZero() {
+		challengeInstance.Status.ClaimTimestamp = metav1.Now()
+		if err := r.GetClient().Status().Update(ctx, challengeInstance); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// The owner reference to the pool is removed as well, so that deleting the pool does not delete claimed challenge
+	// instances.
+	delete(challengeInstance.Labels, v1alpha1.ChallengeInstancePoolLabelName)
+	var ownerReferences []metav1.OwnerReference
+	for _, ownerReference := range challengeInstance.OwnerReferences {
+		if ownerReference.Kind == "ChallengeInstancePool" && ownerReference.Name == poolName {
+			continue
+		}
+		ownerReferences = append(ownerReferences, ownerReference)
+	}
+	challengeInstance.OwnerReferences = ownerReferences
+	if err := r.GetClient().Update(ctx, challengeInstance); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// IsUnclaimed returns true if the given challenge instance waits in its pool to be claimed. Unclaimed challenge
+// instances do not expire.
+func IsUnclaimed(challengeInstance *v1alpha1.ChallengeInstance) bool {
+	if _, ok := challengeInstance.Labels[v1alpha1.ChallengeInstancePoolLabelName]; !ok {
+		return false
+	}
+	return len(challengeInstance.Spec.Owner) == 0 && len(challengeInstance.Spec.APIKeyName) == 0
+}
@@ -0,0 +1,131 @@
+package challengeinstance_test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstance"
+	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+var _ = Describe("ClaimReconciler", func() {
+	var reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]
+
+	BeforeEach(func() {
+		reconciler = challengeinstance.NewReconciler(k8sClient, challengeinstance.WithClaimReconciler())
+	})
+
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+	})
+
+	It("should keep unclaimed instances in the pool", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := newPooledInstance()
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Labels).To(HaveKeyWithValue(v1alpha1.ChallengeInstancePoolLabelName, "test"))
+		Expect(instance.OwnerReferences).To(HaveLen(1))
+		Expect(instance.Status.ClaimTimestamp.IsZero()).To(BeTrue())
+		Expect(challengeinstance.IsUnclaimed(&instance)).To(BeTrue())
+	})
+
+	It("should release claimed instances from the pool", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := newPooledInstance()
+		instance.Spec.Owner = "team-a"
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Labels).ToNot(HaveKey(v1alpha1.ChallengeInstancePoolLabelName))
+		Expect(instance.OwnerReferences).To(BeEmpty())
+		Expect(instance.Status.ClaimTimestamp.IsZero()).To(BeFalse())
+	})
+
+	It("should only allow setting the owner and the API key once", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := newPooledInstance()
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("claim the instance")
+		instance.Spec.Owner = "team-a"
+		instance.Spec.APIKeyName = "test"
+		Expect(k8sClient.Update(ctx, &instance)).To(Succeed())
+
+		By("change the owner")
+		instance.Spec.Owner = "team-b"
+		Expect(k8sClient.Update(ctx, &instance)).To(MatchError(ContainSubstring("owner can not be changed once it is set")))
+
+		By("remove the owner")
+		instance.Spec.Owner = ""
+		Expect(k8sClient.Update(ctx, &instance)).To(MatchError(ContainSubstring("owner can not be changed once it is set")))
+
+		By("remove the API key")
+		instance.Spec.Owner = "team-a"
+		instance.Spec.APIKeyName = ""
+		Expect(k8sClient.Update(ctx, &instance)).To(MatchError(ContainSubstring("apiKeyName can not be changed once it is set")))
+	})
+
+	It("should ignore instances which are not part of a pool", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+		Expect(challengeinstance.IsUnclaimed(&instance)).To(BeFalse())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.ClaimTimestamp.IsZero()).To(BeTrue())
+	})
+})
+
+// newPooledInstance returns a challenge instance which looks like it was created by a pool with the name "test".
+func newPooledInstance() v1alpha1.ChallengeInstance {
+	return v1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+			Labels: map[string]string{
+				v1alpha1.ChallengeInstancePoolLabelName: "test",
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: v1alpha1.GroupVersion.String(),
+					Kind:       "ChallengeInstancePool",
+					Name:       "test",
+					UID:        "00000000-0000-0000-0000-000000000000",
+					Controller: ptr.To(true),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,131 @@
+package challengeinstance
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+)
+
+// readyConditionTypes are the conditions which all need to be true for the challenge instance to be ready. If one of
+// them is false, the challenge instance is degraded.
+var readyConditionTypes = []string{
+	v1alpha1.ChallengeInstanceConditionNamespaceReady,
+	v1alpha1.ChallengeInstanceConditionNetworkIsolated,
+	v1alpha1.ChallengeInstanceConditionResourcesLimited,
+	v1alpha1.ChallengeInstanceConditionFlagReady,
+	v1alpha1.ChallengeInstanceConditionManifestsApplied,
+	v1alpha1.ChallengeInstanceConditionExposed,
+	v1alpha1.ChallengeInstanceConditionPortAllocated,
+	v1alpha1.ChallengeInstanceConditionWorkloadsReady,
+}
+
+// setCondition sets the given condition on the challenge instance and persists the status if anything changed. The
+// aggregated conditions, the phase and the observed generation are updated along the way.
+func setCondition(ctx context.Context, k8sClient client.Client, challengeInstance *v1alpha1.ChallengeInstance, conditionType string, status metav1.ConditionStatus, reason string, message string) error {
+	changed := meta.SetStatusCondition(&challengeInstance.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: challengeInstance.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+	if summarize(challengeInstance) {
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return k8sClient.Status().Update(ctx, challengeInstance)
+}
+
+// summarize updates the aggregated conditions, the phase and the observed generation of the challenge instance. It
+// returns true if anything changed.
+func summarize(challengeInstance *v1alpha1.ChallengeInstance) bool {
+	changed := false
+	if challengeInstance.Status.ObservedGeneration != challengeInstance.Generation {
+		challengeInstance.Status.ObservedGeneration = challengeInstance.Generation
+		changed = true
+	}
+	if meta.SetStatusCondition(&challengeInstance.Status.Conditions, getReadyCondition(challengeInstance)) {
+		changed = true
+	}
+	if challengeInstance.DeletionTimestamp.IsZero() {
+		// We do not consider an instance as degraded when it is being torn down.
+		if meta.SetStatusCondition(&challengeInstance.Status.Conditions, getDegradedCondition(challengeInstance)) {
+			changed = true
+		}
+	}
+	if phase := getPhase(challengeInstance); challengeInstance.Status.Phase != phase {
+		challengeInstance.Status.Phase = phase
+		changed = true
+	}
+	return changed
+}
+
+func getReadyCondition(challengeInstance *v1alpha1.ChallengeInstance) metav1.Condition {
+	for _, conditionType := range readyConditionTypes {
+		condition := meta.FindStatusCondition(challengeInstance.Status.Conditions, conditionType)
+		if condition == nil || condition.Status != metav1.ConditionTrue {
+			return metav1.Condition{
+				Type:               v1alpha1.ChallengeInstanceConditionReady,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: challengeInstance.Generation,
+				Reason:             v1alpha1.ChallengeInstanceReasonProvisioning,
+				Message:            "Waiting for " + conditionType,
+			}
+		}
+	}
+	return metav1.Condition{
+		Type:               v1alpha1.ChallengeInstanceConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: challengeInstance.Generation,
+		Reason:             v1alpha1.ChallengeInstanceReasonReady,
+		Message:            "The challenge instance is ready",
+	}
+}
+
+func getDegradedCondition(challengeInstance *v1alpha1.ChallengeInstance) metav1.Condition {
+	for _, conditionType := range readyConditionTypes {
+		condition := meta.FindStatusCondition(challengeInstance.Status.Conditions, conditionType)
+		if condition != nil && condition.Status == metav1.ConditionFalse {
+			return metav1.Condition{
+				Type:               v1alpha1.ChallengeInstanceConditionDegraded,
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: challengeInstance.Generation,
+				Reason:             v1alpha1.ChallengeInstanceReasonDegraded,
+				Message:            conditionType + ": " + condition.Message,
+			}
+		}
+	}
+	return metav1.Condition{
+		Type:               v1alpha1.ChallengeInstanceConditionDegraded,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: challengeInstance.Generation,
+		Reason:             v1alpha1.ChallengeInstanceReasonHealthy,
+		Message:            "The challenge instance is not degraded",
+	}
+}
+
+func getPhase(challengeInstance *v1alpha1.ChallengeInstance) v1alpha1.ChallengeInstancePhase {
+	conditions := challengeInstance.Status.Conditions
+	switch {
+	case meta.IsStatusConditionTrue(conditions, v1alpha1.ChallengeInstanceConditionExpiring):
+		return v1alpha1.ChallengeInstancePhaseExpiring
+	case !challengeInstance.DeletionTimestamp.IsZero():
+		return v1alpha1.ChallengeInstancePhaseTerminating
+	case meta.IsStatusConditionTrue(conditions, v1alpha1.ChallengeInstanceConditionDegraded):
+		return v1alpha1.ChallengeInstancePhaseDegraded
+	case meta.IsStatusConditionTrue(conditions, v1alpha1.ChallengeInstanceConditionReady):
+		return v1alpha1.ChallengeInstancePhaseReady
+	}
+	for _, conditionType := range readyConditionTypes {
+		if meta.FindStatusCondition(conditions, conditionType) != nil {
+			return v1alpha1.ChallengeInstancePhaseProvisioning
+		}
+	}
+	return v1alpha1.ChallengeInstancePhasePending
+}
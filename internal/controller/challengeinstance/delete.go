@@ -2,8 +2,10 @@ package challengeinstance
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -11,7 +13,8 @@ import (
 	"github.com/backbone81/ctf-challenge-operator/internal/utils"
 )
 
-// DeleteReconciler is responsible for deleting the challenge instance when it is expired.
+// DeleteReconciler is responsible for deleting the challenge instance when it is expired. Unclaimed challenge instances
+// of a pool do not expire.
 type DeleteReconciler struct {
 	utils.DefaultSubReconciler
 }
@@ -28,12 +31,55 @@ func (r *DeleteReconciler) Reconcile(ctx context.Context, challengeInstance *v1a
 		return ctrl.Result{}, nil
 	}
 
-	if challengeInstance.Status.ExpirationTimestamp.Time.Before(time.Now()) {
+	if IsUnclaimed(challengeInstance) {
+		// The challenge instance does not expire before it is claimed.
+		return ctrl.Result{}, setCondition(
+			ctx,
+			r.GetClient(),
+			challengeInstance,
+			v1alpha1.ChallengeInstanceConditionExpiring,
+			metav1.ConditionFalse,
+			v1alpha1.ChallengeInstanceReasonUnclaimed,
+			"The challenge instance waits in its pool to be claimed",
+		)
+	}
+
+	challengeDescription, err := getLifetimeChallengeDescription(ctx, r.GetClient(), challengeInstance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The maximum lifetime of the challenge description is applied here as well, because it might have been lowered
+	// after the expiration timestamp was calculated.
+	expirationTimestamp := getExpirationTimestamp(challengeInstance, challengeDescription)
+	if expirationTimestamp.Before(time.Now()) {
+		if err := setCondition(
+			ctx,
+			r.GetClient(),
+			challengeInstance,
+			v1alpha1.ChallengeInstanceConditionExpiring,
+			metav1.ConditionTrue,
+			v1alpha1.ChallengeInstanceReasonExpired,
+			fmt.Sprintf("The challenge instance expired at %s", expirationTimestamp.UTC().Format(time.RFC3339)),
+		); err != nil {
+			return ctrl.Result{}, err
+		}
 		if err := r.GetClient().Delete(ctx, challengeInstance); err != nil {
 			return ctrl.Result{}, err
 		}
 		return ctrl.Result{}, nil
 	}
 
-	return ctrl.Result{RequeueAfter: time.Until(challengeInstance.Status.ExpirationTimestamp.Time)}, nil
+	if err := setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionExpiring,
+		metav1.ConditionFalse,
+		v1alpha1.ChallengeInstanceReasonActive,
+		fmt.Sprintf("The challenge instance expires at %s", expirationTimestamp.UTC().Format(time.RFC3339)),
+	); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: time.Until(expirationTimestamp)}, nil
 }
@@ -4,7 +4,9 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -74,6 +76,38 @@ var _ = Describe("DeleteReconciler", func() {
 
 		By("verify all postconditions")
 		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(meta.IsStatusConditionFalse(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionExpiring)).To(BeTrue())
+	})
+
+	It("should mark the instance as expiring when expiration is reached", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+				Finalizers: []string{
+					testutils.DoNotDeleteFinalizerName,
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		instance.Status.ExpirationTimestamp = metav1.NewTime(time.Now().Add(-time.Minute))
+		Expect(k8sClient.Status().Update(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.DeletionTimestamp.IsZero()).To(BeFalse())
+		condition := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionExpiring)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(v1alpha1.ChallengeInstanceReasonExpired))
+		Expect(instance.Status.Phase).To(Equal(v1alpha1.ChallengeInstancePhaseExpiring))
 	})
 
 	It("should not delete the instance when expiration is reached and instance is already deleted", func(ctx SpecContext) {
@@ -104,4 +138,74 @@ var _ = Describe("DeleteReconciler", func() {
 		By("verify all postconditions")
 		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
 	})
+
+	It("should delete the instance when the maximum lifetime is reached", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescriptionWithLifetime(ctx, nil, nil, ptr.To(int64(1)))
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionName: description.Name,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		instance.Status.ExpirationTimestamp = metav1.NewTime(time.Now().Add(time.Hour))
+		Expect(k8sClient.Status().Update(ctx, &instance)).To(Succeed())
+		time.Sleep(2 * time.Second)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(MatchError(ContainSubstring("not found")))
+	})
+
+	It("should requeue at the maximum lifetime when it is before the expiration", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescriptionWithLifetime(ctx, nil, nil, ptr.To(int64(120)))
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionName: description.Name,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		instance.Status.ExpirationTimestamp = metav1.NewTime(time.Now().Add(time.Hour))
+		Expect(k8sClient.Status().Update(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+
+		By("verify all postconditions")
+		Expect(result.RequeueAfter).To(BeNumerically("~", 2*time.Minute, testutils.DurationEpsilon))
+	})
+
+	It("should not expire unclaimed instances of a pool", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := newPooledInstance()
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		condition := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionExpiring)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(v1alpha1.ChallengeInstanceReasonUnclaimed))
+	})
 })
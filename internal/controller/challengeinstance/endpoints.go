@@ -0,0 +1,395 @@
+package challengeinstance
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/description"
+	"github.com/backbone81/ctf-challenge-operator/internal/rendering"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+var (
+	serviceGroupKind   = schema.GroupKind{Group: corev1.GroupName, Kind: "Service"}
+	ingressGroupKind   = schema.GroupKind{Group: networkingv1.GroupName, Kind: "Ingress"}
+	httpRouteGroupKind = schema.GroupKind{Group: "gateway.networking.k8s.io", Kind: "HTTPRoute"}
+)
+
+// EndpointsReconciler is responsible for discovering the endpoints players can connect to and for rendering the
+// connection info of the challenge instance.
+type EndpointsReconciler struct {
+	utils.DefaultSubReconciler
+	recorder    record.EventRecorder
+	nodeAddress string
+}
+
+func NewEndpointsReconciler(client client.Client, recorder record.EventRecorder, nodeAddress string) *EndpointsReconciler {
+	return &EndpointsReconciler{
+		DefaultSubReconciler: utils.NewDefaultSubReconciler(client),
+		recorder:             recorder,
+		nodeAddress:          nodeAddress,
+	}
+}
+
+func (r *EndpointsReconciler) Reconcile(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) (ctrl.Result, error) {
+	if !challengeInstance.DeletionTimestamp.IsZero() {
+		// We do not discover endpoints when the resource is already being deleted.
+		return ctrl.Result{}, nil
+	}
+
+	if !meta.IsStatusConditionTrue(challengeInstance.Status.Conditions, v1alpha1.ChallengeInstanceConditionManifestsApplied) {
+		// There are no endpoints before all manifests were applied.
+		return ctrl.Result{}, nil
+	}
+
+	challengeDescription, err := description.Get(ctx, r.GetClient(), challengeInstance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	endpoints, err := r.discoverExposureEndpoints(ctx, challengeInstance, challengeDescription)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	manifestEndpoints, err := r.discoverEndpoints(ctx, challengeInstance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	endpoints = append(endpoints, manifestEndpoints...)
+
+	// A broken template must not stop the reconciliation of the instance, so we only report it as an event.
+	connectionInfo, err := getConnectionInfo(challengeInstance, challengeDescription, endpoints)
+	if err != nil {
+		r.recorder.Eventf(
+			challengeInstance,
+			corev1.EventTypeWarning,
+			"ConnectionInfo",
+			"Failed to render the connection info: %s",
+			err,
+		)
+	}
+	return ctrl.Result{}, r.updateStatus(ctx, challengeInstance, endpoints, connectionInfo)
+}
+
+// discoverEndpoints returns the endpoints of all services, ingresses and HTTP routes in the inventory of the challenge
+// instance. Objects which do not exist (yet) are skipped.
+func (r *EndpointsReconciler) discoverEndpoints(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) ([]v1alpha1.ChallengeInstanceEndpoint, error) {
+	var result []v1alpha1.ChallengeInstanceEndpoint
+	for _, entry := range challengeInstance.Status.Inventory {
+		key := client.ObjectKey{
+			Namespace: entry.Namespace,
+			Name:      entry.Name,
+		}
+
+		var endpoints []v1alpha1.ChallengeInstanceEndpoint
+		var err error
+		switch schema.FromAPIVersionAndKind(entry.APIVersion, entry.Kind).GroupKind() {
+		case serviceGroupKind:
+			endpoints, err = r.discoverServiceEndpoints(ctx, key)
+		case ingressGroupKind:
+			endpoints, err = r.discoverIngressEndpoints(ctx, key)
+		case httpRouteGroupKind:
+			endpoints, err = r.discoverHTTPRouteEndpoints(ctx, entry.APIVersion, key)
+		default:
+			continue
+		}
+		if err != nil {
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, err
+		}
+		result = append(result, endpoints...)
+	}
+	return result, nil
+}
+
+// discoverExposureEndpoints returns the endpoints of the objects which were generated for the exposure and the port
+// exposure of the challenge description. They come first, because they are the endpoints the challenge is meant to be
+// played through.
+func (r *EndpointsReconciler) discoverExposureEndpoints(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, challengeDescription *v1alpha1.ChallengeDescription) ([]v1alpha1.ChallengeInstanceEndpoint, error) {
+	var result []v1alpha1.ChallengeInstanceEndpoint
+	if exposure := challengeDescription.Spec.Exposure; exposure != nil && len(challengeInstance.Status.Hostname) != 0 {
+		tls := exposure.Protocol == v1alpha1.ExposureProtocolHTTPS
+		if isExposureProxied(challengeInstance) {
+			// There is no object to discover, the web proxy serves the host name of the challenge instance directly.
+			result = append(result, newHTTPEndpoint("ChallengeInstance", challengeInstance.Name, challengeInstance.Status.Hostname, tls))
+		} else {
+			endpoint, err := r.discoverHostnameEndpoint(ctx, challengeInstance, tls)
+			if err != nil {
+				return nil, err
+			}
+			if endpoint != nil {
+				result = append(result, *endpoint)
+			}
+		}
+	}
+	if challengeDescription.Spec.PortExposure != nil && challengeInstance.Status.AllocatedPort != 0 {
+		endpoints, err := r.discoverServiceEndpoints(ctx, client.ObjectKey{
+			Namespace: challengeInstance.Status.Namespace,
+			Name:      PortServiceName,
+		})
+		if client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+		result = append(result, endpoints...)
+	}
+	return result, nil
+}
+
+// isExposureProxied returns true if the exposure of the challenge instance is served by the web proxy of the operator.
+func isExposureProxied(challengeInstance *v1alpha1.ChallengeInstance) bool {
+	condition := meta.FindStatusCondition(challengeInstance.Status.Conditions, v1alpha1.ChallengeInstanceConditionExposed)
+	return condition != nil && condition.Reason == v1alpha1.ChallengeInstanceReasonExposureProxied
+}
+
+// discoverHostnameEndpoint returns the endpoint of the ingress or HTTP route which was generated for the host name of
+// the challenge instance. It returns nil if the object does not exist (yet).
+func (r *EndpointsReconciler) discoverHostnameEndpoint(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, tls bool) (*v1alpha1.ChallengeInstanceEndpoint, error) {
+	// The endpoints reconciler does not know which kind of object was generated, so we look for both.
+	for _, gvk := range []schema.GroupVersionKind{
+		ingressGroupKind.WithVersion("v1"),
+		schema.FromAPIVersionAndKind(gatewayAPIVersion, httpRouteGroupKind.Kind),
+	} {
+		var obj unstructured.Unstructured
+		obj.SetGroupVersionKind(gvk)
+		if err := r.GetClient().Get(ctx, client.ObjectKey{
+			Namespace: challengeInstance.Status.Namespace,
+			Name:      ExposureName,
+		}, &obj); err != nil {
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, err
+		}
+		endpoint := newHTTPEndpoint(gvk.Kind, ExposureName, challengeInstance.Status.Hostname, tls)
+		return &endpoint, nil
+	}
+	return nil, nil
+}
+
+// discoverServiceEndpoints returns the endpoints of services of type NodePort and LoadBalancer. Services of type
+// ClusterIP are not reachable by players and do not have any endpoints.
+func (r *EndpointsReconciler) discoverServiceEndpoints(ctx context.Context, key client.ObjectKey) ([]v1alpha1.ChallengeInstanceEndpoint, error) {
+	var service corev1.Service
+	if err := r.GetClient().Get(ctx, key, &service); err != nil {
+		return nil, err
+	}
+
+	var result []v1alpha1.ChallengeInstanceEndpoint
+	switch service.Spec.Type {
+	case corev1.ServiceTypeNodePort:
+		nodeAddress, err := r.getNodeAddress(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(nodeAddress) == 0 {
+			return nil, nil
+		}
+		for _, port := range service.Spec.Ports {
+			if port.NodePort == 0 {
+				continue
+			}
+			result = append(result, v1alpha1.ChallengeInstanceEndpoint{
+				Kind:     serviceGroupKind.Kind,
+				Name:     service.Name,
+				Host:     nodeAddress,
+				Port:     port.NodePort,
+				Protocol: string(port.Protocol),
+			})
+		}
+	case corev1.ServiceTypeLoadBalancer:
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			host := getLoadBalancerHost(ingress)
+			if len(host) == 0 {
+				continue
+			}
+			for _, port := range service.Spec.Ports {
+				result = append(result, v1alpha1.ChallengeInstanceEndpoint{
+					Kind:     serviceGroupKind.Kind,
+					Name:     service.Name,
+					Host:     host,
+					Port:     port.Port,
+					Protocol: string(port.Protocol),
+				})
+			}
+		}
+	default:
+	}
+	return result, nil
+}
+
+// discoverIngressEndpoints returns one endpoint per host of the ingress. Rules without host are served on the address
+// of the load balancer of the ingress.
+func (r *EndpointsReconciler) discoverIngressEndpoints(ctx context.Context, key client.ObjectKey) ([]v1alpha1.ChallengeInstanceEndpoint, error) {
+	var ingress networkingv1.Ingress
+	if err := r.GetClient().Get(ctx, key, &ingress); err != nil {
+		return nil, err
+	}
+
+	tlsHosts := make(map[string]bool)
+	for _, tls := range ingress.Spec.TLS {
+		for _, host := range tls.Hosts {
+			tlsHosts[host] = true
+		}
+	}
+
+	var hosts []string
+	for _, rule := range ingress.Spec.Rules {
+		if len(rule.Host) != 0 {
+			hosts = append(hosts, rule.Host)
+			continue
+		}
+		for _, loadBalancerIngress := range ingress.Status.LoadBalancer.Ingress {
+			hosts = append(hosts, getIngressLoadBalancerHost(loadBalancerIngress))
+		}
+	}
+
+	var result []v1alpha1.ChallengeInstanceEndpoint
+	seen := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		if len(host) == 0 || seen[host] {
+			continue
+		}
+		seen[host] = true
+		result = append(result, newHTTPEndpoint(ingressGroupKind.Kind, ingress.Name, host, tlsHosts[host]))
+	}
+	return result, nil
+}
+
+// discoverHTTPRouteEndpoints returns one endpoint per host name of the HTTP route. The route is read as unstructured
+// object, because the Gateway API is not necessarily installed in the cluster.
+func (r *EndpointsReconciler) discoverHTTPRouteEndpoints(ctx context.Context, apiVersion string, key client.ObjectKey) ([]v1alpha1.ChallengeInstanceEndpoint, error) {
+	var httpRoute unstructured.Unstructured
+	httpRoute.SetAPIVersion(apiVersion)
+	httpRoute.SetKind(httpRouteGroupKind.Kind)
+	if err := r.GetClient().Get(ctx, key, &httpRoute); err != nil {
+		return nil, err
+	}
+
+	hostnames, _, err := unstructured.NestedStringSlice(httpRoute.Object, "spec", "hostnames")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]v1alpha1.ChallengeInstanceEndpoint, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		result = append(result, newHTTPEndpoint(httpRouteGroupKind.Kind, httpRoute.GetName(), hostname, false))
+	}
+	return result, nil
+}
+
+// getNodeAddress returns the address under which node ports are reachable. Without a configured address, the first
+// external IP of any node is used, falling back to the first internal IP. It returns an empty string if no address is
+// known.
+func (r *EndpointsReconciler) getNodeAddress(ctx context.Context) (string, error) {
+	if len(r.nodeAddress) != 0 {
+		return r.nodeAddress, nil
+	}
+
+	var nodeList corev1.NodeList
+	if err := r.GetClient().List(ctx, &nodeList); err != nil {
+		return "", err
+	}
+	for _, addressType := range []corev1.NodeAddressType{corev1.NodeExternalIP, corev1.NodeInternalIP} {
+		for _, node := range nodeList.Items {
+			for _, address := range node.Status.Addresses {
+				if address.Type == addressType {
+					return address.Address, nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
+func (r *EndpointsReconciler) updateStatus(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, endpoints []v1alpha1.ChallengeInstanceEndpoint, connectionInfo string) error {
+	if equality.Semantic.DeepEqual(challengeInstance.Status.Endpoints, endpoints) &&
+		challengeInstance.Status.ConnectionInfo == connectionInfo {
+		return nil
+	}
+	challengeInstance.Status.Endpoints = endpoints
+	challengeInstance.Status.ConnectionInfo = connectionInfo
+	return r.GetClient().Status().Update(ctx, challengeInstance)
+}
+
+// getConnectionInfo renders the connection info template of the challenge description with the given endpoints.
+func getConnectionInfo(challengeInstance *v1alpha1.ChallengeInstance, challengeDescription *v1alpha1.ChallengeDescription, endpoints []v1alpha1.ChallengeInstanceEndpoint) (string, error) {
+	if len(challengeDescription.Spec.ConnectionInfo) == 0 {
+		return "", nil
+	}
+
+	data := rendering.ConnectionData{
+		Instance:    getRenderingInstance(challengeInstance),
+		Description: getRenderingDescription(challengeDescription),
+		Namespace:   challengeInstance.Status.Namespace,
+	}
+	for _, endpoint := range endpoints {
+		data.Endpoints = append(data.Endpoints, rendering.Endpoint{
+			Kind:     endpoint.Kind,
+			Name:     endpoint.Name,
+			Host:     endpoint.Host,
+			Port:     endpoint.Port,
+			Protocol: endpoint.Protocol,
+			URL:      endpoint.URL,
+		})
+	}
+	if len(data.Endpoints) != 0 {
+		data.Host = data.Endpoints[0].Host
+		data.Port = data.Endpoints[0].Port
+		data.URL = data.Endpoints[0].URL
+	}
+
+	connectionInfo, err := rendering.RenderConnectionInfo(challengeDescription.Spec.ConnectionInfo, data)
+	if err != nil {
+		return "", fmt.Errorf("rendering connection info: %w", err)
+	}
+	return connectionInfo, nil
+}
+
+func newHTTPEndpoint(kind string, name string, host string, tls bool) v1alpha1.ChallengeInstanceEndpoint {
+	if tls {
+		return v1alpha1.ChallengeInstanceEndpoint{
+			Kind:     kind,
+			Name:     name,
+			Host:     host,
+			Port:     443,
+			Protocol: "HTTPS",
+			URL:      "https://" + host,
+		}
+	}
+	return v1alpha1.ChallengeInstanceEndpoint{
+		Kind:     kind,
+		Name:     name,
+		Host:     host,
+		Port:     80,
+		Protocol: "HTTP",
+		URL:      "http://" + host,
+	}
+}
+
+func getLoadBalancerHost(ingress corev1.LoadBalancerIngress) string {
+	if len(ingress.IP) != 0 {
+		return ingress.IP
+	}
+	return ingress.Hostname
+}
+
+func getIngressLoadBalancerHost(ingress networkingv1.IngressLoadBalancerIngress) string {
+	if len(ingress.IP) != 0 {
+		return ingress.IP
+	}
+	return ingress.Hostname
+}
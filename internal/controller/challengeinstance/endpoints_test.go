@@ -0,0 +1,205 @@
+package challengeinstance_test
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstance"
+	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+var _ = Describe("EndpointsReconciler", func() {
+	var reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]
+
+	BeforeEach(func() {
+		reconciler = challengeinstance.NewReconciler(
+			k8sClient,
+			challengeinstance.WithManifestsReconciler(record.NewFakeRecorder(5)),
+			challengeinstance.WithEndpointsReconciler(record.NewFakeRecorder(5), "203.0.113.10"),
+		)
+	})
+
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+	})
+
+	It("should discover node ports and render the connection info", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		service := newService(corev1.ServiceTypeNodePort)
+		instance := createInstanceWithEndpoints(ctx, "nc {{ .Host }} {{ .Port }}", &service)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      service.Name,
+		}, &service)).To(Succeed())
+		nodePort := service.Spec.Ports[0].NodePort
+		Expect(nodePort).ToNot(BeZero())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Endpoints).To(Equal([]v1alpha1.ChallengeInstanceEndpoint{
+			{
+				Kind:     "Service",
+				Name:     service.Name,
+				Host:     "203.0.113.10",
+				Port:     nodePort,
+				Protocol: "TCP",
+			},
+		}))
+		Expect(instance.Status.ConnectionInfo).To(Equal(fmt.Sprintf("nc 203.0.113.10 %d", nodePort)))
+	})
+
+	It("should discover the hosts of ingresses", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		ingress := networkingv1.Ingress{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: networkingv1.SchemeGroupVersion.String(),
+				Kind:       "Ingress",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: testutils.GenerateName("test-"),
+			},
+			Spec: networkingv1.IngressSpec{
+				TLS: []networkingv1.IngressTLS{
+					{
+						Hosts: []string{"secure.example.com"},
+					},
+				},
+				Rules: []networkingv1.IngressRule{
+					{
+						Host: "secure.example.com",
+					},
+					{
+						Host: "plain.example.com",
+					},
+				},
+			},
+		}
+		instance := createInstanceWithEndpoints(ctx, "{{ .URL }}", &ingress)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Endpoints).To(HaveLen(2))
+		Expect(instance.Status.Endpoints[0].URL).To(Equal("https://secure.example.com"))
+		Expect(instance.Status.Endpoints[1].URL).To(Equal("http://plain.example.com"))
+		Expect(instance.Status.ConnectionInfo).To(Equal("https://secure.example.com"))
+	})
+
+	It("should not report cluster internal services", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		service := newService(corev1.ServiceTypeClusterIP)
+		instance := createInstanceWithEndpoints(ctx, "", &service)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Endpoints).To(BeEmpty())
+		Expect(instance.Status.ConnectionInfo).To(BeEmpty())
+	})
+
+	It("should not fail on a broken connection info template", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		service := newService(corev1.ServiceTypeNodePort)
+		instance := createInstanceWithEndpoints(ctx, "{{ .Flag }}", &service)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Endpoints).To(HaveLen(1))
+		Expect(instance.Status.ConnectionInfo).To(BeEmpty())
+	})
+})
+
+func newService(serviceType corev1.ServiceType) corev1.Service {
+	return corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testutils.GenerateName("test-"),
+		},
+		Spec: corev1.ServiceSpec{
+			Type: serviceType,
+			Selector: map[string]string{
+				"app": "test",
+			},
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "tcp",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       1337,
+					TargetPort: intstr.FromInt32(1337),
+				},
+			},
+		},
+	}
+}
+
+func createInstanceWithEndpoints(ctx SpecContext, connectionInfo string, manifests ...client.Object) v1alpha1.ChallengeInstance {
+	description := v1alpha1.ChallengeDescription{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeDescriptionSpec{
+			Title:          "test",
+			Description:    "test",
+			Flag:           "test",
+			ConnectionInfo: connectionInfo,
+		},
+	}
+	for _, manifest := range manifests {
+		manifestRaw, err := ToRaw(manifest)
+		Expect(err).ToNot(HaveOccurred())
+		description.Spec.Manifests = append(description.Spec.Manifests, runtime.RawExtension{
+			Raw: manifestRaw,
+		})
+	}
+	Expect(k8sClient.Create(ctx, &description)).To(Succeed())
+
+	instance := v1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeInstanceSpec{
+			ChallengeDescriptionName: description.Name,
+			ExpirationSeconds:        ptr.To(int64(3600)),
+		},
+	}
+	Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+	CreateInstanceNamespace(ctx, &instance)
+	return instance
+}
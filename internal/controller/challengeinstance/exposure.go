@@ -0,0 +1,308 @@
+package challengeinstance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/description"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+const (
+	// ExposureName is the name of the ingress or HTTP route which is generated in the namespace of every challenge
+	// instance for the exposure of the challenge description.
+	ExposureName = "ctf-exposure"
+
+	// gatewayAPIVersion is the version of the Gateway API the HTTP routes are created with.
+	gatewayAPIVersion = "gateway.networking.k8s.io/v1"
+)
+
+// ExposureMode selects the kind of object which is generated for the exposure of challenge instances.
+type ExposureMode string
+
+const (
+	// ExposureModeIngress generates an Ingress for every challenge instance.
+	ExposureModeIngress ExposureMode = "ingress"
+
+	// ExposureModeGateway generates a Gateway API HTTPRoute for every challenge instance.
+	ExposureModeGateway ExposureMode = "gateway"
+
+	// ExposureModeProxy does not generate any object. The challenge instances are reached through the web proxy of the
+	// operator, which only lets players of the owner of the challenge instance pass.
+	ExposureModeProxy ExposureMode = "proxy"
+)
+
+// ExposureConfig configures how services of challenge instances are exposed to players.
+type ExposureConfig struct {
+	// Domain is the wildcard domain the host names of challenge instances are generated under. An empty domain
+	// disables the exposure.
+	Domain string
+
+	// Mode selects whether an Ingress or an HTTPRoute is generated.
+	Mode ExposureMode
+
+	// IngressClassName is the ingress class of the generated ingresses. Empty uses the default ingress class.
+	IngressClassName string
+
+	// GatewayName is the name of the gateway the generated HTTP routes are attached to.
+	GatewayName string
+
+	// GatewayNamespace is the namespace of the gateway the generated HTTP routes are attached to.
+	GatewayNamespace string
+}
+
+// ExposureReconciler is responsible for exposing a service of the challenge instance under a generated host name. It
+// generates an Ingress or a Gateway API HTTPRoute in the namespace of the challenge instance, which is removed
+// together with the namespace.
+type ExposureReconciler struct {
+	utils.DefaultSubReconciler
+	config ExposureConfig
+}
+
+func NewExposureReconciler(client client.Client, config ExposureConfig) *ExposureReconciler {
+	return &ExposureReconciler{
+		DefaultSubReconciler: utils.NewDefaultSubReconciler(client),
+		config:               config,
+	}
+}
+
+func (r *ExposureReconciler) Reconcile(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) (ctrl.Result, error) {
+	if !challengeInstance.DeletionTimestamp.IsZero() {
+		// We do not expose anything when the resource is already being deleted. The exposure is removed together with
+		// the namespace.
+		return ctrl.Result{}, nil
+	}
+
+	if !meta.IsStatusConditionTrue(challengeInstance.Status.Conditions, v1alpha1.ChallengeInstanceConditionNamespaceReady) {
+		// The exposure can only be created when the namespace exists.
+		return ctrl.Result{}, nil
+	}
+
+	challengeDescription, err := description.Get(ctx, r.GetClient(), challengeInstance)
+	if err != nil {
+		return ctrl.Result{}, errors.Join(err, r.setExposureFailed(ctx, challengeInstance, err))
+	}
+
+	exposure := challengeDescription.Spec.Exposure
+	if exposure == nil {
+		if err := r.deleteExposure(ctx, challengeInstance); err != nil {
+			return ctrl.Result{}, errors.Join(err, r.setExposureFailed(ctx, challengeInstance, err))
+		}
+		if err := r.updateHostname(ctx, challengeInstance, ""); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, setCondition(
+			ctx,
+			r.GetClient(),
+			challengeInstance,
+			v1alpha1.ChallengeInstanceConditionExposed,
+			metav1.ConditionTrue,
+			v1alpha1.ChallengeInstanceReasonExposureNotRequested,
+			"The challenge description does not request an exposure",
+		)
+	}
+
+	if len(r.config.Domain) == 0 {
+		// Without a domain, we can not generate a host name. This is a configuration issue of the operator, which we
+		// report instead of retrying.
+		return ctrl.Result{}, r.setExposureFailed(ctx, challengeInstance, errors.New("no exposure domain is configured for the operator"))
+	}
+
+	hostname := GetHostname(challengeInstance, r.config.Domain)
+	if r.config.Mode == ExposureModeProxy {
+		return ctrl.Result{}, r.reconcileProxy(ctx, challengeInstance, hostname)
+	}
+
+	desiredSpec := r.getDesiredSpec(challengeInstance, exposure, hostname)
+	if err := r.GetClient().Patch(ctx, desiredSpec, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership); err != nil {
+		err = fmt.Errorf("applying %s %s/%s: %w", desiredSpec.GetKind(), desiredSpec.GetNamespace(), desiredSpec.GetName(), err)
+		return ctrl.Result{}, errors.Join(err, r.setExposureFailed(ctx, challengeInstance, err))
+	}
+	if err := r.updateHostname(ctx, challengeInstance, hostname); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionExposed,
+		metav1.ConditionTrue,
+		v1alpha1.ChallengeInstanceReasonExposureApplied,
+		"The service is exposed at "+hostname,
+	)
+}
+
+// reconcileProxy records the host name of a challenge instance which is reached through the web proxy. An ingress or
+// HTTP route which was generated before is removed, because it would allow bypassing the proxy.
+func (r *ExposureReconciler) reconcileProxy(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, hostname string) error {
+	if err := r.deleteExposure(ctx, challengeInstance); err != nil {
+		return errors.Join(err, r.setExposureFailed(ctx, challengeInstance, err))
+	}
+	if err := r.updateHostname(ctx, challengeInstance, hostname); err != nil {
+		return err
+	}
+	return setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionExposed,
+		metav1.ConditionTrue,
+		v1alpha1.ChallengeInstanceReasonExposureProxied,
+		"The service is exposed through the web proxy at "+hostname,
+	)
+}
+
+func (r *ExposureReconciler) setExposureFailed(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, err error) error {
+	return setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionExposed,
+		metav1.ConditionFalse,
+		v1alpha1.ChallengeInstanceReasonExposureFailed,
+		err.Error(),
+	)
+}
+
+func (r *ExposureReconciler) updateHostname(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, hostname string) error {
+	if challengeInstance.Status.Hostname == hostname {
+		return nil
+	}
+	challengeInstance.Status.Hostname = hostname
+	return r.GetClient().Status().Update(ctx, challengeInstance)
+}
+
+// deleteExposure removes the generated ingress or HTTP route when the challenge description no longer requests an
+// exposure. In proxy mode, both kinds of objects are removed.
+func (r *ExposureReconciler) deleteExposure(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) error {
+	gvks := []schema.GroupVersionKind{r.getGroupVersionKind()}
+	if r.config.Mode == ExposureModeProxy {
+		gvks = []schema.GroupVersionKind{
+			ingressGroupKind.WithVersion("v1"),
+			schema.FromAPIVersionAndKind(gatewayAPIVersion, httpRouteGroupKind.Kind),
+		}
+	}
+	for _, gvk := range gvks {
+		var obj unstructured.Unstructured
+		obj.SetGroupVersionKind(gvk)
+		obj.SetNamespace(challengeInstance.Status.Namespace)
+		obj.SetName(ExposureName)
+		if err := r.GetClient().Delete(ctx, &obj); err != nil {
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ExposureReconciler) getGroupVersionKind() schema.GroupVersionKind {
+	if r.config.Mode == ExposureModeGateway {
+		return schema.FromAPIVersionAndKind(gatewayAPIVersion, httpRouteGroupKind.Kind)
+	}
+	return ingressGroupKind.WithVersion("v1")
+}
+
+// getDesiredSpec returns the ingress or HTTP route which routes the generated host name to the exposed service. The
+// objects are built as unstructured objects, because the Gateway API is not necessarily installed in the cluster.
+func (r *ExposureReconciler) getDesiredSpec(challengeInstance *v1alpha1.ChallengeInstance, exposure *v1alpha1.Exposure, hostname string) *unstructured.Unstructured {
+	var spec map[string]any
+	if r.config.Mode == ExposureModeGateway {
+		spec = getHTTPRouteSpec(exposure, hostname, r.config.GatewayName, r.config.GatewayNamespace)
+	} else {
+		spec = getIngressSpec(exposure, hostname, r.config.IngressClassName)
+	}
+
+	result := &unstructured.Unstructured{
+		Object: map[string]any{
+			"spec": spec,
+		},
+	}
+	result.SetGroupVersionKind(r.getGroupVersionKind())
+	result.SetNamespace(challengeInstance.Status.Namespace)
+	result.SetName(ExposureName)
+	result.SetLabels(map[string]string{
+		ManagedByLabelName: ManagedByLabelValue,
+	})
+	return result
+}
+
+func getIngressSpec(exposure *v1alpha1.Exposure, hostname string, ingressClassName string) map[string]any {
+	result := map[string]any{
+		"rules": []any{
+			map[string]any{
+				"host": hostname,
+				"http": map[string]any{
+					"paths": []any{
+						map[string]any{
+							"path":     "/",
+							"pathType": "Prefix",
+							"backend": map[string]any{
+								"service": map[string]any{
+									"name": exposure.ServiceName,
+									"port": map[string]any{
+										"number": int64(exposure.Port),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if len(ingressClassName) != 0 {
+		result["ingressClassName"] = ingressClassName
+	}
+	if exposure.Protocol == v1alpha1.ExposureProtocolHTTPS {
+		// Without a secret name, the ingress controller serves its default certificate, which is expected to be a
+		// wildcard certificate for the exposure domain.
+		result["tls"] = []any{
+			map[string]any{
+				"hosts": []any{hostname},
+			},
+		}
+	}
+	return result
+}
+
+func getHTTPRouteSpec(exposure *v1alpha1.Exposure, hostname string, gatewayName string, gatewayNamespace string) map[string]any {
+	// TLS is configured on the listeners of the gateway, so the protocol does not change the HTTP route.
+	parentRef := map[string]any{
+		"name": gatewayName,
+	}
+	if len(gatewayNamespace) != 0 {
+		parentRef["namespace"] = gatewayNamespace
+	}
+	return map[string]any{
+		"parentRefs": []any{parentRef},
+		"hostnames":  []any{hostname},
+		"rules": []any{
+			map[string]any{
+				"backendRefs": []any{
+					map[string]any{
+						"name": exposure.ServiceName,
+						"port": int64(exposure.Port),
+					},
+				},
+			},
+		},
+	}
+}
+
+// GetHostname returns the host name of the challenge instance under the given domain. The name of the instance
+// namespace is used as host label, because it is unique and a valid DNS label.
+func GetHostname(challengeInstance *v1alpha1.ChallengeInstance, domain string) string {
+	return challengeInstance.Status.Namespace + "." + domain
+}
@@ -0,0 +1,269 @@
+package challengeinstance_test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstance"
+	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+var _ = Describe("ExposureReconciler", func() {
+	var reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]
+
+	BeforeEach(func() {
+		reconciler = newExposureReconciler(challengeinstance.ExposureConfig{
+			Domain:           "chal.example.org",
+			Mode:             challengeinstance.ExposureModeIngress,
+			IngressClassName: "nginx",
+		})
+	})
+
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+	})
+
+	It("should generate an ingress with the host name of the instance", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := createInstanceWithExposure(ctx, &v1alpha1.Exposure{
+			ServiceName: "web",
+			Port:        8080,
+		})
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionExposed)).To(BeTrue())
+		hostname := instance.Status.Namespace + ".chal.example.org"
+		Expect(instance.Status.Hostname).To(Equal(hostname))
+
+		var ingress networkingv1.Ingress
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.ExposureName,
+		}, &ingress)).To(Succeed())
+		Expect(ingress.Labels).To(HaveKeyWithValue(challengeinstance.ManagedByLabelName, challengeinstance.ManagedByLabelValue))
+		Expect(ingress.Spec.IngressClassName).To(Equal(ptr.To("nginx")))
+		Expect(ingress.Spec.TLS).To(BeEmpty())
+		Expect(ingress.Spec.Rules).To(HaveLen(1))
+		Expect(ingress.Spec.Rules[0].Host).To(Equal(hostname))
+		Expect(ingress.Spec.Rules[0].HTTP.Paths).To(HaveLen(1))
+		Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name).To(Equal("web"))
+		Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port.Number).To(Equal(int32(8080)))
+
+		Expect(instance.Status.Endpoints).To(Equal([]v1alpha1.ChallengeInstanceEndpoint{
+			{
+				Kind:     "Ingress",
+				Name:     challengeinstance.ExposureName,
+				Host:     hostname,
+				Port:     80,
+				Protocol: "HTTP",
+				URL:      "http://" + hostname,
+			},
+		}))
+	})
+
+	It("should terminate TLS for HTTPS", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := createInstanceWithExposure(ctx, &v1alpha1.Exposure{
+			ServiceName: "web",
+			Port:        8080,
+			Protocol:    v1alpha1.ExposureProtocolHTTPS,
+		})
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		var ingress networkingv1.Ingress
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.ExposureName,
+		}, &ingress)).To(Succeed())
+		Expect(ingress.Spec.TLS).To(Equal([]networkingv1.IngressTLS{
+			{
+				Hosts: []string{instance.Status.Hostname},
+			},
+		}))
+		Expect(instance.Status.Endpoints).To(HaveLen(1))
+		Expect(instance.Status.Endpoints[0].URL).To(Equal("https://" + instance.Status.Hostname))
+	})
+
+	It("should not generate an ingress in proxy mode", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := createInstanceWithExposure(ctx, &v1alpha1.Exposure{
+			ServiceName: "web",
+			Port:        8080,
+			Protocol:    v1alpha1.ExposureProtocolHTTPS,
+		})
+		_, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		reconciler = newExposureReconciler(challengeinstance.ExposureConfig{
+			Domain: "chal.example.org",
+			Mode:   challengeinstance.ExposureModeProxy,
+		})
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		condition := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionExposed)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(v1alpha1.ChallengeInstanceReasonExposureProxied))
+		hostname := instance.Status.Namespace + ".chal.example.org"
+		Expect(instance.Status.Hostname).To(Equal(hostname))
+
+		var ingress networkingv1.Ingress
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.ExposureName,
+		}, &ingress)).To(MatchError(ContainSubstring("not found")))
+
+		Expect(instance.Status.Endpoints).To(Equal([]v1alpha1.ChallengeInstanceEndpoint{
+			{
+				Kind:     "ChallengeInstance",
+				Name:     instance.Name,
+				Host:     hostname,
+				Port:     443,
+				Protocol: "HTTPS",
+				URL:      "https://" + hostname,
+			},
+		}))
+	})
+
+	It("should delete the ingress when the exposure is removed", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := createInstanceWithExposure(ctx, &v1alpha1.Exposure{
+			ServiceName: "web",
+			Port:        8080,
+		})
+		_, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+
+		var description v1alpha1.ChallengeDescription
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Namespace,
+			Name:      instance.Spec.ChallengeDescriptionName,
+		}, &description)).To(Succeed())
+		description.Spec.Exposure = nil
+		Expect(k8sClient.Update(ctx, &description)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionExposed)).To(BeTrue())
+		Expect(instance.Status.Hostname).To(BeEmpty())
+		Expect(instance.Status.Endpoints).To(BeEmpty())
+
+		var ingress networkingv1.Ingress
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.ExposureName,
+		}, &ingress)).To(MatchError(ContainSubstring("not found")))
+	})
+
+	It("should report a missing exposure domain", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		reconciler = newExposureReconciler(challengeinstance.ExposureConfig{
+			Mode: challengeinstance.ExposureModeIngress,
+		})
+		instance := createInstanceWithExposure(ctx, &v1alpha1.Exposure{
+			ServiceName: "web",
+			Port:        8080,
+		})
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		condition := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionExposed)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(v1alpha1.ChallengeInstanceReasonExposureFailed))
+		Expect(instance.Status.Hostname).To(BeEmpty())
+	})
+})
+
+func newExposureReconciler(config challengeinstance.ExposureConfig) *utils.Reconciler[*v1alpha1.ChallengeInstance] {
+	return challengeinstance.NewReconciler(
+		k8sClient,
+		challengeinstance.WithNamespaceReconciler(
+			challengeinstance.DefaultNamespacePrefix,
+			challengeinstance.DefaultPodSecurityLevel,
+			challengeinstance.DefaultMaxPodSecurityLevel,
+		),
+		challengeinstance.WithManifestsReconciler(record.NewFakeRecorder(5)),
+		challengeinstance.WithExposureReconciler(config),
+		challengeinstance.WithEndpointsReconciler(record.NewFakeRecorder(5), ""),
+	)
+}
+
+func createInstanceWithExposure(ctx SpecContext, exposure *v1alpha1.Exposure) v1alpha1.ChallengeInstance {
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testutils.GenerateName("test-"),
+		},
+	}
+	configMapRaw, err := ToRaw(&configMap)
+	Expect(err).ToNot(HaveOccurred())
+
+	description := v1alpha1.ChallengeDescription{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeDescriptionSpec{
+			Title:       "test",
+			Description: "test",
+			Flag:        "test",
+			Exposure:    exposure,
+			Manifests: []runtime.RawExtension{
+				{
+					Raw: configMapRaw,
+				},
+			},
+		},
+	}
+	Expect(k8sClient.Create(ctx, &description)).To(Succeed())
+
+	instance := v1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeInstanceSpec{
+			ChallengeDescriptionName: description.Name,
+		},
+	}
+	Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+	return instance
+}
@@ -0,0 +1,202 @@
+package challengeinstance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/description"
+	"github.com/backbone81/ctf-challenge-operator/internal/flag"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+const (
+	// FlagSecretName is the name of the secret holding the flag in the namespace of the challenge instance.
+	FlagSecretName = "challenge-flag"
+
+	// FlagSecretKey is the key of the flag in the flag secret.
+	FlagSecretKey = "flag"
+)
+
+// FlagReconciler is responsible for providing the flag of the challenge instance as a secret in the namespace of the
+// challenge instance.
+type FlagReconciler struct {
+	utils.DefaultSubReconciler
+}
+
+func NewFlagReconciler(client client.Client) *FlagReconciler {
+	return &FlagReconciler{
+		DefaultSubReconciler: utils.NewDefaultSubReconciler(client),
+	}
+}
+
+func (r *FlagReconciler) Reconcile(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) (ctrl.Result, error) {
+	if !challengeInstance.DeletionTimestamp.IsZero() {
+		// We do not create the flag when the resource is already being deleted. The secret is removed together with
+		// the namespace.
+		return ctrl.Result{}, nil
+	}
+
+	if !meta.IsStatusConditionTrue(challengeInstance.Status.Conditions, v1alpha1.ChallengeInstanceConditionNamespaceReady) {
+		// The secret can only be created when the namespace exists.
+		return ctrl.Result{}, nil
+	}
+
+	currentSpec, err := r.getFlagSecret(ctx, challengeInstance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	desiredSpec, err := r.getDesiredFlagSecretSpec(ctx, challengeInstance, currentSpec)
+	if errors.Is(err, flag.ErrNoPlainText) {
+		return r.reconcileNoPlainText(ctx, challengeInstance)
+	}
+	if err != nil {
+		return ctrl.Result{}, errors.Join(err, r.setFlagFailed(ctx, challengeInstance, err))
+	}
+
+	if currentSpec == nil {
+		if err := r.GetClient().Create(ctx, desiredSpec); err != nil {
+			return ctrl.Result{}, errors.Join(err, r.setFlagFailed(ctx, challengeInstance, err))
+		}
+	} else if !equality.Semantic.DeepEqual(currentSpec.Data, desiredSpec.Data) {
+		currentSpec.Data = desiredSpec.Data
+		if err := r.GetClient().Update(ctx, currentSpec); err != nil {
+			return ctrl.Result{}, errors.Join(err, r.setFlagFailed(ctx, challengeInstance, err))
+		}
+	}
+
+	flagSecretRef := v1alpha1.SecretKeyReference{
+		Namespace: desiredSpec.Namespace,
+		Name:      desiredSpec.Name,
+		Key:       FlagSecretKey,
+	}
+	if !equality.Semantic.DeepEqual(challengeInstance.Status.FlagSecretRef, &flagSecretRef) {
+		challengeInstance.Status.FlagSecretRef = &flagSecretRef
+		if err := r.GetClient().Status().Update(ctx, challengeInstance); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionFlagReady,
+		metav1.ConditionTrue,
+		v1alpha1.ChallengeInstanceReasonFlagCreated,
+		fmt.Sprintf("Flag is provided in secret %s/%s", desiredSpec.Namespace, desiredSpec.Name),
+	)
+}
+
+// reconcileNoPlainText handles challenge descriptions which do not provide the flag in plain text, because it is only
+// known as hash or only flag matchers are given. There is no way to provide the flag to the workload in that case.
+func (r *FlagReconciler) reconcileNoPlainText(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) (ctrl.Result, error) {
+	if challengeInstance.Status.FlagSecretRef != nil {
+		challengeInstance.Status.FlagSecretRef = nil
+		if err := r.GetClient().Status().Update(ctx, challengeInstance); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionFlagReady,
+		metav1.ConditionTrue,
+		v1alpha1.ChallengeInstanceReasonFlagNotProvided,
+		"The flag is not available in plain text and is not provided to the workload",
+	)
+}
+
+func (r *FlagReconciler) setFlagFailed(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, err error) error {
+	return setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionFlagReady,
+		metav1.ConditionFalse,
+		v1alpha1.ChallengeInstanceReasonFlagFailed,
+		err.Error(),
+	)
+}
+
+func (r *FlagReconciler) getFlagSecret(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) (*corev1.Secret, error) {
+	var secret corev1.Secret
+	if err := r.GetClient().Get(ctx, client.ObjectKey{
+		Namespace: challengeInstance.Status.Namespace,
+		Name:      FlagSecretName,
+	}, &secret); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return &secret, nil
+}
+
+// getFlag returns the flag of the given challenge instance. It returns an empty string if the flag secret is not yet
+// recorded in the status.
+func getFlag(ctx context.Context, k8sClient client.Client, challengeInstance *v1alpha1.ChallengeInstance) (string, error) {
+	flagSecretRef := challengeInstance.Status.FlagSecretRef
+	if flagSecretRef == nil {
+		return "", nil
+	}
+
+	return flag.GetFromSecret(ctx, k8sClient, flagSecretRef)
+}
+
+func (r *FlagReconciler) getDesiredFlagSecretSpec(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, currentSpec *corev1.Secret) (*corev1.Secret, error) {
+	challengeDescription, err := description.Get(ctx, r.GetClient(), challengeInstance)
+	if err != nil {
+		return nil, err
+	}
+
+	flagValue, err := r.getFlagValue(ctx, challengeInstance, challengeDescription, currentSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: challengeInstance.Status.Namespace,
+			Name:      FlagSecretName,
+		},
+		Data: map[string][]byte{
+			FlagSecretKey: []byte(flagValue),
+		},
+	}, nil
+}
+
+func (r *FlagReconciler) getFlagValue(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, challengeDescription *v1alpha1.ChallengeDescription, currentSpec *corev1.Secret) (string, error) {
+	flagGeneration := challengeDescription.Spec.FlagGeneration
+	if !flag.IsGenerated(flagGeneration) {
+		return flag.GetStatic(ctx, r.GetClient(), challengeDescription)
+	}
+
+	if flagGeneration.Mode == v1alpha1.FlagGenerationModeRandom &&
+		currentSpec != nil && len(currentSpec.Data[FlagSecretKey]) != 0 {
+		// Random flags can not be generated again, so we keep the one which was already handed out.
+		return string(currentSpec.Data[FlagSecretKey]), nil
+	}
+
+	var hmacKey []byte
+	if flagGeneration.Mode == v1alpha1.FlagGenerationModeHMAC {
+		if flagGeneration.HMACKeySecretRef == nil {
+			return "", errors.New("no HMAC key secret configured")
+		}
+		var hmacKeySecret corev1.Secret
+		if err := r.GetClient().Get(ctx, client.ObjectKey{
+			Namespace: challengeDescription.Namespace,
+			Name:      flagGeneration.HMACKeySecretRef.Name,
+		}, &hmacKeySecret); err != nil {
+			return "", fmt.Errorf("getting HMAC key secret: %w", err)
+		}
+		hmacKey = hmacKeySecret.Data[flagGeneration.HMACKeySecretRef.Key]
+	}
+	return flag.Generate(flagGeneration, "", string(challengeInstance.UID), hmacKey)
+}
@@ -0,0 +1,164 @@
+package challengeinstance_test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstance"
+	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+var _ = Describe("FlagReconciler", func() {
+	var reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]
+
+	BeforeEach(func() {
+		reconciler = challengeinstance.NewReconciler(
+			k8sClient,
+			challengeinstance.WithNamespaceReconciler(
+				challengeinstance.DefaultNamespacePrefix,
+				challengeinstance.DefaultPodSecurityLevel,
+				challengeinstance.DefaultMaxPodSecurityLevel,
+			),
+			challengeinstance.WithFlagReconciler(),
+		)
+	})
+
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+	})
+
+	It("should provide the static flag", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := createInstanceWithFlagGeneration(ctx, nil)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionFlagReady)).To(BeTrue())
+		Expect(instance.Status.FlagSecretRef).To(Equal(&v1alpha1.SecretKeyReference{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.FlagSecretName,
+			Key:       challengeinstance.FlagSecretKey,
+		}))
+
+		var secret corev1.Secret
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.FlagSecretRef.Namespace,
+			Name:      instance.Status.FlagSecretRef.Name,
+		}, &secret)).To(Succeed())
+		Expect(string(secret.Data[instance.Status.FlagSecretRef.Key])).To(Equal("test"))
+	})
+
+	It("should keep a random flag stable", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := createInstanceWithFlagGeneration(ctx, &v1alpha1.FlagGeneration{
+			Mode:   v1alpha1.FlagGenerationModeRandom,
+			Format: "CTF{%s}",
+			Prefix: "test",
+			Length: 16,
+		})
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		var secret corev1.Secret
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.FlagSecretName,
+		}, &secret)).To(Succeed())
+		generatedFlag := string(secret.Data[challengeinstance.FlagSecretKey])
+		Expect(generatedFlag).To(MatchRegexp(`^CTF\{test_[0-9a-f]{32}\}$`))
+
+		By("run the reconciler")
+		result, err = reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&secret), &secret)).To(Succeed())
+		Expect(string(secret.Data[challengeinstance.FlagSecretKey])).To(Equal(generatedFlag))
+	})
+
+	It("should report a failure when the HMAC key is missing", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := createInstanceWithFlagGeneration(ctx, &v1alpha1.FlagGeneration{
+			Mode:   v1alpha1.FlagGenerationModeHMAC,
+			Format: "CTF{%s}",
+			Length: 16,
+			HMACKeySecretRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: testutils.GenerateName("test-"),
+				},
+				Key: "key",
+			},
+		})
+
+		By("run the reconciler")
+		_, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).To(HaveOccurred())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		condition := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionFlagReady)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(v1alpha1.ChallengeInstanceReasonFlagFailed))
+		Expect(instance.Status.FlagSecretRef).To(BeNil())
+	})
+})
+
+// createInstanceWithFlagGeneration creates a challenge description with the given flag generation and a challenge
+// instance referencing it.
+func createInstanceWithFlagGeneration(ctx SpecContext, flagGeneration *v1alpha1.FlagGeneration) v1alpha1.ChallengeInstance {
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testutils.GenerateName("test-"),
+		},
+	}
+	configMapRaw, err := ToRaw(&configMap)
+	Expect(err).ToNot(HaveOccurred())
+
+	description := v1alpha1.ChallengeDescription{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeDescriptionSpec{
+			Title:          "test",
+			Description:    "test",
+			Flag:           "test",
+			FlagGeneration: flagGeneration,
+			Manifests: []runtime.RawExtension{
+				{
+					Raw: configMapRaw,
+				},
+			},
+		},
+	}
+	Expect(k8sClient.Create(ctx, &description)).To(Succeed())
+
+	instance := v1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeInstanceSpec{
+			ChallengeDescriptionName: description.Name,
+		},
+	}
+	Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+	return instance
+}
@@ -0,0 +1,113 @@
+package challengeinstance
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+)
+
+// inventoryKey identifies an object independent of its API version and UID.
+type inventoryKey struct {
+	Group     string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func newInventoryEntry(obj *unstructured.Unstructured) v1alpha1.InventoryEntry {
+	return v1alpha1.InventoryEntry{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+		UID:        obj.GetUID(),
+	}
+}
+
+func getInventoryKey(entry v1alpha1.InventoryEntry) inventoryKey {
+	return inventoryKey{
+		Group:     schema.FromAPIVersionAndKind(entry.APIVersion, entry.Kind).Group,
+		Kind:      entry.Kind,
+		Namespace: entry.Namespace,
+		Name:      entry.Name,
+	}
+}
+
+// pruneInventory deletes all objects which are listed in the inventory of the challenge instance but are missing from
+// the given desired inventory. Objects which were replaced by somebody else in the meantime are left alone.
+func (r *ManifestsReconciler) pruneInventory(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, desiredInventory []v1alpha1.InventoryEntry) error {
+	desiredKeys := make(map[inventoryKey]struct{}, len(desiredInventory))
+	for _, entry := range desiredInventory {
+		desiredKeys[getInventoryKey(entry)] = struct{}{}
+	}
+
+	for _, entry := range challengeInstance.Status.Inventory {
+		if _, ok := desiredKeys[getInventoryKey(entry)]; ok {
+			continue
+		}
+		if err := r.pruneInventoryEntry(ctx, challengeInstance, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ManifestsReconciler) pruneInventoryEntry(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, entry v1alpha1.InventoryEntry) error {
+	var obj unstructured.Unstructured
+	obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(entry.APIVersion, entry.Kind))
+	if err := r.GetClient().Get(ctx, client.ObjectKey{
+		Namespace: entry.Namespace,
+		Name:      entry.Name,
+	}, &obj); err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			// The object is already gone.
+			return nil
+		}
+		return err
+	}
+	if obj.GetUID() != entry.UID {
+		// The object was replaced by somebody else. We only delete what we created.
+		return nil
+	}
+
+	if err := r.GetClient().Delete(ctx, &obj, client.Preconditions{UID: &entry.UID}); client.IgnoreNotFound(err) != nil {
+		r.recorder.Eventf(
+			challengeInstance,
+			corev1.EventTypeWarning,
+			"Deleting",
+			"Failed to delete %s at %s/%s: %s",
+			obj.GroupVersionKind(),
+			entry.Namespace,
+			entry.Name,
+			err,
+		)
+		return err
+	}
+	r.recorder.Eventf(
+		challengeInstance,
+		corev1.EventTypeNormal,
+		"Deleting",
+		"Deleted %s at %s/%s which is no longer part of the manifests",
+		obj.GroupVersionKind(),
+		entry.Namespace,
+		entry.Name,
+	)
+	return nil
+}
+
+// updateInventory persists the given inventory in the status of the challenge instance if it changed.
+func updateInventory(ctx context.Context, k8sClient client.Client, challengeInstance *v1alpha1.ChallengeInstance, inventory []v1alpha1.InventoryEntry) error {
+	if equality.Semantic.DeepEqual(challengeInstance.Status.Inventory, inventory) {
+		return nil
+	}
+	challengeInstance.Status.Inventory = inventory
+	return k8sClient.Status().Update(ctx, challengeInstance)
+}
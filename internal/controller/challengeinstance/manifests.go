@@ -2,9 +2,12 @@ package challengeinstance
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -13,6 +16,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/description"
+	"github.com/backbone81/ctf-challenge-operator/internal/rendering"
 	"github.com/backbone81/ctf-challenge-operator/internal/utils"
 )
 
@@ -35,62 +40,92 @@ func (r *ManifestsReconciler) Reconcile(ctx context.Context, challengeInstance *
 		return ctrl.Result{}, nil
 	}
 
-	var challengeDescription v1alpha1.ChallengeDescription
-	if err := r.GetClient().Get(ctx, client.ObjectKey{
-		Namespace: challengeInstance.Namespace,
-		Name:      challengeInstance.Spec.ChallengeDescriptionName,
-	}, &challengeDescription); err != nil {
+	if len(challengeInstance.Status.Namespace) == 0 {
+		// The manifests can only be applied when the namespace exists.
+		return ctrl.Result{}, nil
+	}
+
+	challengeDescription, err := description.Get(ctx, r.GetClient(), challengeInstance)
+	if err != nil {
 		r.recorder.Eventf(
 			challengeInstance,
 			corev1.EventTypeWarning,
 			"Creating",
-			"ChallengeDescription could not be found at %s/%s: %s",
-			challengeInstance.Namespace,
-			challengeInstance.Spec.ChallengeDescriptionName,
+			"%s could not be found: %s",
+			description.String(challengeInstance),
 			err,
 		)
-		return ctrl.Result{}, err
-	}
-
-	codecFactory := serializer.NewCodecFactory(clientgoscheme.Scheme)
-	decoder := codecFactory.UniversalDeserializer()
-
-	for _, raw := range challengeDescription.Spec.Manifests {
-		var desiredSpec unstructured.Unstructured
-		if _, _, err := decoder.Decode(raw.Raw, nil, &desiredSpec); err != nil {
-			return ctrl.Result{}, err
+		reason := v1alpha1.ChallengeInstanceReasonManifestsFailed
+		if apierrors.IsNotFound(err) {
+			reason = v1alpha1.ChallengeInstanceReasonDescriptionNotFound
 		}
+		return ctrl.Result{}, errors.Join(err, r.setManifestsFailed(ctx, challengeInstance, reason, err))
+	}
 
-		// We need to make sure that we overwrite the target namespace to prevent challenge instances from placing
-		// workload into unrelated namespaces.
-		desiredSpec.SetNamespace(challengeInstance.Name)
+	desiredSpecs, err := getDesiredManifests(ctx, r.GetClient(), challengeInstance, challengeDescription)
+	if err != nil {
+		return ctrl.Result{}, errors.Join(err, r.setManifestsFailed(ctx, challengeInstance, v1alpha1.ChallengeInstanceReasonManifestsFailed, err))
+	}
 
-		if result, err := r.reconcileManifest(ctx, challengeInstance, &desiredSpec); err != nil || !result.IsZero() {
+	inventory := make([]v1alpha1.InventoryEntry, 0, len(desiredSpecs))
+	for _, desiredSpec := range desiredSpecs {
+		if result, err := r.reconcileManifest(ctx, challengeInstance, desiredSpec); err != nil || !result.IsZero() {
+			if err != nil {
+				err = errors.Join(err, r.setManifestsFailed(ctx, challengeInstance, v1alpha1.ChallengeInstanceReasonManifestsFailed, err))
+			}
 			return result, err
 		}
+		inventory = append(inventory, newInventoryEntry(desiredSpec))
 	}
-	return ctrl.Result{}, nil
+
+	if err := r.pruneInventory(ctx, challengeInstance, inventory); err != nil {
+		return ctrl.Result{}, errors.Join(err, r.setManifestsFailed(ctx, challengeInstance, v1alpha1.ChallengeInstanceReasonManifestsFailed, err))
+	}
+	if err := updateInventory(ctx, r.GetClient(), challengeInstance, inventory); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionManifestsApplied,
+		metav1.ConditionTrue,
+		v1alpha1.ChallengeInstanceReasonManifestsApplied,
+		fmt.Sprintf("All %d manifests were applied", len(challengeDescription.Spec.Manifests)),
+	)
+}
+
+func (r *ManifestsReconciler) setManifestsFailed(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, reason string, err error) error {
+	return setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionManifestsApplied,
+		metav1.ConditionFalse,
+		reason,
+		err.Error(),
+	)
 }
 
 func (r *ManifestsReconciler) reconcileManifest(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, desiredSpec *unstructured.Unstructured) (ctrl.Result, error) {
-	currentSpec, err := r.getCurrentSpec(ctx, desiredSpec)
+	currentSpec, err := getCurrentSpec(ctx, r.GetClient(), desiredSpec)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	if currentSpec == nil {
-		return r.reconcileManifestOnCreate(ctx, challengeInstance, desiredSpec)
+	action := "Creating"
+	if currentSpec != nil {
+		action = "Updating"
 	}
-	return r.reconcileManifestOnUpdate(ctx, challengeInstance, desiredSpec, currentSpec)
-}
 
-func (r *ManifestsReconciler) reconcileManifestOnCreate(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, desiredSpec *unstructured.Unstructured) (ctrl.Result, error) {
-	if err := r.GetClient().Create(ctx, desiredSpec); err != nil {
+	// Server-side apply only touches the fields which are set in the manifest, but owns all of them. Changes made by
+	// others to those fields are overwritten, which is why we force the ownership on conflicts.
+	if err := r.GetClient().Patch(ctx, desiredSpec, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership); err != nil {
 		r.recorder.Eventf(
 			challengeInstance,
 			corev1.EventTypeWarning,
-			"Creating",
-			"Failed to create %s at %s/%s: %s",
+			action,
+			"Failed to apply %s at %s/%s: %s",
 			desiredSpec.GroupVersionKind(),
 			desiredSpec.GetNamespace(),
 			desiredSpec.GetName(),
@@ -98,54 +133,39 @@ func (r *ManifestsReconciler) reconcileManifestOnCreate(ctx context.Context, cha
 		)
 		return ctrl.Result{}, err
 	}
-	r.recorder.Eventf(
-		challengeInstance,
-		corev1.EventTypeNormal,
-		"Creating",
-		"Created %s at %s/%s",
-		desiredSpec.GroupVersionKind(),
-		desiredSpec.GetNamespace(),
-		desiredSpec.GetName(),
-	)
-	return ctrl.Result{}, nil
-}
 
-func (r *ManifestsReconciler) reconcileManifestOnUpdate(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, desiredSpec *unstructured.Unstructured, currentSpec *unstructured.Unstructured) (ctrl.Result, error) {
-	if equality.Semantic.DeepDerivative(desiredSpec.Object["spec"], currentSpec.Object["spec"]) {
-		// The resources are identical. Nothing to do.
-		return ctrl.Result{}, nil
-	}
-
-	currentSpec.Object["spec"] = desiredSpec.Object["spec"]
-	if err := r.GetClient().Update(ctx, currentSpec); err != nil {
+	switch {
+	case currentSpec == nil:
 		r.recorder.Eventf(
 			challengeInstance,
-			corev1.EventTypeWarning,
-			"Updating",
-			"Failed to update %s at %s/%s: %s",
+			corev1.EventTypeNormal,
+			action,
+			"Created %s at %s/%s",
+			desiredSpec.GroupVersionKind(),
+			desiredSpec.GetNamespace(),
+			desiredSpec.GetName(),
+		)
+	case currentSpec.GetResourceVersion() != desiredSpec.GetResourceVersion():
+		// The API server only assigns a new resource version when the apply actually changed something.
+		r.recorder.Eventf(
+			challengeInstance,
+			corev1.EventTypeNormal,
+			action,
+			"Updated %s at %s/%s",
 			desiredSpec.GroupVersionKind(),
 			desiredSpec.GetNamespace(),
 			desiredSpec.GetName(),
-			err,
 		)
-		return ctrl.Result{}, err
 	}
-	r.recorder.Eventf(
-		challengeInstance,
-		corev1.EventTypeNormal,
-		"Updating",
-		"Updated %s at %s/%s",
-		desiredSpec.GroupVersionKind(),
-		desiredSpec.GetNamespace(),
-		desiredSpec.GetName(),
-	)
 	return ctrl.Result{}, nil
 }
 
-func (r *ManifestsReconciler) getCurrentSpec(ctx context.Context, desiredSpec client.Object) (*unstructured.Unstructured, error) {
+// getCurrentSpec returns the object which currently exists for the given desired object. It returns nil if the object
+// does not exist.
+func getCurrentSpec(ctx context.Context, k8sClient client.Client, desiredSpec *unstructured.Unstructured) (*unstructured.Unstructured, error) {
 	var currentSpec unstructured.Unstructured
-	currentSpec.SetGroupVersionKind(desiredSpec.GetObjectKind().GroupVersionKind())
-	if err := r.GetClient().Get(ctx, client.ObjectKey{
+	currentSpec.SetGroupVersionKind(desiredSpec.GroupVersionKind())
+	if err := k8sClient.Get(ctx, client.ObjectKey{
 		Namespace: desiredSpec.GetNamespace(),
 		Name:      desiredSpec.GetName(),
 	}, &currentSpec); err != nil {
@@ -153,3 +173,73 @@ func (r *ManifestsReconciler) getCurrentSpec(ctx context.Context, desiredSpec cl
 	}
 	return &currentSpec, nil
 }
+
+// getDesiredManifests decodes the manifests of the challenge description into the objects which should exist for the
+// given challenge instance. All string values of the manifests are rendered as templates.
+func getDesiredManifests(ctx context.Context, k8sClient client.Client, challengeInstance *v1alpha1.ChallengeInstance, challengeDescription *v1alpha1.ChallengeDescription) ([]*unstructured.Unstructured, error) {
+	data, err := getRenderingData(ctx, k8sClient, challengeInstance, challengeDescription)
+	if err != nil {
+		return nil, err
+	}
+
+	codecFactory := serializer.NewCodecFactory(clientgoscheme.Scheme)
+	decoder := codecFactory.UniversalDeserializer()
+
+	desiredSpecs := make([]*unstructured.Unstructured, 0, len(challengeDescription.Spec.Manifests))
+	for i, raw := range challengeDescription.Spec.Manifests {
+		var desiredSpec unstructured.Unstructured
+		if _, _, err := decoder.Decode(raw.Raw, nil, &desiredSpec); err != nil {
+			return nil, err
+		}
+
+		if err := rendering.Render(&desiredSpec, data); err != nil {
+			return nil, fmt.Errorf("rendering manifest %d: %w", i, err)
+		}
+
+		// We need to make sure that we overwrite the target namespace to prevent challenge instances from placing
+		// workload into unrelated namespaces.
+		desiredSpec.SetNamespace(challengeInstance.Status.Namespace)
+
+		desiredSpecs = append(desiredSpecs, &desiredSpec)
+	}
+	return desiredSpecs, nil
+}
+
+// getRenderingData returns the data context which is available to the templates in the manifests.
+func getRenderingData(ctx context.Context, k8sClient client.Client, challengeInstance *v1alpha1.ChallengeInstance, challengeDescription *v1alpha1.ChallengeDescription) (rendering.Data, error) {
+	flagValue, err := getFlag(ctx, k8sClient, challengeInstance)
+	if err != nil {
+		return rendering.Data{}, err
+	}
+
+	return rendering.Data{
+		Instance:            getRenderingInstance(challengeInstance),
+		Description:         getRenderingDescription(challengeDescription),
+		Namespace:           challengeInstance.Status.Namespace,
+		Flag:                flagValue,
+		ExpirationTimestamp: challengeInstance.Status.ExpirationTimestamp.Time,
+		Seed:                rendering.SeedFromUID(string(challengeInstance.UID)),
+	}, nil
+}
+
+// getRenderingInstance returns the details about the challenge instance which are available to templates.
+func getRenderingInstance(challengeInstance *v1alpha1.ChallengeInstance) rendering.Instance {
+	return rendering.Instance{
+		Name:        challengeInstance.Name,
+		Namespace:   challengeInstance.Namespace,
+		UID:         string(challengeInstance.UID),
+		Owner:       challengeInstance.Spec.Owner,
+		Labels:      challengeInstance.Labels,
+		Annotations: challengeInstance.Annotations,
+	}
+}
+
+// getRenderingDescription returns the details about the challenge description which are available to templates.
+func getRenderingDescription(challengeDescription *v1alpha1.ChallengeDescription) rendering.Description {
+	return rendering.Description{
+		Name:     challengeDescription.Name,
+		Title:    challengeDescription.Spec.Title,
+		Category: challengeDescription.Spec.Category,
+		Value:    challengeDescription.Spec.Value,
+	}
+}
@@ -2,6 +2,7 @@ package challengeinstance_test
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
 	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstance"
+	"github.com/backbone81/ctf-challenge-operator/internal/flag"
 	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
 	"github.com/backbone81/ctf-challenge-operator/internal/utils"
 )
@@ -68,12 +70,70 @@ var _ = Describe("ManifestsReconciler", func() {
 		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
 		Expect(instance.Spec.ChallengeDescriptionName).ToNot(BeZero())
 
-		namespace := corev1.Namespace{
+		CreateInstanceNamespace(ctx, &instance)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Name:      configMap.Name,
+			Namespace: instance.Status.Namespace,
+		}, &configMap)).To(Succeed())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionManifestsApplied)).To(BeTrue())
+	})
+
+	It("should create the manifests of a cluster challenge description", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		configMap := corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: testutils.GenerateName("test-"),
+			},
+		}
+		configMapRaw, err := ToRaw(&configMap)
+		Expect(err).ToNot(HaveOccurred())
+
+		clusterDescription := v1alpha1.ClusterChallengeDescription{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+			},
+			Spec: v1alpha1.ClusterChallengeDescriptionSpec{
+				ChallengeDescriptionSpec: v1alpha1.ChallengeDescriptionSpec{
+					Title:       "test",
+					Description: "test",
+					FlagHash: &v1alpha1.FlagHash{
+						Algorithm: v1alpha1.FlagHashAlgorithmSHA256,
+						Salt:      "01234567",
+						Hash:      flag.Hash("01234567", "test"),
+					},
+					Manifests: []runtime.RawExtension{
+						{
+							Raw: configMapRaw,
+						},
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &clusterDescription)).To(Succeed())
+
+		instance := v1alpha1.ChallengeInstance{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: instance.Name,
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionRef: &v1alpha1.ChallengeDescriptionReference{
+					Kind: v1alpha1.ClusterChallengeDescriptionKind,
+					Name: clusterDescription.Name,
+				},
 			},
 		}
-		Expect(k8sClient.Create(ctx, &namespace)).To(Succeed())
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		CreateInstanceNamespace(ctx, &instance)
 
 		By("run the reconciler")
 		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
@@ -83,8 +143,39 @@ var _ = Describe("ManifestsReconciler", func() {
 		By("verify all postconditions")
 		Expect(k8sClient.Get(ctx, client.ObjectKey{
 			Name:      configMap.Name,
-			Namespace: instance.Name,
+			Namespace: instance.Status.Namespace,
 		}, &configMap)).To(Succeed())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionManifestsApplied)).To(BeTrue())
+	})
+
+	It("should report a missing cluster challenge description", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionRef: &v1alpha1.ChallengeDescriptionReference{
+					Kind: v1alpha1.ClusterChallengeDescriptionKind,
+					Name: testutils.GenerateName("does-not-exist-"),
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		CreateInstanceNamespace(ctx, &instance)
+
+		By("run the reconciler")
+		_, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).To(HaveOccurred())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		condition := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionManifestsApplied)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Reason).To(Equal(v1alpha1.ChallengeInstanceReasonDescriptionNotFound))
 	})
 
 	It("should succeed if the manifests are already there", func(ctx SpecContext) {
@@ -128,14 +219,37 @@ var _ = Describe("ManifestsReconciler", func() {
 		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
 		Expect(instance.Spec.ChallengeDescriptionName).ToNot(BeZero())
 
-		namespace := corev1.Namespace{
+		CreateInstanceNamespace(ctx, &instance)
+		configMap.Namespace = instance.Status.Namespace
+		Expect(k8sClient.Create(ctx, &configMap)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Name:      configMap.Name,
+			Namespace: instance.Status.Namespace,
+		}, &configMap)).To(Succeed())
+	})
+
+	It("should render the manifests as templates", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		configMap := corev1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: instance.Name,
+				Name: "{{ .Instance.Name }}-config",
+			},
+			Data: map[string]string{
+				"title":     "{{ .Description.Title }}",
+				"namespace": "{{ .Namespace }}",
 			},
 		}
-		Expect(k8sClient.Create(ctx, &namespace)).To(Succeed())
-		configMap.Namespace = instance.Name
-		Expect(k8sClient.Create(ctx, &configMap)).To(Succeed())
+		configMapRaw, err := ToRaw(&configMap)
+		Expect(err).ToNot(HaveOccurred())
+
+		instance := createInstanceWithManifests(ctx, configMapRaw)
 
 		By("run the reconciler")
 		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
@@ -144,9 +258,35 @@ var _ = Describe("ManifestsReconciler", func() {
 
 		By("verify all postconditions")
 		Expect(k8sClient.Get(ctx, client.ObjectKey{
-			Name:      configMap.Name,
-			Namespace: instance.Name,
+			Name:      instance.Name + "-config",
+			Namespace: instance.Status.Namespace,
 		}, &configMap)).To(Succeed())
+		Expect(configMap.Data).To(HaveKeyWithValue("title", "test"))
+		Expect(configMap.Data).To(HaveKeyWithValue("namespace", instance.Status.Namespace))
+	})
+
+	It("should fail with an invalid template", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		configMap := corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "{{ .NotExisting }}",
+			},
+		}
+		configMapRaw, err := ToRaw(&configMap)
+		Expect(err).ToNot(HaveOccurred())
+
+		instance := createInstanceWithManifests(ctx, configMapRaw)
+
+		By("run the reconciler")
+		_, err = reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).To(HaveOccurred())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		condition := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionManifestsApplied)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(v1alpha1.ChallengeInstanceReasonManifestsFailed))
 	})
 
 	It("should fail if the referenced challenge description is missing", func(ctx SpecContext) {
@@ -163,17 +303,19 @@ var _ = Describe("ManifestsReconciler", func() {
 		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
 		Expect(instance.Spec.ChallengeDescriptionName).ToNot(BeZero())
 
-		namespace := corev1.Namespace{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: instance.Name,
-			},
-		}
-		Expect(k8sClient.Create(ctx, &namespace)).To(Succeed())
+		CreateInstanceNamespace(ctx, &instance)
 
 		By("run the reconciler")
 		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
 		Expect(err).To(HaveOccurred())
 		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		condition := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionManifestsApplied)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(v1alpha1.ChallengeInstanceReasonDescriptionNotFound))
 	})
 
 	It("should fail with a malformed manifests", func(ctx SpecContext) {
@@ -208,17 +350,20 @@ var _ = Describe("ManifestsReconciler", func() {
 		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
 		Expect(instance.Spec.ChallengeDescriptionName).ToNot(BeZero())
 
-		namespace := corev1.Namespace{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: instance.Name,
-			},
-		}
-		Expect(k8sClient.Create(ctx, &namespace)).To(Succeed())
+		CreateInstanceNamespace(ctx, &instance)
 
 		By("run the reconciler")
 		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
 		Expect(err).To(HaveOccurred())
 		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		condition := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionManifestsApplied)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(v1alpha1.ChallengeInstanceReasonManifestsFailed))
+		Expect(instance.Status.Phase).To(Equal(v1alpha1.ChallengeInstancePhaseDegraded))
 	})
 
 	It("should not create the manifests when the instance is deleted", func(ctx SpecContext) {
@@ -268,12 +413,31 @@ var _ = Describe("ManifestsReconciler", func() {
 		Expect(instance.DeletionTimestamp.IsZero()).To(BeFalse())
 		Expect(instance.Spec.ChallengeDescriptionName).ToNot(BeZero())
 
-		namespace := corev1.Namespace{
+		CreateInstanceNamespace(ctx, &instance)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Name:      configMap.Name,
+			Namespace: instance.Status.Namespace,
+		}, &configMap)).To(MatchError(ContainSubstring("not found")))
+	})
+
+	It("should record the applied objects in the inventory", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		configMap := corev1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: instance.Name,
+				Name: testutils.GenerateName("test-"),
 			},
 		}
-		Expect(k8sClient.Create(ctx, &namespace)).To(Succeed())
+		configMapRaw, err := ToRaw(&configMap)
+		Expect(err).ToNot(HaveOccurred())
+
+		instance := createInstanceWithManifests(ctx, configMapRaw)
 
 		By("run the reconciler")
 		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
@@ -283,7 +447,191 @@ var _ = Describe("ManifestsReconciler", func() {
 		By("verify all postconditions")
 		Expect(k8sClient.Get(ctx, client.ObjectKey{
 			Name:      configMap.Name,
-			Namespace: instance.Name,
-		}, &configMap)).To(MatchError(ContainSubstring("not found")))
+			Namespace: instance.Status.Namespace,
+		}, &configMap)).To(Succeed())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Inventory).To(ConsistOf(v1alpha1.InventoryEntry{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+			Namespace:  instance.Status.Namespace,
+			Name:       configMap.Name,
+			UID:        configMap.UID,
+		}))
+	})
+
+	It("should prune objects which were removed from the manifests", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		keptConfigMap := corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: testutils.GenerateName("test-"),
+			},
+		}
+		keptConfigMapRaw, err := ToRaw(&keptConfigMap)
+		Expect(err).ToNot(HaveOccurred())
+		removedConfigMap := corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: testutils.GenerateName("test-"),
+			},
+		}
+		removedConfigMapRaw, err := ToRaw(&removedConfigMap)
+		Expect(err).ToNot(HaveOccurred())
+
+		instance := createInstanceWithManifests(ctx, keptConfigMapRaw, removedConfigMapRaw)
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		var description v1alpha1.ChallengeDescription
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Namespace,
+			Name:      instance.Spec.ChallengeDescriptionName,
+		}, &description)).To(Succeed())
+		description.Spec.Manifests = description.Spec.Manifests[:1]
+		Expect(k8sClient.Update(ctx, &description)).To(Succeed())
+
+		By("run the reconciler")
+		result, err = reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Name:      keptConfigMap.Name,
+			Namespace: instance.Status.Namespace,
+		}, &keptConfigMap)).To(Succeed())
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Name:      removedConfigMap.Name,
+			Namespace: instance.Status.Namespace,
+		}, &removedConfigMap)).To(MatchError(ContainSubstring("not found")))
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Inventory).To(HaveLen(1))
+		Expect(instance.Status.Inventory[0].Name).To(Equal(keptConfigMap.Name))
+	})
+
+	It("should not prune objects which were replaced by somebody else", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		configMap := corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: testutils.GenerateName("test-"),
+			},
+		}
+		configMapRaw, err := ToRaw(&configMap)
+		Expect(err).ToNot(HaveOccurred())
+
+		instance := createInstanceWithManifests(ctx, configMapRaw)
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		instance.Status.Inventory = []v1alpha1.InventoryEntry{
+			{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+				Namespace:  instance.Status.Namespace,
+				Name:       "replaced",
+				UID:        "00000000-0000-0000-0000-000000000000",
+			},
+		}
+		Expect(k8sClient.Status().Update(ctx, &instance)).To(Succeed())
+		replacedConfigMap := corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "replaced",
+				Namespace: instance.Status.Namespace,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &replacedConfigMap)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&replacedConfigMap), &replacedConfigMap)).To(Succeed())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Inventory).To(HaveLen(1))
+		Expect(instance.Status.Inventory[0].Name).To(Equal(configMap.Name))
+	})
+
+	It("should update all fields set in the manifests", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		configMap := corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: testutils.GenerateName("test-"),
+			},
+			Data: map[string]string{
+				"key": "old",
+			},
+		}
+		configMapRaw, err := ToRaw(&configMap)
+		Expect(err).ToNot(HaveOccurred())
+
+		instance := createInstanceWithManifests(ctx, configMapRaw)
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		configMap.Labels = map[string]string{
+			"label": "new",
+		}
+		configMap.Data["key"] = "new"
+		configMapRaw, err = ToRaw(&configMap)
+		Expect(err).ToNot(HaveOccurred())
+		var description v1alpha1.ChallengeDescription
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Namespace,
+			Name:      instance.Spec.ChallengeDescriptionName,
+		}, &description)).To(Succeed())
+		description.Spec.Manifests[0].Raw = configMapRaw
+		Expect(k8sClient.Update(ctx, &description)).To(Succeed())
+
+		By("run the reconciler")
+		result, err = reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Name:      configMap.Name,
+			Namespace: instance.Status.Namespace,
+		}, &configMap)).To(Succeed())
+		Expect(configMap.Data).To(HaveKeyWithValue("key", "new"))
+		Expect(configMap.Labels).To(HaveKeyWithValue("label", "new"))
+	})
+
+	It("should revert changes to fields set in the manifests and keep other fields", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		configMap := corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: testutils.GenerateName("test-"),
+			},
+			Data: map[string]string{
+				"key": "desired",
+			},
+		}
+		configMapRaw, err := ToRaw(&configMap)
+		Expect(err).ToNot(HaveOccurred())
+
+		instance := createInstanceWithManifests(ctx, configMapRaw)
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Name:      configMap.Name,
+			Namespace: instance.Status.Namespace,
+		}, &configMap)).To(Succeed())
+		configMap.Data["key"] = "changed"
+		configMap.Labels = map[string]string{
+			"added-by": "somebody-else",
+		}
+		Expect(k8sClient.Update(ctx, &configMap)).To(Succeed())
+
+		By("run the reconciler")
+		result, err = reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&configMap), &configMap)).To(Succeed())
+		Expect(configMap.Data).To(HaveKeyWithValue("key", "desired"))
+		Expect(configMap.Labels).To(HaveKeyWithValue("added-by", "somebody-else"))
 	})
 })
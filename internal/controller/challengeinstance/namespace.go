@@ -2,24 +2,72 @@ package challengeinstance
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/description"
 	"github.com/backbone81/ctf-challenge-operator/internal/utils"
 )
 
-// NamespaceReconciler is responsible for creating the namespace for the challenge instance.
+const (
+	// DefaultNamespacePrefix is the prefix of the namespace names of challenge instances. The UID of the challenge
+	// instance is appended.
+	DefaultNamespacePrefix = "ctf-"
+
+	// InstanceUIDLabelName is the label which marks a namespace as created for the challenge instance with the given
+	// UID. Namespaces without this label are never touched by the operator.
+	InstanceUIDLabelName = "ctf.backbone81/challenge-instance-uid"
+
+	// DefaultPodSecurityLevel is the Pod Security Standard which is enforced in the namespace of challenge instances
+	// when the challenge description does not request a different level.
+	DefaultPodSecurityLevel = v1alpha1.PodSecurityLevelRestricted
+
+	// DefaultMaxPodSecurityLevel is the most permissive Pod Security Standard a challenge description can request.
+	DefaultMaxPodSecurityLevel = v1alpha1.PodSecurityLevelBaseline
+)
+
+// podSecurityLabelNames are the labels of the Pod Security Admission which are set to the pod security level on the
+// namespace of every challenge instance.
+var podSecurityLabelNames = []string{
+	"pod-security.kubernetes.io/enforce",
+	"pod-security.kubernetes.io/warn",
+	"pod-security.kubernetes.io/audit",
+}
+
+// podSecurityLevelRanks orders the pod security levels from the most restrictive to the most permissive.
+var podSecurityLevelRanks = map[v1alpha1.PodSecurityLevel]int{
+	v1alpha1.PodSecurityLevelRestricted: 0,
+	v1alpha1.PodSecurityLevelBaseline:   1,
+	v1alpha1.PodSecurityLevelPrivileged: 2,
+}
+
+// NamespaceReconciler is responsible for creating the namespace for the challenge instance. The namespace is labeled
+// for the Pod Security Admission with the pod security level of the challenge.
 type NamespaceReconciler struct {
 	utils.DefaultSubReconciler
+	namespacePrefix         string
+	defaultPodSecurityLevel v1alpha1.PodSecurityLevel
+	maxPodSecurityLevel     v1alpha1.PodSecurityLevel
 }
 
-func NewNamespaceReconciler(client client.Client) *NamespaceReconciler {
+func NewNamespaceReconciler(
+	client client.Client,
+	namespacePrefix string,
+	defaultPodSecurityLevel v1alpha1.PodSecurityLevel,
+	maxPodSecurityLevel v1alpha1.PodSecurityLevel,
+) *NamespaceReconciler {
 	return &NamespaceReconciler{
-		DefaultSubReconciler: utils.NewDefaultSubReconciler(client),
+		DefaultSubReconciler:    utils.NewDefaultSubReconciler(client),
+		namespacePrefix:         namespacePrefix,
+		defaultPodSecurityLevel: defaultPodSecurityLevel,
+		maxPodSecurityLevel:     maxPodSecurityLevel,
 	}
 }
 
@@ -29,49 +77,230 @@ func (r *NamespaceReconciler) Reconcile(ctx context.Context, challengeInstance *
 		return ctrl.Result{}, err
 	}
 
+	if namespace != nil && !isNamespaceOfInstance(namespace, challengeInstance) {
+		// We refuse to work with a namespace we did not create, to not adopt and later delete unrelated workload.
+		return ctrl.Result{}, setCondition(
+			ctx,
+			r.GetClient(),
+			challengeInstance,
+			v1alpha1.ChallengeInstanceConditionNamespaceReady,
+			metav1.ConditionFalse,
+			v1alpha1.ChallengeInstanceReasonNamespaceFailed,
+			fmt.Sprintf("Namespace %s was not created for this challenge instance", namespace.Name),
+		)
+	}
+
 	if !challengeInstance.DeletionTimestamp.IsZero() {
-		return r.reconcileOnDelete(ctx, namespace)
+		return r.reconcileOnDelete(ctx, challengeInstance, namespace)
+	}
+
+	podSecurityLevel, err := r.getPodSecurityLevel(ctx, challengeInstance)
+	if err != nil {
+		return ctrl.Result{}, errors.Join(err, setCondition(
+			ctx,
+			r.GetClient(),
+			challengeInstance,
+			v1alpha1.ChallengeInstanceConditionNamespaceReady,
+			metav1.ConditionFalse,
+			v1alpha1.ChallengeInstanceReasonNamespaceFailed,
+			fmt.Sprintf("Failed to determine the pod security level: %s", err),
+		))
 	}
 
 	if namespace == nil {
-		desiredSpec := r.getDesiredNamespaceSpec(challengeInstance)
-		return r.reconcileOnCreate(ctx, desiredSpec)
+		desiredSpec := r.getDesiredNamespaceSpec(challengeInstance, podSecurityLevel)
+		return r.reconcileOnCreate(ctx, challengeInstance, desiredSpec)
+	}
+
+	if !namespace.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, setCondition(
+			ctx,
+			r.GetClient(),
+			challengeInstance,
+			v1alpha1.ChallengeInstanceConditionNamespaceReady,
+			metav1.ConditionFalse,
+			v1alpha1.ChallengeInstanceReasonNamespaceTerminating,
+			fmt.Sprintf("Namespace %s is being deleted", namespace.Name),
+		)
 	}
-	return ctrl.Result{}, nil
+	return r.reconcileOnUpdate(ctx, challengeInstance, namespace, podSecurityLevel)
 }
 
-func (r *NamespaceReconciler) reconcileOnCreate(ctx context.Context, desiredSpec *corev1.Namespace) (ctrl.Result, error) {
+func (r *NamespaceReconciler) reconcileOnCreate(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, desiredSpec *corev1.Namespace) (ctrl.Result, error) {
 	if err := r.GetClient().Create(ctx, desiredSpec); err != nil {
-		return ctrl.Result{}, err
+		// An already existing namespace is picked up by the next reconcile, which makes sure that it was created for
+		// this challenge instance.
+		return ctrl.Result{}, errors.Join(err, setCondition(
+			ctx,
+			r.GetClient(),
+			challengeInstance,
+			v1alpha1.ChallengeInstanceConditionNamespaceReady,
+			metav1.ConditionFalse,
+			v1alpha1.ChallengeInstanceReasonNamespaceFailed,
+			fmt.Sprintf("Failed to create namespace %s: %s", desiredSpec.Name, err),
+		))
+	}
+	return ctrl.Result{}, r.setNamespaceReady(ctx, challengeInstance, desiredSpec)
+}
+
+func (r *NamespaceReconciler) reconcileOnUpdate(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, currentSpec *corev1.Namespace, podSecurityLevel v1alpha1.PodSecurityLevel) (ctrl.Result, error) {
+	if !hasPodSecurityLabels(currentSpec, podSecurityLevel) {
+		patch := client.MergeFrom(currentSpec.DeepCopy())
+		setPodSecurityLabels(currentSpec, podSecurityLevel)
+		if err := r.GetClient().Patch(ctx, currentSpec, patch); err != nil {
+			return ctrl.Result{}, errors.Join(err, setCondition(
+				ctx,
+				r.GetClient(),
+				challengeInstance,
+				v1alpha1.ChallengeInstanceConditionNamespaceReady,
+				metav1.ConditionFalse,
+				v1alpha1.ChallengeInstanceReasonNamespaceFailed,
+				fmt.Sprintf("Failed to update pod security labels of namespace %s: %s", currentSpec.Name, err),
+			))
+		}
 	}
-	return ctrl.Result{}, nil
+	return ctrl.Result{}, r.setNamespaceReady(ctx, challengeInstance, currentSpec)
 }
 
-func (r *NamespaceReconciler) reconcileOnDelete(ctx context.Context, currentSpec *corev1.Namespace) (ctrl.Result, error) {
+func (r *NamespaceReconciler) reconcileOnDelete(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, currentSpec *corev1.Namespace) (ctrl.Result, error) {
 	if currentSpec == nil {
 		return ctrl.Result{}, nil
 	}
 
-	if err := r.GetClient().Delete(ctx, currentSpec); err != nil {
-		return ctrl.Result{}, err
+	uid := currentSpec.UID
+	if err := r.GetClient().Delete(ctx, currentSpec, client.Preconditions{UID: &uid}); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	return ctrl.Result{}, setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionNamespaceReady,
+		metav1.ConditionFalse,
+		v1alpha1.ChallengeInstanceReasonNamespaceTerminating,
+		fmt.Sprintf("Namespace %s is being deleted", currentSpec.Name),
+	)
+}
+
+// setNamespaceReady records the name of the namespace in the status of the challenge instance and marks the namespace
+// as ready.
+func (r *NamespaceReconciler) setNamespaceReady(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, namespace *corev1.Namespace) error {
+	if challengeInstance.Status.Namespace != namespace.Name {
+		challengeInstance.Status.Namespace = namespace.Name
+		if err := r.GetClient().Status().Update(ctx, challengeInstance); err != nil {
+			return err
+		}
 	}
-	return ctrl.Result{}, nil
+	return setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionNamespaceReady,
+		metav1.ConditionTrue,
+		v1alpha1.ChallengeInstanceReasonNamespaceCreated,
+		fmt.Sprintf("Namespace %s exists", namespace.Name),
+	)
 }
 
+// getNamespace returns the namespace of the challenge instance. It returns nil if the namespace does not exist. When
+// no namespace is recorded in the status yet, the namespace is looked up by the name derived from the challenge
+// instance. Namespaces of earlier versions of the operator are named like the challenge instance. They are only picked
+// up when they were labeled with the UID of the challenge instance.
 func (r *NamespaceReconciler) getNamespace(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) (*corev1.Namespace, error) {
+	if len(challengeInstance.Status.Namespace) != 0 {
+		return r.getNamespaceByName(ctx, challengeInstance.Status.Namespace)
+	}
+
+	namespace, err := r.getNamespaceByName(ctx, GetNamespaceName(r.namespacePrefix, challengeInstance))
+	if err != nil || namespace != nil {
+		return namespace, err
+	}
+
+	legacyNamespace, err := r.getNamespaceByName(ctx, challengeInstance.Name)
+	if err != nil || legacyNamespace == nil || !isNamespaceOfInstance(legacyNamespace, challengeInstance) {
+		return nil, err
+	}
+	return legacyNamespace, nil
+}
+
+// getNamespaceByName returns the namespace with the given name. It returns nil if the namespace does not exist.
+func (r *NamespaceReconciler) getNamespaceByName(ctx context.Context, name string) (*corev1.Namespace, error) {
 	var namespace corev1.Namespace
 	if err := r.GetClient().Get(ctx, client.ObjectKey{
-		Name: challengeInstance.Name,
+		Name: name,
 	}, &namespace); err != nil {
 		return nil, client.IgnoreNotFound(err)
 	}
 	return &namespace, nil
 }
 
-func (r *NamespaceReconciler) getDesiredNamespaceSpec(challengeInstance *v1alpha1.ChallengeInstance) *corev1.Namespace {
-	return &corev1.Namespace{
+// getPodSecurityLevel returns the pod security level for the namespace of the challenge instance. The level requested
+// by the challenge description is capped at the maximum level of the operator. Without a challenge description, the
+// default level applies.
+func (r *NamespaceReconciler) getPodSecurityLevel(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) (v1alpha1.PodSecurityLevel, error) {
+	podSecurityLevel := r.defaultPodSecurityLevel
+	if len(description.Ref(challengeInstance).Name) != 0 {
+		challengeDescription, err := description.Get(ctx, r.GetClient(), challengeInstance)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return "", err
+		}
+		if challengeDescription != nil && len(challengeDescription.Spec.PodSecurityLevel) != 0 {
+			podSecurityLevel = challengeDescription.Spec.PodSecurityLevel
+		}
+	}
+	return limitPodSecurityLevel(podSecurityLevel, r.maxPodSecurityLevel), nil
+}
+
+func (r *NamespaceReconciler) getDesiredNamespaceSpec(challengeInstance *v1alpha1.ChallengeInstance, podSecurityLevel v1alpha1.PodSecurityLevel) *corev1.Namespace {
+	namespace := &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: challengeInstance.Name,
+			Name: GetNamespaceName(r.namespacePrefix, challengeInstance),
+			Labels: map[string]string{
+				InstanceUIDLabelName: string(challengeInstance.UID),
+			},
 		},
 	}
+	setPodSecurityLabels(namespace, podSecurityLevel)
+	return namespace
+}
+
+// GetNamespaceName returns the name of the namespace which is created for the given challenge instance. The name is
+// derived from the UID of the challenge instance, so that a namespace which was created but could not be recorded in
+// the status is found again.
+func GetNamespaceName(namespacePrefix string, challengeInstance *v1alpha1.ChallengeInstance) string {
+	return namespacePrefix + string(challengeInstance.UID)
+}
+
+// isNamespaceOfInstance returns true if the given namespace was created for the given challenge instance.
+func isNamespaceOfInstance(namespace *corev1.Namespace, challengeInstance *v1alpha1.ChallengeInstance) bool {
+	return namespace.Labels[InstanceUIDLabelName] == string(challengeInstance.UID)
+}
+
+// limitPodSecurityLevel returns the given pod security level, unless it is more permissive than the maximum level. In
+// that case the maximum level is returned.
+func limitPodSecurityLevel(podSecurityLevel v1alpha1.PodSecurityLevel, maxPodSecurityLevel v1alpha1.PodSecurityLevel) v1alpha1.PodSecurityLevel {
+	if podSecurityLevelRanks[podSecurityLevel] > podSecurityLevelRanks[maxPodSecurityLevel] {
+		return maxPodSecurityLevel
+	}
+	return podSecurityLevel
+}
+
+// hasPodSecurityLabels returns true if all pod security labels of the namespace are set to the given level.
+func hasPodSecurityLabels(namespace *corev1.Namespace, podSecurityLevel v1alpha1.PodSecurityLevel) bool {
+	for _, labelName := range podSecurityLabelNames {
+		if namespace.Labels[labelName] != string(podSecurityLevel) {
+			return false
+		}
+	}
+	return true
+}
+
+// setPodSecurityLabels sets all pod security labels of the namespace to the given level.
+func setPodSecurityLabels(namespace *corev1.Namespace, podSecurityLevel v1alpha1.PodSecurityLevel) {
+	if namespace.Labels == nil {
+		namespace.Labels = make(map[string]string)
+	}
+	for _, labelName := range podSecurityLabelNames {
+		namespace.Labels[labelName] = string(podSecurityLevel)
+	}
 }
@@ -2,7 +2,9 @@ package challengeinstance_test
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -18,7 +20,11 @@ var _ = Describe("NamespaceReconciler", func() {
 	var reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]
 
 	BeforeEach(func() {
-		reconciler = challengeinstance.NewReconciler(k8sClient, challengeinstance.WithNamespaceReconciler())
+		reconciler = challengeinstance.NewReconciler(k8sClient, challengeinstance.WithNamespaceReconciler(
+			"test-ns-",
+			challengeinstance.DefaultPodSecurityLevel,
+			challengeinstance.DefaultMaxPodSecurityLevel,
+		))
 	})
 
 	AfterEach(func(ctx SpecContext) {
@@ -34,10 +40,6 @@ var _ = Describe("NamespaceReconciler", func() {
 			},
 		}
 		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
-		var namespace corev1.Namespace
-		Expect(k8sClient.Get(ctx, client.ObjectKey{
-			Name: instance.Name,
-		}, &namespace)).ToNot(Succeed())
 
 		By("run the reconciler")
 		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
@@ -45,12 +47,73 @@ var _ = Describe("NamespaceReconciler", func() {
 		Expect(result).To(BeZero())
 
 		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Namespace).To(Equal(challengeinstance.GetNamespaceName("test-ns-", &instance)))
+		var namespace corev1.Namespace
 		Expect(k8sClient.Get(ctx, client.ObjectKey{
-			Name: instance.Name,
+			Name: instance.Status.Namespace,
 		}, &namespace)).To(Succeed())
+		Expect(namespace.Labels).To(HaveKeyWithValue(challengeinstance.InstanceUIDLabelName, string(instance.UID)))
+		Expect(namespace.Labels).To(HaveKeyWithValue("pod-security.kubernetes.io/enforce", "restricted"))
+		Expect(namespace.Labels).To(HaveKeyWithValue("pod-security.kubernetes.io/warn", "restricted"))
+		Expect(namespace.Labels).To(HaveKeyWithValue("pod-security.kubernetes.io/audit", "restricted"))
+		Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionNamespaceReady)).To(BeTrue())
+		Expect(instance.Status.Phase).To(Equal(v1alpha1.ChallengeInstancePhaseProvisioning))
+		Expect(instance.Status.ObservedGeneration).To(Equal(instance.Generation))
 	})
 
 	It("should succeed if the namespace already exists", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+		namespace := CreateInstanceNamespace(ctx, &instance)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Namespace).To(Equal(namespace.Name))
+		Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionNamespaceReady)).To(BeTrue())
+	})
+
+	It("should adopt a namespace created for the instance which was not recorded in the status", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+		namespace := corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: challengeinstance.GetNamespaceName("test-ns-", &instance),
+				Labels: map[string]string{
+					challengeinstance.InstanceUIDLabelName: string(instance.UID),
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &namespace)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Namespace).To(Equal(namespace.Name))
+	})
+
+	It("should adopt a namespace of an earlier version which was labeled for the instance", func(ctx SpecContext) {
 		By("prepare test with all preconditions")
 		instance := v1alpha1.ChallengeInstance{
 			ObjectMeta: metav1.ObjectMeta{
@@ -62,6 +125,9 @@ var _ = Describe("NamespaceReconciler", func() {
 		namespace := corev1.Namespace{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: instance.Name,
+				Labels: map[string]string{
+					challengeinstance.InstanceUIDLabelName: string(instance.UID),
+				},
 			},
 		}
 		Expect(k8sClient.Create(ctx, &namespace)).To(Succeed())
@@ -72,9 +138,121 @@ var _ = Describe("NamespaceReconciler", func() {
 		Expect(result).To(BeZero())
 
 		By("verify all postconditions")
-		Expect(k8sClient.Get(ctx, client.ObjectKey{
-			Name: instance.Name,
-		}, &namespace)).To(Succeed())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Namespace).To(Equal(instance.Name))
+	})
+
+	It("should not adopt an unlabeled namespace of an earlier version", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+		namespace := corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: instance.Name,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &namespace)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Namespace).To(Equal(challengeinstance.GetNamespaceName("test-ns-", &instance)))
+	})
+
+	It("should refuse a namespace with the derived name which was not created for the instance", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+		namespace := corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: challengeinstance.GetNamespaceName("test-ns-", &instance),
+			},
+		}
+		Expect(k8sClient.Create(ctx, &namespace)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Namespace).To(BeEmpty())
+		condition := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionNamespaceReady)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Reason).To(Equal(v1alpha1.ChallengeInstanceReasonNamespaceFailed))
+	})
+
+	It("should refuse a namespace which was not created for the instance", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+		namespace := corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &namespace)).To(Succeed())
+		instance.Status.Namespace = namespace.Name
+		Expect(k8sClient.Status().Update(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		condition := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionNamespaceReady)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(v1alpha1.ChallengeInstanceReasonNamespaceFailed))
+	})
+
+	It("should report the namespace as not ready when it is being deleted", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+		namespace := CreateInstanceNamespace(ctx, &instance)
+		Expect(k8sClient.Delete(ctx, &namespace)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		condition := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionNamespaceReady)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(v1alpha1.ChallengeInstanceReasonNamespaceTerminating))
+		Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionDegraded)).To(BeTrue())
+		Expect(instance.Status.Phase).To(Equal(v1alpha1.ChallengeInstancePhaseDegraded))
 	})
 
 	It("should delete the namespace on deletion", func(ctx SpecContext) {
@@ -89,14 +267,64 @@ var _ = Describe("NamespaceReconciler", func() {
 			},
 		}
 		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+		namespace := CreateInstanceNamespace(ctx, &instance)
 		Expect(k8sClient.Delete(ctx, &instance)).To(Succeed())
-		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&namespace), &namespace)).To(Succeed())
+		Expect(namespace.DeletionTimestamp.IsZero()).To(BeFalse())
+	})
+
+	It("should not delete a namespace which was not created for the instance", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+				Finalizers: []string{
+					testutils.DoNotDeleteFinalizerName,
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
 		namespace := corev1.Namespace{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: instance.Name,
+				GenerateName: "test-",
 			},
 		}
 		Expect(k8sClient.Create(ctx, &namespace)).To(Succeed())
+		instance.Status.Namespace = namespace.Name
+		Expect(k8sClient.Status().Update(ctx, &instance)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&namespace), &namespace)).To(Succeed())
+		Expect(namespace.DeletionTimestamp.IsZero()).To(BeTrue())
+	})
+
+	It("should apply the pod security level of the challenge description", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescriptionWithPodSecurityLevel(ctx, v1alpha1.PodSecurityLevelBaseline)
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionName: description.Name,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
 
 		By("run the reconciler")
 		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
@@ -104,9 +332,97 @@ var _ = Describe("NamespaceReconciler", func() {
 		Expect(result).To(BeZero())
 
 		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		var namespace corev1.Namespace
 		Expect(k8sClient.Get(ctx, client.ObjectKey{
-			Name: instance.Name,
+			Name: instance.Status.Namespace,
 		}, &namespace)).To(Succeed())
-		Expect(namespace.DeletionTimestamp.IsZero()).To(BeFalse())
+		Expect(namespace.Labels).To(HaveKeyWithValue("pod-security.kubernetes.io/enforce", "baseline"))
+	})
+
+	It("should not exceed the maximum pod security level", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescriptionWithPodSecurityLevel(ctx, v1alpha1.PodSecurityLevelPrivileged)
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionName: description.Name,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		var namespace corev1.Namespace
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Name: instance.Status.Namespace,
+		}, &namespace)).To(Succeed())
+		Expect(namespace.Labels).To(HaveKeyWithValue("pod-security.kubernetes.io/enforce", "baseline"))
+	})
+
+	It("should update the pod security labels of an existing namespace", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescriptionWithPodSecurityLevel(ctx, v1alpha1.PodSecurityLevelBaseline)
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionName: description.Name,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+		namespace := CreateInstanceNamespace(ctx, &instance)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&namespace), &namespace)).To(Succeed())
+		Expect(namespace.Labels).To(HaveKeyWithValue(challengeinstance.InstanceUIDLabelName, string(instance.UID)))
+		Expect(namespace.Labels).To(HaveKeyWithValue("pod-security.kubernetes.io/enforce", "baseline"))
+		Expect(namespace.Labels).To(HaveKeyWithValue("pod-security.kubernetes.io/warn", "baseline"))
+		Expect(namespace.Labels).To(HaveKeyWithValue("pod-security.kubernetes.io/audit", "baseline"))
 	})
 })
+
+func createDescriptionWithPodSecurityLevel(ctx SpecContext, podSecurityLevel v1alpha1.PodSecurityLevel) v1alpha1.ChallengeDescription {
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testutils.GenerateName("test-"),
+		},
+	}
+	configMapRaw, err := ToRaw(&configMap)
+	Expect(err).ToNot(HaveOccurred())
+
+	description := v1alpha1.ChallengeDescription{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeDescriptionSpec{
+			Title:            "test",
+			Description:      "test",
+			Flag:             "test",
+			PodSecurityLevel: podSecurityLevel,
+			Manifests: []runtime.RawExtension{
+				{
+					Raw: configMapRaw,
+				},
+			},
+		},
+	}
+	Expect(k8sClient.Create(ctx, &description)).To(Succeed())
+	return description
+}
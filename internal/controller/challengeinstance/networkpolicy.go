@@ -0,0 +1,379 @@
+package challengeinstance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/description"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+const (
+	// DefaultIngressNamespace is the namespace which is allowed to connect to all challenge instances by default.
+	DefaultIngressNamespace = "ingress-nginx"
+
+	// DefaultDNSNamespace is the namespace of the cluster DNS by default.
+	DefaultDNSNamespace = "kube-system"
+
+	// ManagedByLabelName is the label which marks objects created by the operator itself instead of the manifests of
+	// the challenge description.
+	ManagedByLabelName = "app.kubernetes.io/managed-by"
+
+	// ManagedByLabelValue is the value of ManagedByLabelName for objects created by the operator.
+	ManagedByLabelValue = "ctf-challenge-operator"
+
+	// The names of the network policies which are created in the namespace of every challenge instance.
+	NetworkPolicyDefaultDenyName    = "ctf-default-deny"
+	NetworkPolicyAllowNamespaceName = "ctf-allow-same-namespace"
+	NetworkPolicyAllowDNSName       = "ctf-allow-dns"
+	NetworkPolicyAllowIngressName   = "ctf-allow-ingress"
+	NetworkPolicyAllowEgressName    = "ctf-allow-egress"
+	NetworkPolicyAllowPortName      = "ctf-allow-port"
+)
+
+// DefaultDNSPodLabels are the labels of the cluster DNS pods by default.
+var DefaultDNSPodLabels = map[string]string{
+	"k8s-app": "kube-dns",
+}
+
+// NetworkPolicyConfig configures the traffic which is allowed for all challenge instances.
+type NetworkPolicyConfig struct {
+	// IngressNamespace is the namespace which is allowed to connect to all challenge instances. An empty namespace
+	// allows no additional traffic.
+	IngressNamespace string
+
+	// DNSNamespace is the namespace of the cluster DNS pods. An empty namespace selects the pods in all namespaces.
+	DNSNamespace string
+
+	// DNSPodLabels are the labels of the cluster DNS pods. Empty labels select all pods in the DNS namespace.
+	DNSPodLabels map[string]string
+}
+
+// privateCIDRs are the address ranges which are not considered part of the internet. They cover the cluster networks
+// in most setups as well as cloud metadata services.
+var privateCIDRs = []string{
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"100.64.0.0/10",
+	"169.254.0.0/16",
+	"127.0.0.0/8",
+}
+
+// privateIPv6CIDRs are the IPv6 address ranges which are not considered part of the internet.
+var privateIPv6CIDRs = []string{
+	"fc00::/7",
+	"fe80::/10",
+	"::1/128",
+}
+
+// NetworkPolicyReconciler is responsible for isolating the namespace of the challenge instance from the rest of the
+// cluster. All traffic is denied, except for traffic within the namespace, DNS lookups at the cluster DNS, traffic
+// from the ingress namespace, external traffic to the allocated port and the network access granted by the challenge
+// description.
+type NetworkPolicyReconciler struct {
+	utils.DefaultSubReconciler
+	config NetworkPolicyConfig
+}
+
+func NewNetworkPolicyReconciler(client client.Client, config NetworkPolicyConfig) *NetworkPolicyReconciler {
+	return &NetworkPolicyReconciler{
+		DefaultSubReconciler: utils.NewDefaultSubReconciler(client),
+		config:               config,
+	}
+}
+
+func (r *NetworkPolicyReconciler) Reconcile(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) (ctrl.Result, error) {
+	if !challengeInstance.DeletionTimestamp.IsZero() {
+		// We do not create network policies when the resource is already being deleted. They are removed together
+		// with the namespace.
+		return ctrl.Result{}, nil
+	}
+
+	if !meta.IsStatusConditionTrue(challengeInstance.Status.Conditions, v1alpha1.ChallengeInstanceConditionNamespaceReady) {
+		// The network policies can only be created when the namespace exists.
+		return ctrl.Result{}, nil
+	}
+
+	challengeDescription, err := description.Get(ctx, r.GetClient(), challengeInstance)
+	if err != nil {
+		return ctrl.Result{}, errors.Join(err, r.setNetworkPoliciesFailed(ctx, challengeInstance, err))
+	}
+
+	desiredSpecs := r.getDesiredNetworkPolicies(challengeInstance, challengeDescription)
+	for _, desiredSpec := range desiredSpecs {
+		if err := r.reconcileNetworkPolicy(ctx, desiredSpec); err != nil {
+			return ctrl.Result{}, errors.Join(err, r.setNetworkPoliciesFailed(ctx, challengeInstance, err))
+		}
+	}
+	if err := r.pruneNetworkPolicies(ctx, challengeInstance, desiredSpecs); err != nil {
+		return ctrl.Result{}, errors.Join(err, r.setNetworkPoliciesFailed(ctx, challengeInstance, err))
+	}
+
+	return ctrl.Result{}, setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionNetworkIsolated,
+		metav1.ConditionTrue,
+		v1alpha1.ChallengeInstanceReasonNetworkPoliciesApplied,
+		fmt.Sprintf("All %d network policies were applied", len(desiredSpecs)),
+	)
+}
+
+func (r *NetworkPolicyReconciler) setNetworkPoliciesFailed(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, err error) error {
+	return setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionNetworkIsolated,
+		metav1.ConditionFalse,
+		v1alpha1.ChallengeInstanceReasonNetworkPoliciesFailed,
+		err.Error(),
+	)
+}
+
+func (r *NetworkPolicyReconciler) reconcileNetworkPolicy(ctx context.Context, desiredSpec *networkingv1.NetworkPolicy) error {
+	var currentSpec networkingv1.NetworkPolicy
+	if err := r.GetClient().Get(ctx, client.ObjectKeyFromObject(desiredSpec), &currentSpec); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		return r.GetClient().Create(ctx, desiredSpec)
+	}
+
+	if equality.Semantic.DeepEqual(currentSpec.Spec, desiredSpec.Spec) &&
+		equality.Semantic.DeepEqual(currentSpec.Labels, desiredSpec.Labels) {
+		// The network policies are identical. Nothing to do.
+		return nil
+	}
+	currentSpec.Labels = desiredSpec.Labels
+	currentSpec.Spec = desiredSpec.Spec
+	return r.GetClient().Update(ctx, &currentSpec)
+}
+
+// pruneNetworkPolicies deletes all network policies created by the operator which are no longer desired. This happens
+// when network access is revoked in the challenge description.
+func (r *NetworkPolicyReconciler) pruneNetworkPolicies(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, desiredSpecs []*networkingv1.NetworkPolicy) error {
+	desiredNames := make(map[string]struct{}, len(desiredSpecs))
+	for _, desiredSpec := range desiredSpecs {
+		desiredNames[desiredSpec.Name] = struct{}{}
+	}
+
+	var networkPolicyList networkingv1.NetworkPolicyList
+	if err := r.GetClient().List(
+		ctx,
+		&networkPolicyList,
+		client.InNamespace(challengeInstance.Status.Namespace),
+		client.MatchingLabels{ManagedByLabelName: ManagedByLabelValue},
+	); err != nil {
+		return err
+	}
+	for _, networkPolicy := range networkPolicyList.Items {
+		if _, ok := desiredNames[networkPolicy.Name]; ok {
+			continue
+		}
+		if err := r.GetClient().Delete(ctx, &networkPolicy); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getDesiredNetworkPolicies returns the network policies which should exist in the namespace of the challenge
+// instance. Network policies are additive, so the default deny policy is extended by the allow policies.
+func (r *NetworkPolicyReconciler) getDesiredNetworkPolicies(challengeInstance *v1alpha1.ChallengeInstance, challengeDescription *v1alpha1.ChallengeDescription) []*networkingv1.NetworkPolicy {
+	var networkAccess v1alpha1.NetworkAccess
+	if challengeDescription.Spec.NetworkAccess != nil {
+		networkAccess = *challengeDescription.Spec.NetworkAccess
+	}
+
+	dnsPort := intstr.FromInt32(53)
+	udp := corev1.ProtocolUDP
+	tcp := corev1.ProtocolTCP
+	networkPolicies := []*networkingv1.NetworkPolicy{
+		r.newNetworkPolicy(challengeInstance, NetworkPolicyDefaultDenyName, networkingv1.NetworkPolicySpec{
+			PolicyTypes: []networkingv1.PolicyType{
+				networkingv1.PolicyTypeIngress,
+				networkingv1.PolicyTypeEgress,
+			},
+		}),
+		r.newNetworkPolicy(challengeInstance, NetworkPolicyAllowNamespaceName, networkingv1.NetworkPolicySpec{
+			PolicyTypes: []networkingv1.PolicyType{
+				networkingv1.PolicyTypeIngress,
+				networkingv1.PolicyTypeEgress,
+			},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					From: []networkingv1.NetworkPolicyPeer{
+						{PodSelector: &metav1.LabelSelector{}},
+					},
+				},
+			},
+			Egress: []networkingv1.NetworkPolicyEgressRule{
+				{
+					To: []networkingv1.NetworkPolicyPeer{
+						{PodSelector: &metav1.LabelSelector{}},
+					},
+				},
+			},
+		}),
+		r.newNetworkPolicy(challengeInstance, NetworkPolicyAllowDNSName, networkingv1.NetworkPolicySpec{
+			PolicyTypes: []networkingv1.PolicyType{
+				networkingv1.PolicyTypeEgress,
+			},
+			Egress: []networkingv1.NetworkPolicyEgressRule{
+				{
+					To: []networkingv1.NetworkPolicyPeer{
+						r.getDNSPeer(),
+					},
+					Ports: []networkingv1.NetworkPolicyPort{
+						{Protocol: &udp, Port: &dnsPort},
+						{Protocol: &tcp, Port: &dnsPort},
+					},
+				},
+			},
+		}),
+	}
+
+	var ingressNamespaces []string
+	if len(r.config.IngressNamespace) != 0 {
+		ingressNamespaces = append(ingressNamespaces, r.config.IngressNamespace)
+	}
+	ingressNamespaces = append(ingressNamespaces, networkAccess.IngressNamespaces...)
+	if len(ingressNamespaces) != 0 {
+		networkPolicies = append(networkPolicies, r.newNetworkPolicy(challengeInstance, NetworkPolicyAllowIngressName, networkingv1.NetworkPolicySpec{
+			PolicyTypes: []networkingv1.PolicyType{
+				networkingv1.PolicyTypeIngress,
+			},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					From: getNamespacePeers(ingressNamespaces),
+				},
+			},
+		}))
+	}
+
+	if networkPolicy := r.getPortNetworkPolicy(challengeInstance, challengeDescription); networkPolicy != nil {
+		networkPolicies = append(networkPolicies, networkPolicy)
+	}
+
+	egressPeers := getNamespacePeers(networkAccess.EgressNamespaces)
+	if networkAccess.Internet {
+		egressPeers = append(egressPeers,
+			networkingv1.NetworkPolicyPeer{
+				IPBlock: &networkingv1.IPBlock{
+					CIDR:   "0.0.0.0/0",
+					Except: privateCIDRs,
+				},
+			},
+			networkingv1.NetworkPolicyPeer{
+				IPBlock: &networkingv1.IPBlock{
+					CIDR:   "::/0",
+					Except: privateIPv6CIDRs,
+				},
+			},
+		)
+	}
+	if len(egressPeers) != 0 {
+		networkPolicies = append(networkPolicies, r.newNetworkPolicy(challengeInstance, NetworkPolicyAllowEgressName, networkingv1.NetworkPolicySpec{
+			PolicyTypes: []networkingv1.PolicyType{
+				networkingv1.PolicyTypeEgress,
+			},
+			Egress: []networkingv1.NetworkPolicyEgressRule{
+				{
+					To: egressPeers,
+				},
+			},
+		}))
+	}
+	return networkPolicies
+}
+
+func (r *NetworkPolicyReconciler) newNetworkPolicy(challengeInstance *v1alpha1.ChallengeInstance, name string, spec networkingv1.NetworkPolicySpec) *networkingv1.NetworkPolicy {
+	// All network policies apply to all pods in the namespace.
+	spec.PodSelector = metav1.LabelSelector{}
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: challengeInstance.Status.Namespace,
+			Name:      name,
+			Labels: map[string]string{
+				ManagedByLabelName: ManagedByLabelValue,
+			},
+		},
+		Spec: spec,
+	}
+}
+
+// getPortNetworkPolicy returns the network policy which allows traffic from any source to the pods of the port
+// exposure. Connections to a node port or load balancer arrive from outside the cluster or from the nodes, so they can
+// not be restricted to a namespace. The network policy is only returned when a port is allocated. The allocation is
+// recorded in the status after the network policies were reconciled, which triggers another reconciliation.
+func (r *NetworkPolicyReconciler) getPortNetworkPolicy(challengeInstance *v1alpha1.ChallengeInstance, challengeDescription *v1alpha1.ChallengeDescription) *networkingv1.NetworkPolicy {
+	portExposure := challengeDescription.Spec.PortExposure
+	if portExposure == nil || challengeInstance.Status.AllocatedPort == 0 {
+		return nil
+	}
+
+	targetPort := intstr.FromInt32(portExposure.TargetPort)
+	tcp := corev1.ProtocolTCP
+	networkPolicy := r.newNetworkPolicy(challengeInstance, NetworkPolicyAllowPortName, networkingv1.NetworkPolicySpec{
+		PolicyTypes: []networkingv1.PolicyType{
+			networkingv1.PolicyTypeIngress,
+		},
+		Ingress: []networkingv1.NetworkPolicyIngressRule{
+			{
+				Ports: []networkingv1.NetworkPolicyPort{
+					{Protocol: &tcp, Port: &targetPort},
+				},
+			},
+		},
+	})
+	// Only the pods receiving the connections of the port exposure are reachable.
+	networkPolicy.Spec.PodSelector = metav1.LabelSelector{
+		MatchLabels: portExposure.Selector,
+	}
+	return networkPolicy
+}
+
+// getDNSPeer returns the network policy peer which selects the cluster DNS pods.
+func (r *NetworkPolicyReconciler) getDNSPeer() networkingv1.NetworkPolicyPeer {
+	namespaceSelector := &metav1.LabelSelector{}
+	if len(r.config.DNSNamespace) != 0 {
+		namespaceSelector.MatchLabels = map[string]string{
+			corev1.LabelMetadataName: r.config.DNSNamespace,
+		}
+	}
+	return networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: namespaceSelector,
+		PodSelector: &metav1.LabelSelector{
+			MatchLabels: r.config.DNSPodLabels,
+		},
+	}
+}
+
+// getNamespacePeers returns network policy peers which select all pods in the given namespaces.
+func getNamespacePeers(namespaces []string) []networkingv1.NetworkPolicyPeer {
+	peers := make([]networkingv1.NetworkPolicyPeer, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					corev1.LabelMetadataName: namespace,
+				},
+			},
+		})
+	}
+	return peers
+}
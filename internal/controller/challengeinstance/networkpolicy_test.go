@@ -0,0 +1,271 @@
+package challengeinstance_test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstance"
+	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+var _ = Describe("NetworkPolicyReconciler", func() {
+	var reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]
+
+	BeforeEach(func() {
+		reconciler = challengeinstance.NewReconciler(
+			k8sClient,
+			challengeinstance.WithNamespaceReconciler(
+				challengeinstance.DefaultNamespacePrefix,
+				challengeinstance.DefaultPodSecurityLevel,
+				challengeinstance.DefaultMaxPodSecurityLevel,
+			),
+			challengeinstance.WithNetworkPolicyReconciler(challengeinstance.NetworkPolicyConfig{
+				IngressNamespace: "test-ingress",
+				DNSNamespace:     "test-dns",
+				DNSPodLabels: map[string]string{
+					"k8s-app": "test-dns",
+				},
+			}),
+		)
+	})
+
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+	})
+
+	It("should isolate the namespace by default", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := createInstanceWithNetworkAccess(ctx, nil)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionNetworkIsolated)).To(BeTrue())
+		Expect(getNetworkPolicyNames(ctx, instance)).To(ConsistOf(
+			challengeinstance.NetworkPolicyDefaultDenyName,
+			challengeinstance.NetworkPolicyAllowNamespaceName,
+			challengeinstance.NetworkPolicyAllowDNSName,
+			challengeinstance.NetworkPolicyAllowIngressName,
+		))
+
+		var networkPolicy networkingv1.NetworkPolicy
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.NetworkPolicyAllowIngressName,
+		}, &networkPolicy)).To(Succeed())
+		Expect(networkPolicy.Spec.Ingress).To(HaveLen(1))
+		Expect(networkPolicy.Spec.Ingress[0].From).To(HaveLen(1))
+		Expect(networkPolicy.Spec.Ingress[0].From[0].NamespaceSelector.MatchLabels).To(
+			HaveKeyWithValue(corev1.LabelMetadataName, "test-ingress"),
+		)
+	})
+
+	It("should only allow DNS lookups at the cluster DNS", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := createInstanceWithNetworkAccess(ctx, nil)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		var networkPolicy networkingv1.NetworkPolicy
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.NetworkPolicyAllowDNSName,
+		}, &networkPolicy)).To(Succeed())
+		Expect(networkPolicy.Spec.Egress).To(HaveLen(1))
+		Expect(networkPolicy.Spec.Egress[0].Ports).To(HaveLen(2))
+		Expect(networkPolicy.Spec.Egress[0].To).To(HaveLen(1))
+		peer := networkPolicy.Spec.Egress[0].To[0]
+		Expect(peer.IPBlock).To(BeNil())
+		Expect(peer.NamespaceSelector).ToNot(BeNil())
+		Expect(peer.NamespaceSelector.MatchLabels).To(Equal(map[string]string{
+			corev1.LabelMetadataName: "test-dns",
+		}))
+		Expect(peer.PodSelector).ToNot(BeNil())
+		Expect(peer.PodSelector.MatchLabels).To(Equal(map[string]string{
+			"k8s-app": "test-dns",
+		}))
+	})
+
+	It("should grant the network access of the challenge description", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := createInstanceWithNetworkAccess(ctx, &v1alpha1.NetworkAccess{
+			Internet:          true,
+			EgressNamespaces:  []string{"test-egress"},
+			IngressNamespaces: []string{"test-other-ingress"},
+		})
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		var networkPolicy networkingv1.NetworkPolicy
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.NetworkPolicyAllowEgressName,
+		}, &networkPolicy)).To(Succeed())
+		Expect(networkPolicy.Spec.Egress).To(HaveLen(1))
+		Expect(networkPolicy.Spec.Egress[0].To).To(HaveLen(3))
+		Expect(networkPolicy.Spec.Egress[0].To[1].IPBlock.CIDR).To(Equal("0.0.0.0/0"))
+		Expect(networkPolicy.Spec.Egress[0].To[1].IPBlock.Except).To(ContainElement("169.254.0.0/16"))
+
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.NetworkPolicyAllowIngressName,
+		}, &networkPolicy)).To(Succeed())
+		Expect(networkPolicy.Spec.Ingress[0].From).To(HaveLen(2))
+	})
+
+	It("should allow external traffic to the allocated port", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := createInstanceWithNetworkAccess(ctx, nil)
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		var description v1alpha1.ChallengeDescription
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Namespace,
+			Name:      instance.Spec.ChallengeDescriptionName,
+		}, &description)).To(Succeed())
+		description.Spec.PortExposure = &v1alpha1.PortExposure{
+			Type: corev1.ServiceTypeNodePort,
+			Selector: map[string]string{
+				"app": "test",
+			},
+			TargetPort: 1337,
+		}
+		Expect(k8sClient.Update(ctx, &description)).To(Succeed())
+
+		result, err = reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(getNetworkPolicyNames(ctx, instance)).ToNot(ContainElement(challengeinstance.NetworkPolicyAllowPortName))
+
+		instance.Status.AllocatedPort = 30000
+		Expect(k8sClient.Status().Update(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err = reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		var networkPolicy networkingv1.NetworkPolicy
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.NetworkPolicyAllowPortName,
+		}, &networkPolicy)).To(Succeed())
+		Expect(networkPolicy.Labels).To(HaveKeyWithValue(challengeinstance.ManagedByLabelName, challengeinstance.ManagedByLabelValue))
+		Expect(networkPolicy.Spec.PodSelector.MatchLabels).To(Equal(map[string]string{
+			"app": "test",
+		}))
+		Expect(networkPolicy.Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeIngress))
+		Expect(networkPolicy.Spec.Ingress).To(HaveLen(1))
+		Expect(networkPolicy.Spec.Ingress[0].From).To(BeEmpty())
+		Expect(networkPolicy.Spec.Ingress[0].Ports).To(HaveLen(1))
+		Expect(*networkPolicy.Spec.Ingress[0].Ports[0].Protocol).To(Equal(corev1.ProtocolTCP))
+		Expect(networkPolicy.Spec.Ingress[0].Ports[0].Port.IntValue()).To(Equal(1337))
+	})
+
+	It("should remove network access which was revoked", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := createInstanceWithNetworkAccess(ctx, &v1alpha1.NetworkAccess{
+			Internet: true,
+		})
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(getNetworkPolicyNames(ctx, instance)).To(ContainElement(challengeinstance.NetworkPolicyAllowEgressName))
+
+		var description v1alpha1.ChallengeDescription
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Namespace,
+			Name:      instance.Spec.ChallengeDescriptionName,
+		}, &description)).To(Succeed())
+		description.Spec.NetworkAccess = nil
+		Expect(k8sClient.Update(ctx, &description)).To(Succeed())
+
+		By("run the reconciler")
+		result, err = reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(getNetworkPolicyNames(ctx, instance)).ToNot(ContainElement(challengeinstance.NetworkPolicyAllowEgressName))
+	})
+})
+
+func createInstanceWithNetworkAccess(ctx SpecContext, networkAccess *v1alpha1.NetworkAccess) v1alpha1.ChallengeInstance {
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testutils.GenerateName("test-"),
+		},
+	}
+	configMapRaw, err := ToRaw(&configMap)
+	Expect(err).ToNot(HaveOccurred())
+
+	description := v1alpha1.ChallengeDescription{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeDescriptionSpec{
+			Title:         "test",
+			Description:   "test",
+			Flag:          "test",
+			NetworkAccess: networkAccess,
+			Manifests: []runtime.RawExtension{
+				{
+					Raw: configMapRaw,
+				},
+			},
+		},
+	}
+	Expect(k8sClient.Create(ctx, &description)).To(Succeed())
+
+	instance := v1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeInstanceSpec{
+			ChallengeDescriptionName: description.Name,
+		},
+	}
+	Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+	return instance
+}
+
+func getNetworkPolicyNames(ctx SpecContext, instance v1alpha1.ChallengeInstance) []string {
+	var networkPolicyList networkingv1.NetworkPolicyList
+	Expect(k8sClient.List(ctx, &networkPolicyList, client.InNamespace(instance.Status.Namespace))).To(Succeed())
+
+	names := make([]string, 0, len(networkPolicyList.Items))
+	for _, networkPolicy := range networkPolicyList.Items {
+		names = append(names, networkPolicy.Name)
+	}
+	return names
+}
@@ -0,0 +1,263 @@
+package challengeinstance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/description"
+	"github.com/backbone81/ctf-challenge-operator/internal/portallocator"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+// PortServiceName is the name of the service which is generated in the namespace of every challenge instance for the
+// port exposure of the challenge description.
+const PortServiceName = "ctf-port"
+
+// PortReconciler is responsible for allocating an external port from the port range of the operator and exposing the
+// challenge instance on that port with a service of type NodePort or LoadBalancer. The allocated port is persisted in
+// the status of the challenge instance. The allocations are restored from there, so a restart of the operator does not
+// hand out a port twice.
+type PortReconciler struct {
+	utils.DefaultSubReconciler
+	allocator *portallocator.Allocator
+}
+
+// NewPortReconciler returns a new port reconciler. A nil allocator means that no port range is configured, in which
+// case port exposures are reported as failed.
+func NewPortReconciler(client client.Client, allocator *portallocator.Allocator) *PortReconciler {
+	return &PortReconciler{
+		DefaultSubReconciler: utils.NewDefaultSubReconciler(client),
+		allocator:            allocator,
+	}
+}
+
+func (r *PortReconciler) Reconcile(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) (ctrl.Result, error) {
+	if !challengeInstance.DeletionTimestamp.IsZero() {
+		// The service is removed together with the namespace. We delete it right away nevertheless, because the node
+		// port is only freed with the service and we want to hand out the port again.
+		return ctrl.Result{}, r.releasePort(ctx, challengeInstance)
+	}
+
+	if !meta.IsStatusConditionTrue(challengeInstance.Status.Conditions, v1alpha1.ChallengeInstanceConditionNamespaceReady) {
+		// The service can only be created when the namespace exists.
+		return ctrl.Result{}, nil
+	}
+
+	challengeDescription, err := description.Get(ctx, r.GetClient(), challengeInstance)
+	if err != nil {
+		return ctrl.Result{}, errors.Join(err, r.setPortAllocationFailed(ctx, challengeInstance, err))
+	}
+
+	portExposure := challengeDescription.Spec.PortExposure
+	if portExposure == nil {
+		if err := r.releasePort(ctx, challengeInstance); err != nil {
+			return ctrl.Result{}, errors.Join(err, r.setPortAllocationFailed(ctx, challengeInstance, err))
+		}
+		if err := r.updateAllocatedPort(ctx, challengeInstance, 0); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, setCondition(
+			ctx,
+			r.GetClient(),
+			challengeInstance,
+			v1alpha1.ChallengeInstanceConditionPortAllocated,
+			metav1.ConditionTrue,
+			v1alpha1.ChallengeInstanceReasonPortNotRequested,
+			"The challenge description does not request a port exposure",
+		)
+	}
+
+	if portExposure.Type == corev1.ServiceTypeClusterIP {
+		return ctrl.Result{}, r.reconcileGatewayOnly(ctx, challengeInstance, portExposure)
+	}
+
+	if r.allocator == nil {
+		// Without a port range, we can not allocate a port. This is a configuration issue of the operator, which we
+		// report instead of retrying.
+		return ctrl.Result{}, r.setPortAllocationFailed(ctx, challengeInstance, errors.New("no port range is configured for the operator"))
+	}
+
+	port, err := r.allocatePort(ctx, challengeInstance)
+	if err != nil {
+		err = fmt.Errorf("allocating port from range %s: %w", r.allocator.Range(), err)
+		return ctrl.Result{}, errors.Join(err, r.setPortAllocationFailed(ctx, challengeInstance, err))
+	}
+
+	// The allocation is persisted before the service is created, so that it survives a restart of the operator in
+	// between.
+	if err := r.updateAllocatedPort(ctx, challengeInstance, port); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	desiredSpec := getDesiredPortService(challengeInstance, portExposure, port)
+	if err := r.GetClient().Patch(ctx, desiredSpec, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership); err != nil {
+		err = fmt.Errorf("applying Service %s/%s: %w", desiredSpec.GetNamespace(), desiredSpec.GetName(), err)
+		return ctrl.Result{}, errors.Join(err, r.setPortAllocationFailed(ctx, challengeInstance, err))
+	}
+	return ctrl.Result{}, setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionPortAllocated,
+		metav1.ConditionTrue,
+		v1alpha1.ChallengeInstanceReasonPortAllocated,
+		fmt.Sprintf("Port %d is allocated", port),
+	)
+}
+
+// reconcileGatewayOnly creates the service for a port exposure which is only reachable through the TCP gateway. No
+// external port is needed, and a port which was allocated before is released.
+func (r *PortReconciler) reconcileGatewayOnly(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, portExposure *v1alpha1.PortExposure) error {
+	if r.allocator != nil {
+		r.allocator.Release(string(challengeInstance.UID))
+	}
+	if err := r.updateAllocatedPort(ctx, challengeInstance, 0); err != nil {
+		return err
+	}
+
+	desiredSpec := getDesiredPortService(challengeInstance, portExposure, portExposure.TargetPort)
+	if err := r.GetClient().Patch(ctx, desiredSpec, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership); err != nil {
+		err = fmt.Errorf("applying Service %s/%s: %w", desiredSpec.GetNamespace(), desiredSpec.GetName(), err)
+		return errors.Join(err, r.setPortAllocationFailed(ctx, challengeInstance, err))
+	}
+	return setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionPortAllocated,
+		metav1.ConditionTrue,
+		v1alpha1.ChallengeInstanceReasonGatewayOnly,
+		"The service is only reachable through the TCP gateway",
+	)
+}
+
+func (r *PortReconciler) setPortAllocationFailed(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, err error) error {
+	return setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionPortAllocated,
+		metav1.ConditionFalse,
+		v1alpha1.ChallengeInstanceReasonPortAllocationFailed,
+		err.Error(),
+	)
+}
+
+func (r *PortReconciler) updateAllocatedPort(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, port int32) error {
+	if challengeInstance.Status.AllocatedPort == port {
+		return nil
+	}
+	challengeInstance.Status.AllocatedPort = port
+	return r.GetClient().Status().Update(ctx, challengeInstance)
+}
+
+// allocatePort returns the port of the challenge instance. A port which is already recorded in the status is kept,
+// unless it was handed out to another challenge instance in the meantime.
+func (r *PortReconciler) allocatePort(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) (int32, error) {
+	key := string(challengeInstance.UID)
+	if port, ok := r.allocator.Lookup(key); ok {
+		return port, nil
+	}
+
+	// The allocator does not know about this challenge instance. This happens after a restart of the operator or when
+	// the challenge instance is new. In both cases we need all allocations of the other challenge instances before we
+	// can safely hand out a port.
+	if err := r.restoreAllocations(ctx); err != nil {
+		return 0, err
+	}
+	if port, ok := r.allocator.Lookup(key); ok {
+		return port, nil
+	}
+	return r.allocator.Allocate(key)
+}
+
+// restoreAllocations records the ports of all challenge instances in the allocator and releases the ports of challenge
+// instances which no longer exist.
+func (r *PortReconciler) restoreAllocations(ctx context.Context) error {
+	var challengeInstanceList v1alpha1.ChallengeInstanceList
+	if err := r.GetClient().List(ctx, &challengeInstanceList); err != nil {
+		return err
+	}
+
+	keys := make(map[string]bool, len(challengeInstanceList.Items))
+	for _, challengeInstance := range challengeInstanceList.Items {
+		key := string(challengeInstance.UID)
+		keys[key] = true
+		if challengeInstance.Status.AllocatedPort == 0 {
+			continue
+		}
+		// A port outside the range is left over from a previous configuration, and a conflicting port can only be
+		// kept by one of the challenge instances. In both cases the error is ignored, and the affected challenge
+		// instance receives a new port when it is reconciled.
+		_ = r.allocator.Restore(key, challengeInstance.Status.AllocatedPort)
+	}
+	r.allocator.Retain(keys)
+	return nil
+}
+
+// releasePort deletes the service of the port exposure and frees the allocated port.
+func (r *PortReconciler) releasePort(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) error {
+	if len(challengeInstance.Status.Namespace) != 0 {
+		var service corev1.Service
+		service.Namespace = challengeInstance.Status.Namespace
+		service.Name = PortServiceName
+		if err := r.GetClient().Delete(ctx, &service); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	if r.allocator != nil {
+		r.allocator.Release(string(challengeInstance.UID))
+	}
+	return nil
+}
+
+// getDesiredPortService returns the service which exposes the challenge instance on the given port. Node ports are
+// taken from the port range, load balancers listen on the port and receive a node port from Kubernetes. Services of
+// type ClusterIP listen on the given port within the cluster only.
+func getDesiredPortService(challengeInstance *v1alpha1.ChallengeInstance, portExposure *v1alpha1.PortExposure, port int32) *unstructured.Unstructured {
+	serviceType := portExposure.Type
+	if len(serviceType) == 0 {
+		serviceType = corev1.ServiceTypeNodePort
+	}
+
+	servicePort := map[string]any{
+		"name":       "tcp",
+		"protocol":   string(corev1.ProtocolTCP),
+		"port":       int64(port),
+		"targetPort": int64(portExposure.TargetPort),
+	}
+	if serviceType == corev1.ServiceTypeNodePort {
+		servicePort["nodePort"] = int64(port)
+	}
+
+	selector := make(map[string]any, len(portExposure.Selector))
+	for key, value := range portExposure.Selector {
+		selector[key] = value
+	}
+
+	result := &unstructured.Unstructured{
+		Object: map[string]any{
+			"spec": map[string]any{
+				"type":     string(serviceType),
+				"selector": selector,
+				"ports":    []any{servicePort},
+			},
+		},
+	}
+	result.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind(serviceGroupKind.Kind))
+	result.SetNamespace(challengeInstance.Status.Namespace)
+	result.SetName(PortServiceName)
+	result.SetLabels(map[string]string{
+		ManagedByLabelName: ManagedByLabelValue,
+	})
+	return result
+}
@@ -0,0 +1,278 @@
+package challengeinstance_test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstance"
+	"github.com/backbone81/ctf-challenge-operator/internal/portallocator"
+	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+var _ = Describe("PortReconciler", func() {
+	var allocator *portallocator.Allocator
+	var reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]
+
+	BeforeEach(func() {
+		allocator = portallocator.New(portallocator.Range{Min: 32700, Max: 32709})
+		reconciler = newPortReconciler(allocator)
+	})
+
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+		deleteAllPortServices(ctx)
+	})
+
+	It("should expose the instance on an allocated node port", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := createInstanceWithPortExposure(ctx, &v1alpha1.PortExposure{
+			Selector: map[string]string{
+				"app": "test",
+			},
+			TargetPort: 1337,
+		})
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionPortAllocated)).To(BeTrue())
+		port := instance.Status.AllocatedPort
+		Expect(allocator.Range().Contains(port)).To(BeTrue())
+
+		var service corev1.Service
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.PortServiceName,
+		}, &service)).To(Succeed())
+		Expect(service.Labels).To(HaveKeyWithValue(challengeinstance.ManagedByLabelName, challengeinstance.ManagedByLabelValue))
+		Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeNodePort))
+		Expect(service.Spec.Selector).To(Equal(map[string]string{"app": "test"}))
+		Expect(service.Spec.Ports).To(HaveLen(1))
+		Expect(service.Spec.Ports[0].Port).To(Equal(port))
+		Expect(service.Spec.Ports[0].NodePort).To(Equal(port))
+		Expect(service.Spec.Ports[0].TargetPort.IntValue()).To(Equal(1337))
+
+		Expect(instance.Status.Endpoints).To(Equal([]v1alpha1.ChallengeInstanceEndpoint{
+			{
+				Kind:     "Service",
+				Name:     challengeinstance.PortServiceName,
+				Host:     "203.0.113.10",
+				Port:     port,
+				Protocol: "TCP",
+			},
+		}))
+	})
+
+	It("should not hand out ports of existing instances after a restart", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		portExposure := v1alpha1.PortExposure{
+			Selector: map[string]string{
+				"app": "test",
+			},
+			TargetPort: 1337,
+		}
+		firstInstance := createInstanceWithPortExposure(ctx, &portExposure)
+		_, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&firstInstance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&firstInstance), &firstInstance)).To(Succeed())
+		Expect(firstInstance.Status.AllocatedPort).ToNot(BeZero())
+
+		// A new allocator without any allocations simulates a restart of the operator.
+		restartedAllocator := portallocator.New(allocator.Range())
+		reconciler = newPortReconciler(restartedAllocator)
+		secondInstance := createInstanceWithPortExposure(ctx, &portExposure)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&secondInstance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&secondInstance), &secondInstance)).To(Succeed())
+		Expect(secondInstance.Status.AllocatedPort).ToNot(BeZero())
+		Expect(secondInstance.Status.AllocatedPort).ToNot(Equal(firstInstance.Status.AllocatedPort))
+		port, ok := restartedAllocator.Lookup(string(firstInstance.UID))
+		Expect(ok).To(BeTrue())
+		Expect(port).To(Equal(firstInstance.Status.AllocatedPort))
+	})
+
+	It("should release the port when the port exposure is removed", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := createInstanceWithPortExposure(ctx, &v1alpha1.PortExposure{
+			Selector: map[string]string{
+				"app": "test",
+			},
+			TargetPort: 1337,
+		})
+		_, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+
+		var description v1alpha1.ChallengeDescription
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Namespace,
+			Name:      instance.Spec.ChallengeDescriptionName,
+		}, &description)).To(Succeed())
+		description.Spec.PortExposure = nil
+		Expect(k8sClient.Update(ctx, &description)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionPortAllocated)).To(BeTrue())
+		Expect(instance.Status.AllocatedPort).To(BeZero())
+		Expect(allocator.Len()).To(BeZero())
+
+		var service corev1.Service
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.PortServiceName,
+		}, &service)).To(MatchError(ContainSubstring("not found")))
+	})
+
+	It("should only expose ClusterIP services through the TCP gateway", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		reconciler = newPortReconciler(nil)
+		instance := createInstanceWithPortExposure(ctx, &v1alpha1.PortExposure{
+			Type: corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{
+				"app": "test",
+			},
+			TargetPort: 1337,
+		})
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		condition := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionPortAllocated)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(v1alpha1.ChallengeInstanceReasonGatewayOnly))
+		Expect(instance.Status.AllocatedPort).To(BeZero())
+
+		var service corev1.Service
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.PortServiceName,
+		}, &service)).To(Succeed())
+		Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeClusterIP))
+		Expect(service.Spec.Ports).To(HaveLen(1))
+		Expect(service.Spec.Ports[0].Port).To(Equal(int32(1337)))
+		Expect(service.Spec.Ports[0].NodePort).To(BeZero())
+	})
+
+	It("should report a missing port range", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		reconciler = newPortReconciler(nil)
+		instance := createInstanceWithPortExposure(ctx, &v1alpha1.PortExposure{
+			Selector: map[string]string{
+				"app": "test",
+			},
+			TargetPort: 1337,
+		})
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		condition := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionPortAllocated)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(v1alpha1.ChallengeInstanceReasonPortAllocationFailed))
+		Expect(instance.Status.AllocatedPort).To(BeZero())
+	})
+})
+
+// deleteAllPortServices deletes the services of all port exposures. Namespaces are never removed in the test
+// environment, so the services would otherwise keep their node ports across tests.
+func deleteAllPortServices(ctx SpecContext) {
+	var serviceList corev1.ServiceList
+	Expect(k8sClient.List(ctx, &serviceList, client.MatchingLabels{
+		challengeinstance.ManagedByLabelName: challengeinstance.ManagedByLabelValue,
+	})).To(Succeed())
+
+	for _, service := range serviceList.Items {
+		if service.Name != challengeinstance.PortServiceName {
+			continue
+		}
+		Expect(k8sClient.Delete(ctx, &service)).To(Succeed())
+	}
+}
+
+func newPortReconciler(allocator *portallocator.Allocator) *utils.Reconciler[*v1alpha1.ChallengeInstance] {
+	return challengeinstance.NewReconciler(
+		k8sClient,
+		challengeinstance.WithNamespaceReconciler(
+			challengeinstance.DefaultNamespacePrefix,
+			challengeinstance.DefaultPodSecurityLevel,
+			challengeinstance.DefaultMaxPodSecurityLevel,
+		),
+		challengeinstance.WithManifestsReconciler(record.NewFakeRecorder(5)),
+		challengeinstance.WithPortReconciler(allocator),
+		challengeinstance.WithEndpointsReconciler(record.NewFakeRecorder(5), "203.0.113.10"),
+	)
+}
+
+func createInstanceWithPortExposure(ctx SpecContext, portExposure *v1alpha1.PortExposure) v1alpha1.ChallengeInstance {
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testutils.GenerateName("test-"),
+		},
+	}
+	configMapRaw, err := ToRaw(&configMap)
+	Expect(err).ToNot(HaveOccurred())
+
+	description := v1alpha1.ChallengeDescription{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeDescriptionSpec{
+			Title:        "test",
+			Description:  "test",
+			Flag:         "test",
+			PortExposure: portExposure,
+			Manifests: []runtime.RawExtension{
+				{
+					Raw: configMapRaw,
+				},
+			},
+		},
+	}
+	Expect(k8sClient.Create(ctx, &description)).To(Succeed())
+
+	instance := v1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeInstanceSpec{
+			ChallengeDescriptionName: description.Name,
+		},
+	}
+	Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+	return instance
+}
@@ -0,0 +1,126 @@
+package challengeinstance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/description"
+	"github.com/backbone81/ctf-challenge-operator/internal/readiness"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+// ReadinessRequeueInterval is the interval in which the readiness of the objects is checked again while they did not
+// reach their desired state.
+const ReadinessRequeueInterval = 5 * time.Second
+
+// ReadinessRecheckInterval is the interval in which the readiness of the objects is checked again after they reached
+// their desired state. The objects live in the namespace of the challenge instance and are not watched, so this is how
+// workloads which degrade later on are noticed.
+const ReadinessRecheckInterval = time.Minute
+
+// ReadinessReconciler is responsible for evaluating if all objects created from the manifests of the challenge
+// instance reached their desired state.
+type ReadinessReconciler struct {
+	utils.DefaultSubReconciler
+}
+
+func NewReadinessReconciler(client client.Client) *ReadinessReconciler {
+	return &ReadinessReconciler{
+		DefaultSubReconciler: utils.NewDefaultSubReconciler(client),
+	}
+}
+
+func (r *ReadinessReconciler) Reconcile(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) (ctrl.Result, error) {
+	if !challengeInstance.DeletionTimestamp.IsZero() {
+		// We do not evaluate the readiness when the resource is already being deleted.
+		return ctrl.Result{}, nil
+	}
+
+	if !meta.IsStatusConditionTrue(challengeInstance.Status.Conditions, v1alpha1.ChallengeInstanceConditionManifestsApplied) {
+		// There is no point in checking the readiness before all manifests were applied.
+		return ctrl.Result{}, nil
+	}
+
+	challengeDescription, err := description.Get(ctx, r.GetClient(), challengeInstance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	desiredSpecs, err := getDesiredManifests(ctx, r.GetClient(), challengeInstance, challengeDescription)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var inProgressMessages []string
+	var failedMessages []string
+	for _, desiredSpec := range desiredSpecs {
+		result, err := r.evaluate(ctx, desiredSpec)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		message := fmt.Sprintf("%s %s: %s", desiredSpec.GetKind(), desiredSpec.GetName(), result.Message)
+		switch result.Status {
+		case readiness.StatusInProgress:
+			inProgressMessages = append(inProgressMessages, message)
+		case readiness.StatusFailed:
+			failedMessages = append(failedMessages, message)
+		case readiness.StatusCurrent:
+		}
+	}
+
+	if len(failedMessages) != 0 {
+		return ctrl.Result{RequeueAfter: ReadinessRequeueInterval}, setCondition(
+			ctx,
+			r.GetClient(),
+			challengeInstance,
+			v1alpha1.ChallengeInstanceConditionWorkloadsReady,
+			metav1.ConditionFalse,
+			v1alpha1.ChallengeInstanceReasonWorkloadsFailed,
+			strings.Join(failedMessages, "; "),
+		)
+	}
+	if len(inProgressMessages) != 0 {
+		return ctrl.Result{RequeueAfter: ReadinessRequeueInterval}, setCondition(
+			ctx,
+			r.GetClient(),
+			challengeInstance,
+			v1alpha1.ChallengeInstanceConditionWorkloadsReady,
+			metav1.ConditionUnknown,
+			v1alpha1.ChallengeInstanceReasonWorkloadsProgressing,
+			strings.Join(inProgressMessages, "; "),
+		)
+	}
+	return ctrl.Result{RequeueAfter: ReadinessRecheckInterval}, setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionWorkloadsReady,
+		metav1.ConditionTrue,
+		v1alpha1.ChallengeInstanceReasonWorkloadsReady,
+		fmt.Sprintf("All %d objects are ready", len(desiredSpecs)),
+	)
+}
+
+func (r *ReadinessReconciler) evaluate(ctx context.Context, desiredSpec *unstructured.Unstructured) (readiness.Result, error) {
+	currentSpec, err := getCurrentSpec(ctx, r.GetClient(), desiredSpec)
+	if err != nil {
+		return readiness.Result{}, err
+	}
+	if currentSpec == nil {
+		return readiness.Result{
+			Status:  readiness.StatusInProgress,
+			Message: "does not exist yet",
+		}, nil
+	}
+	return readiness.Evaluate(currentSpec)
+}
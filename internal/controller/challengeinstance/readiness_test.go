@@ -0,0 +1,146 @@
+package challengeinstance_test
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstance"
+	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+var _ = Describe("ReadinessReconciler", func() {
+	var reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]
+
+	BeforeEach(func() {
+		reconciler = challengeinstance.NewReconciler(
+			k8sClient,
+			challengeinstance.WithManifestsReconciler(record.NewFakeRecorder(5)),
+			challengeinstance.WithReadinessReconciler(),
+		)
+	})
+
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+	})
+
+	It("should report the workloads as ready when all objects are current", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		configMap := corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: testutils.GenerateName("test-"),
+			},
+		}
+		configMapRaw, err := ToRaw(&configMap)
+		Expect(err).ToNot(HaveOccurred())
+
+		instance := createInstanceWithManifests(ctx, configMapRaw)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(challengeinstance.ReadinessRecheckInterval))
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		condition := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionWorkloadsReady)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(v1alpha1.ChallengeInstanceReasonWorkloadsReady))
+	})
+
+	It("should requeue while a deployment is not available", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		deployment := appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: testutils.GenerateName("test-"),
+			},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: ptr.To[int32](1),
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"app": "test",
+					},
+				},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							"app": "test",
+						},
+					},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name:  "test",
+								Image: "test",
+							},
+						},
+					},
+				},
+			},
+		}
+		deploymentRaw, err := ToRaw(&deployment)
+		Expect(err).ToNot(HaveOccurred())
+
+		instance := createInstanceWithManifests(ctx, deploymentRaw)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(challengeinstance.ReadinessRequeueInterval))
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		condition := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionWorkloadsReady)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionUnknown))
+		Expect(condition.Reason).To(Equal(v1alpha1.ChallengeInstanceReasonWorkloadsProgressing))
+		Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionReady)).To(BeFalse())
+	})
+})
+
+// createInstanceWithManifests creates a challenge description with the given manifests, a challenge instance
+// referencing it and the namespace of the challenge instance.
+func createInstanceWithManifests(ctx SpecContext, manifests ...[]byte) v1alpha1.ChallengeInstance {
+	description := v1alpha1.ChallengeDescription{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeDescriptionSpec{
+			Title:       "test",
+			Description: "test",
+			Flag:        "test",
+		},
+	}
+	for _, manifest := range manifests {
+		description.Spec.Manifests = append(description.Spec.Manifests, runtime.RawExtension{
+			Raw: manifest,
+		})
+	}
+	Expect(k8sClient.Create(ctx, &description)).To(Succeed())
+
+	instance := v1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeInstanceSpec{
+			ChallengeDescriptionName: description.Name,
+		},
+	}
+	Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+	CreateInstanceNamespace(ctx, &instance)
+	return instance
+}
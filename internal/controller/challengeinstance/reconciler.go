@@ -5,20 +5,40 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/portallocator"
 	"github.com/backbone81/ctf-challenge-operator/internal/utils"
 )
 
 var FinalizerName = "ctf.backbone81/challenge-instance"
 
+// FieldManager is the name of the field manager the manifests of challenge instances are applied with.
+const FieldManager = "ctf-challenge-operator"
+
 // +kubebuilder:rbac:groups=core.ctf.backbone81,resources=challengeinstances,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core.ctf.backbone81,resources=challengeinstances/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core.ctf.backbone81,resources=challengeinstances/finalizers,verbs=update
 
 // +kubebuilder:rbac:groups=core.ctf.backbone81,resources=challengedescriptions,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core.ctf.backbone81,resources=clusterchallengedescriptions,verbs=get;list;watch
 
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=replicasets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=resourcequotas,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=limitranges,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
 
 func NewReconciler(client client.Client, options ...utils.ReconcilerOption[*v1alpha1.ChallengeInstance]) *utils.Reconciler[*v1alpha1.ChallengeInstance] {
 	return utils.NewReconciler[*v1alpha1.ChallengeInstance](
@@ -31,12 +51,30 @@ func NewReconciler(client client.Client, options ...utils.ReconcilerOption[*v1al
 }
 
 // WithDefaultReconcilers returns a reconciler option which enables the default sub-reconcilers.
-func WithDefaultReconcilers(recorder record.EventRecorder) utils.ReconcilerOption[*v1alpha1.ChallengeInstance] {
+func WithDefaultReconcilers(
+	recorder record.EventRecorder,
+	namespacePrefix string,
+	defaultPodSecurityLevel v1alpha1.PodSecurityLevel,
+	maxPodSecurityLevel v1alpha1.PodSecurityLevel,
+	networkPolicyConfig NetworkPolicyConfig,
+	defaultResourceBudget v1alpha1.ResourceBudget,
+	nodeAddress string,
+	exposureConfig ExposureConfig,
+	portAllocator *portallocator.Allocator,
+) utils.ReconcilerOption[*v1alpha1.ChallengeInstance] {
 	return func(reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]) {
 		WithAddFinalizerReconciler()(reconciler)
+		WithClaimReconciler()(reconciler)
 		WithStatusReconciler()(reconciler)
-		WithNamespaceReconciler()(reconciler)
+		WithNamespaceReconciler(namespacePrefix, defaultPodSecurityLevel, maxPodSecurityLevel)(reconciler)
+		WithNetworkPolicyReconciler(networkPolicyConfig)(reconciler)
+		WithResourceLimitsReconciler(defaultResourceBudget)(reconciler)
+		WithFlagReconciler()(reconciler)
 		WithManifestsReconciler(recorder)(reconciler)
+		WithExposureReconciler(exposureConfig)(reconciler)
+		WithPortReconciler(portAllocator)(reconciler)
+		WithReadinessReconciler()(reconciler)
+		WithEndpointsReconciler(recorder, nodeAddress)(reconciler)
 		WithRemoveFinalizerReconciler()(reconciler)
 
 		// The delete reconciler must be last, because the other reconcilers behave differently when the resource is
@@ -45,6 +83,12 @@ func WithDefaultReconcilers(recorder record.EventRecorder) utils.ReconcilerOptio
 	}
 }
 
+func WithClaimReconciler() utils.ReconcilerOption[*v1alpha1.ChallengeInstance] {
+	return func(reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]) {
+		reconciler.AppendSubReconciler(NewClaimReconciler(reconciler.GetClient()))
+	}
+}
+
 func WithStatusReconciler() utils.ReconcilerOption[*v1alpha1.ChallengeInstance] {
 	return func(reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]) {
 		reconciler.AppendSubReconciler(NewStatusReconciler(reconciler.GetClient()))
@@ -57,9 +101,48 @@ func WithDeleteReconciler() utils.ReconcilerOption[*v1alpha1.ChallengeInstance]
 	}
 }
 
-func WithNamespaceReconciler() utils.ReconcilerOption[*v1alpha1.ChallengeInstance] {
+func WithNamespaceReconciler(
+	namespacePrefix string,
+	defaultPodSecurityLevel v1alpha1.PodSecurityLevel,
+	maxPodSecurityLevel v1alpha1.PodSecurityLevel,
+) utils.ReconcilerOption[*v1alpha1.ChallengeInstance] {
+	return func(reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]) {
+		reconciler.AppendSubReconciler(NewNamespaceReconciler(
+			reconciler.GetClient(),
+			namespacePrefix,
+			defaultPodSecurityLevel,
+			maxPodSecurityLevel,
+		))
+	}
+}
+
+func WithNetworkPolicyReconciler(networkPolicyConfig NetworkPolicyConfig) utils.ReconcilerOption[*v1alpha1.ChallengeInstance] {
+	return func(reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]) {
+		reconciler.AppendSubReconciler(NewNetworkPolicyReconciler(reconciler.GetClient(), networkPolicyConfig))
+	}
+}
+
+func WithResourceLimitsReconciler(defaultResourceBudget v1alpha1.ResourceBudget) utils.ReconcilerOption[*v1alpha1.ChallengeInstance] {
+	return func(reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]) {
+		reconciler.AppendSubReconciler(NewResourceLimitsReconciler(reconciler.GetClient(), defaultResourceBudget))
+	}
+}
+
+func WithExposureReconciler(exposureConfig ExposureConfig) utils.ReconcilerOption[*v1alpha1.ChallengeInstance] {
 	return func(reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]) {
-		reconciler.AppendSubReconciler(NewNamespaceReconciler(reconciler.GetClient()))
+		reconciler.AppendSubReconciler(NewExposureReconciler(reconciler.GetClient(), exposureConfig))
+	}
+}
+
+func WithPortReconciler(portAllocator *portallocator.Allocator) utils.ReconcilerOption[*v1alpha1.ChallengeInstance] {
+	return func(reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]) {
+		reconciler.AppendSubReconciler(NewPortReconciler(reconciler.GetClient(), portAllocator))
+	}
+}
+
+func WithEndpointsReconciler(recorder record.EventRecorder, nodeAddress string) utils.ReconcilerOption[*v1alpha1.ChallengeInstance] {
+	return func(reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]) {
+		reconciler.AppendSubReconciler(NewEndpointsReconciler(reconciler.GetClient(), recorder, nodeAddress))
 	}
 }
 
@@ -80,3 +163,15 @@ func WithManifestsReconciler(recorder record.EventRecorder) utils.ReconcilerOpti
 		reconciler.AppendSubReconciler(NewManifestsReconciler(reconciler.GetClient(), recorder))
 	}
 }
+
+func WithReadinessReconciler() utils.ReconcilerOption[*v1alpha1.ChallengeInstance] {
+	return func(reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]) {
+		reconciler.AppendSubReconciler(NewReadinessReconciler(reconciler.GetClient()))
+	}
+}
+
+func WithFlagReconciler() utils.ReconcilerOption[*v1alpha1.ChallengeInstance] {
+	return func(reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]) {
+		reconciler.AppendSubReconciler(NewFlagReconciler(reconciler.GetClient()))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
@@ -22,7 +23,21 @@ var _ = Describe("Reconciler", func() {
 	var reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]
 
 	BeforeEach(func() {
-		reconciler = challengeinstance.NewReconciler(k8sClient, challengeinstance.WithDefaultReconcilers(record.NewFakeRecorder(5)))
+		reconciler = challengeinstance.NewReconciler(k8sClient, challengeinstance.WithDefaultReconcilers(
+			record.NewFakeRecorder(5),
+			challengeinstance.DefaultNamespacePrefix,
+			challengeinstance.DefaultPodSecurityLevel,
+			challengeinstance.DefaultMaxPodSecurityLevel,
+			challengeinstance.NetworkPolicyConfig{
+				IngressNamespace: challengeinstance.DefaultIngressNamespace,
+				DNSNamespace:     challengeinstance.DefaultDNSNamespace,
+				DNSPodLabels:     challengeinstance.DefaultDNSPodLabels,
+			},
+			v1alpha1.ResourceBudget{},
+			"",
+			challengeinstance.ExposureConfig{},
+			nil,
+		))
 	})
 
 	AfterEach(func(ctx SpecContext) {
@@ -80,13 +95,17 @@ var _ = Describe("Reconciler", func() {
 		))
 
 		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Namespace).To(HavePrefix(challengeinstance.DefaultNamespacePrefix))
 		var namespace corev1.Namespace
 		Expect(k8sClient.Get(ctx, client.ObjectKey{
-			Name: instance.Name,
+			Name: instance.Status.Namespace,
 		}, &namespace)).To(Succeed())
 		Expect(k8sClient.Get(ctx, client.ObjectKey{
 			Name:      configMap.Name,
-			Namespace: instance.Name,
+			Namespace: instance.Status.Namespace,
 		}, &configMap)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionReady)).To(BeTrue())
+		Expect(instance.Status.Phase).To(Equal(v1alpha1.ChallengeInstancePhaseReady))
 	})
 })
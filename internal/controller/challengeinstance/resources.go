@@ -0,0 +1,229 @@
+package challengeinstance
+
+import (
+	"context"
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/description"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+const (
+	// ResourceQuotaName is the name of the resource quota in the namespace of every challenge instance.
+	ResourceQuotaName = "ctf-resource-quota"
+
+	// LimitRangeName is the name of the limit range in the namespace of every challenge instance.
+	LimitRangeName = "ctf-limit-range"
+)
+
+// ResourceLimitsReconciler is responsible for enforcing the resource budget of the challenge instance with a resource
+// quota and a limit range in the namespace of the challenge instance.
+type ResourceLimitsReconciler struct {
+	utils.DefaultSubReconciler
+	defaultResourceBudget v1alpha1.ResourceBudget
+}
+
+func NewResourceLimitsReconciler(client client.Client, defaultResourceBudget v1alpha1.ResourceBudget) *ResourceLimitsReconciler {
+	return &ResourceLimitsReconciler{
+		DefaultSubReconciler:  utils.NewDefaultSubReconciler(client),
+		defaultResourceBudget: defaultResourceBudget,
+	}
+}
+
+func (r *ResourceLimitsReconciler) Reconcile(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) (ctrl.Result, error) {
+	if !challengeInstance.DeletionTimestamp.IsZero() {
+		// We do not create resource limits when the resource is already being deleted. They are removed together with
+		// the namespace.
+		return ctrl.Result{}, nil
+	}
+
+	if !meta.IsStatusConditionTrue(challengeInstance.Status.Conditions, v1alpha1.ChallengeInstanceConditionNamespaceReady) {
+		// The resource limits can only be created when the namespace exists.
+		return ctrl.Result{}, nil
+	}
+
+	challengeDescription, err := description.Get(ctx, r.GetClient(), challengeInstance)
+	if err != nil {
+		return ctrl.Result{}, errors.Join(err, r.setResourceLimitsFailed(ctx, challengeInstance, err))
+	}
+
+	resourceBudget := mergeResourceBudget(r.defaultResourceBudget, challengeDescription.Spec.Resources)
+	if err := r.reconcileResourceQuota(ctx, getDesiredResourceQuotaSpec(challengeInstance, resourceBudget)); err != nil {
+		return ctrl.Result{}, errors.Join(err, r.setResourceLimitsFailed(ctx, challengeInstance, err))
+	}
+	if err := r.reconcileLimitRange(ctx, getDesiredLimitRangeSpec(challengeInstance, resourceBudget)); err != nil {
+		return ctrl.Result{}, errors.Join(err, r.setResourceLimitsFailed(ctx, challengeInstance, err))
+	}
+	return ctrl.Result{}, setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionResourcesLimited,
+		metav1.ConditionTrue,
+		v1alpha1.ChallengeInstanceReasonResourceLimitsApplied,
+		"The resource budget is applied",
+	)
+}
+
+func (r *ResourceLimitsReconciler) setResourceLimitsFailed(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, err error) error {
+	return setCondition(
+		ctx,
+		r.GetClient(),
+		challengeInstance,
+		v1alpha1.ChallengeInstanceConditionResourcesLimited,
+		metav1.ConditionFalse,
+		v1alpha1.ChallengeInstanceReasonResourceLimitsFailed,
+		err.Error(),
+	)
+}
+
+// reconcileResourceQuota creates or updates the given resource quota. A resource quota without any hard limits is
+// deleted instead.
+func (r *ResourceLimitsReconciler) reconcileResourceQuota(ctx context.Context, desiredSpec *corev1.ResourceQuota) error {
+	var currentSpec corev1.ResourceQuota
+	if err := r.GetClient().Get(ctx, client.ObjectKeyFromObject(desiredSpec), &currentSpec); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		if len(desiredSpec.Spec.Hard) == 0 {
+			return nil
+		}
+		return r.GetClient().Create(ctx, desiredSpec)
+	}
+
+	if len(desiredSpec.Spec.Hard) == 0 {
+		return client.IgnoreNotFound(r.GetClient().Delete(ctx, &currentSpec))
+	}
+	if equality.Semantic.DeepEqual(currentSpec.Spec, desiredSpec.Spec) {
+		// The resource quotas are identical. Nothing to do.
+		return nil
+	}
+	currentSpec.Spec = desiredSpec.Spec
+	return r.GetClient().Update(ctx, &currentSpec)
+}
+
+// reconcileLimitRange creates or updates the given limit range. A limit range without any limits is deleted instead.
+func (r *ResourceLimitsReconciler) reconcileLimitRange(ctx context.Context, desiredSpec *corev1.LimitRange) error {
+	var currentSpec corev1.LimitRange
+	if err := r.GetClient().Get(ctx, client.ObjectKeyFromObject(desiredSpec), &currentSpec); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		if len(desiredSpec.Spec.Limits) == 0 {
+			return nil
+		}
+		return r.GetClient().Create(ctx, desiredSpec)
+	}
+
+	if len(desiredSpec.Spec.Limits) == 0 {
+		return client.IgnoreNotFound(r.GetClient().Delete(ctx, &currentSpec))
+	}
+	if equality.Semantic.DeepEqual(currentSpec.Spec, desiredSpec.Spec) {
+		// The limit ranges are identical. Nothing to do.
+		return nil
+	}
+	currentSpec.Spec = desiredSpec.Spec
+	return r.GetClient().Update(ctx, &currentSpec)
+}
+
+func getDesiredResourceQuotaSpec(challengeInstance *v1alpha1.ChallengeInstance, resourceBudget v1alpha1.ResourceBudget) *corev1.ResourceQuota {
+	hard := corev1.ResourceList{}
+	if resourceBudget.CPU != nil {
+		hard[corev1.ResourceLimitsCPU] = *resourceBudget.CPU
+	}
+	if resourceBudget.Memory != nil {
+		hard[corev1.ResourceLimitsMemory] = *resourceBudget.Memory
+	}
+	if resourceBudget.Storage != nil {
+		hard[corev1.ResourceRequestsStorage] = *resourceBudget.Storage
+	}
+	if resourceBudget.Pods != nil {
+		hard[corev1.ResourcePods] = *resource.NewQuantity(*resourceBudget.Pods, resource.DecimalSI)
+	}
+	if resourceBudget.LoadBalancers != nil {
+		hard[corev1.ResourceServicesLoadBalancers] = *resource.NewQuantity(*resourceBudget.LoadBalancers, resource.DecimalSI)
+	}
+
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: challengeInstance.Status.Namespace,
+			Name:      ResourceQuotaName,
+			Labels: map[string]string{
+				ManagedByLabelName: ManagedByLabelValue,
+			},
+		},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: hard,
+		},
+	}
+}
+
+func getDesiredLimitRangeSpec(challengeInstance *v1alpha1.ChallengeInstance, resourceBudget v1alpha1.ResourceBudget) *corev1.LimitRange {
+	defaultLimits := corev1.ResourceList{}
+	if resourceBudget.ContainerCPU != nil {
+		defaultLimits[corev1.ResourceCPU] = *resourceBudget.ContainerCPU
+	}
+	if resourceBudget.ContainerMemory != nil {
+		defaultLimits[corev1.ResourceMemory] = *resourceBudget.ContainerMemory
+	}
+
+	var limits []corev1.LimitRangeItem
+	if len(defaultLimits) != 0 {
+		limits = append(limits, corev1.LimitRangeItem{
+			Type:    corev1.LimitTypeContainer,
+			Default: defaultLimits,
+		})
+	}
+	return &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: challengeInstance.Status.Namespace,
+			Name:      LimitRangeName,
+			Labels: map[string]string{
+				ManagedByLabelName: ManagedByLabelValue,
+			},
+		},
+		Spec: corev1.LimitRangeSpec{
+			Limits: limits,
+		},
+	}
+}
+
+// mergeResourceBudget returns the default resource budget with all fields replaced which are provided by the given
+// resource budget of the challenge description.
+func mergeResourceBudget(defaultResourceBudget v1alpha1.ResourceBudget, resourceBudget *v1alpha1.ResourceBudget) v1alpha1.ResourceBudget {
+	result := *defaultResourceBudget.DeepCopy()
+	if resourceBudget == nil {
+		return result
+	}
+	if resourceBudget.CPU != nil {
+		result.CPU = resourceBudget.CPU
+	}
+	if resourceBudget.Memory != nil {
+		result.Memory = resourceBudget.Memory
+	}
+	if resourceBudget.Storage != nil {
+		result.Storage = resourceBudget.Storage
+	}
+	if resourceBudget.Pods != nil {
+		result.Pods = resourceBudget.Pods
+	}
+	if resourceBudget.LoadBalancers != nil {
+		result.LoadBalancers = resourceBudget.LoadBalancers
+	}
+	if resourceBudget.ContainerCPU != nil {
+		result.ContainerCPU = resourceBudget.ContainerCPU
+	}
+	if resourceBudget.ContainerMemory != nil {
+		result.ContainerMemory = resourceBudget.ContainerMemory
+	}
+	return result
+}
@@ -0,0 +1,176 @@
+package challengeinstance_test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstance"
+	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+var _ = Describe("ResourceLimitsReconciler", func() {
+	var reconciler *utils.Reconciler[*v1alpha1.ChallengeInstance]
+
+	BeforeEach(func() {
+		reconciler = challengeinstance.NewReconciler(
+			k8sClient,
+			challengeinstance.WithNamespaceReconciler(
+				challengeinstance.DefaultNamespacePrefix,
+				challengeinstance.DefaultPodSecurityLevel,
+				challengeinstance.DefaultMaxPodSecurityLevel,
+			),
+			challengeinstance.WithResourceLimitsReconciler(v1alpha1.ResourceBudget{
+				CPU:             ptr.To(resource.MustParse("2")),
+				Memory:          ptr.To(resource.MustParse("2Gi")),
+				Pods:            ptr.To(int64(10)),
+				LoadBalancers:   ptr.To(int64(0)),
+				ContainerCPU:    ptr.To(resource.MustParse("500m")),
+				ContainerMemory: ptr.To(resource.MustParse("256Mi")),
+			}),
+		)
+	})
+
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+	})
+
+	It("should apply the default resource budget", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := createInstanceWithResources(ctx, nil)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionResourcesLimited)).To(BeTrue())
+
+		var resourceQuota corev1.ResourceQuota
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.ResourceQuotaName,
+		}, &resourceQuota)).To(Succeed())
+		Expect(resourceQuota.Spec.Hard.Name(corev1.ResourceLimitsCPU, resource.DecimalSI).String()).To(Equal("2"))
+		Expect(resourceQuota.Spec.Hard.Name(corev1.ResourceLimitsMemory, resource.BinarySI).String()).To(Equal("2Gi"))
+		Expect(resourceQuota.Spec.Hard.Pods().Value()).To(Equal(int64(10)))
+		Expect(resourceQuota.Spec.Hard).To(HaveKey(corev1.ResourceServicesLoadBalancers))
+		Expect(resourceQuota.Spec.Hard).ToNot(HaveKey(corev1.ResourceRequestsStorage))
+
+		var limitRange corev1.LimitRange
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.LimitRangeName,
+		}, &limitRange)).To(Succeed())
+		Expect(limitRange.Spec.Limits).To(HaveLen(1))
+		Expect(limitRange.Spec.Limits[0].Default.Cpu().String()).To(Equal("500m"))
+		Expect(limitRange.Spec.Limits[0].Default.Memory().String()).To(Equal("256Mi"))
+	})
+
+	It("should prefer the resource budget of the challenge description", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := createInstanceWithResources(ctx, &v1alpha1.ResourceBudget{
+			CPU:           ptr.To(resource.MustParse("4")),
+			Storage:       ptr.To(resource.MustParse("1Gi")),
+			LoadBalancers: ptr.To(int64(1)),
+		})
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		var resourceQuota corev1.ResourceQuota
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.ResourceQuotaName,
+		}, &resourceQuota)).To(Succeed())
+		Expect(resourceQuota.Spec.Hard.Name(corev1.ResourceLimitsCPU, resource.DecimalSI).String()).To(Equal("4"))
+		Expect(resourceQuota.Spec.Hard.Name(corev1.ResourceLimitsMemory, resource.BinarySI).String()).To(Equal("2Gi"))
+		Expect(resourceQuota.Spec.Hard.Name(corev1.ResourceRequestsStorage, resource.BinarySI).String()).To(Equal("1Gi"))
+		loadBalancers := resourceQuota.Spec.Hard[corev1.ResourceServicesLoadBalancers]
+		Expect(loadBalancers.Value()).To(Equal(int64(1)))
+	})
+
+	It("should not create a resource quota without budget", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		reconciler = challengeinstance.NewReconciler(
+			k8sClient,
+			challengeinstance.WithNamespaceReconciler(
+				challengeinstance.DefaultNamespacePrefix,
+				challengeinstance.DefaultPodSecurityLevel,
+				challengeinstance.DefaultMaxPodSecurityLevel,
+			),
+			challengeinstance.WithResourceLimitsReconciler(v1alpha1.ResourceBudget{}),
+		)
+		instance := createInstanceWithResources(ctx, nil)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, v1alpha1.ChallengeInstanceConditionResourcesLimited)).To(BeTrue())
+		var resourceQuota corev1.ResourceQuota
+		Expect(k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: instance.Status.Namespace,
+			Name:      challengeinstance.ResourceQuotaName,
+		}, &resourceQuota)).To(MatchError(ContainSubstring("not found")))
+	})
+})
+
+func createInstanceWithResources(ctx SpecContext, resources *v1alpha1.ResourceBudget) v1alpha1.ChallengeInstance {
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testutils.GenerateName("test-"),
+		},
+	}
+	configMapRaw, err := ToRaw(&configMap)
+	Expect(err).ToNot(HaveOccurred())
+
+	description := v1alpha1.ChallengeDescription{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeDescriptionSpec{
+			Title:       "test",
+			Description: "test",
+			Flag:        "test",
+			Resources:   resources,
+			Manifests: []runtime.RawExtension{
+				{
+					Raw: configMapRaw,
+				},
+			},
+		},
+	}
+	Expect(k8sClient.Create(ctx, &description)).To(Succeed())
+
+	instance := v1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeInstanceSpec{
+			ChallengeDescriptionName: description.Name,
+		},
+	}
+	Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+	return instance
+}
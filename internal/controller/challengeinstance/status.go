@@ -9,11 +9,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/description"
 	"github.com/backbone81/ctf-challenge-operator/internal/utils"
 )
 
 const (
 	DefaultExpirationSeconds = int64(15 * 60) // 15 minutes
+	DefaultExtensionSeconds  = int64(15 * 60) // 15 minutes
 )
 
 // StatusReconciler is responsible for reconciling the status of the challenge instance.
@@ -33,8 +35,23 @@ func (r *StatusReconciler) Reconcile(ctx context.Context, challengeInstance *v1a
 		return ctrl.Result{}, nil
 	}
 
+	challengeDescription, err := getLifetimeChallengeDescription(ctx, r.GetClient(), challengeInstance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	updateStatus := false
 
+	if IsUnclaimed(challengeInstance) {
+		// The expiration of challenge instances in a pool starts when they are claimed.
+		if summarize(challengeInstance) {
+			if err := r.GetClient().Status().Update(ctx, challengeInstance); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
 	// calculate expiration timestamp
 	if challengeInstance.Status.ExpirationTimestamp.IsZero() {
 		expirationSeconds := DefaultExpirationSeconds
@@ -45,6 +62,22 @@ func (r *StatusReconciler) Reconcile(ctx context.Context, challengeInstance *v1a
 		updateStatus = true
 	}
 
+	// apply requested extensions
+	if applyExtensions(challengeInstance, challengeDescription) {
+		updateStatus = true
+	}
+
+	// enforce the maximum lifetime
+	if expirationTimestamp := getExpirationTimestamp(challengeInstance, challengeDescription); !expirationTimestamp.Equal(challengeInstance.Status.ExpirationTimestamp.Time) {
+		challengeInstance.Status.ExpirationTimestamp = metav1.NewTime(expirationTimestamp)
+		updateStatus = true
+	}
+
+	// record the observed generation together with the aggregated conditions and the phase
+	if summarize(challengeInstance) {
+		updateStatus = true
+	}
+
 	if updateStatus {
 		if err := r.GetClient().Status().Update(ctx, challengeInstance); err != nil {
 			return ctrl.Result{}, err
@@ -52,3 +85,62 @@ func (r *StatusReconciler) Reconcile(ctx context.Context, challengeInstance *v1a
 	}
 	return ctrl.Result{}, nil
 }
+
+// applyExtensions pushes the expiration timestamp forward for every requested extension which was not yet applied.
+// Extensions beyond the maximum number of extensions of the challenge description are ignored. It returns true if the
+// status changed.
+func applyExtensions(challengeInstance *v1alpha1.ChallengeInstance, challengeDescription *v1alpha1.ChallengeDescription) bool {
+	extensions := challengeInstance.Spec.Extensions
+	extensionSeconds := DefaultExtensionSeconds
+	if challengeDescription != nil {
+		if challengeDescription.Spec.MaxExtensions != nil {
+			extensions = min(extensions, *challengeDescription.Spec.MaxExtensions)
+		}
+		if challengeDescription.Spec.ExtensionSeconds != nil {
+			extensionSeconds = *challengeDescription.Spec.ExtensionSeconds
+		}
+	}
+
+	changed := false
+	for challengeInstance.Status.ExtensionsUsed < extensions {
+		challengeInstance.Status.ExpirationTimestamp = metav1.NewTime(
+			challengeInstance.Status.ExpirationTimestamp.Add(time.Duration(extensionSeconds) * time.Second),
+		)
+		challengeInstance.Status.ExtensionsUsed++
+		changed = true
+	}
+	return changed
+}
+
+// getExpirationTimestamp returns the point in time the challenge instance expires. This is the expiration timestamp
+// of the status, limited by the maximum lifetime of the challenge description. The lifetime of challenge instances
+// which were claimed from a pool starts with the claim.
+func getExpirationTimestamp(challengeInstance *v1alpha1.ChallengeInstance, challengeDescription *v1alpha1.ChallengeDescription) time.Time {
+	expirationTimestamp := challengeInstance.Status.ExpirationTimestamp.Time
+	if challengeDescription == nil || challengeDescription.Spec.MaxLifetimeSeconds == nil {
+		return expirationTimestamp
+	}
+
+	lifetimeStart := challengeInstance.CreationTimestamp
+	if !challengeInstance.Status.ClaimTimestamp.IsZero() {
+		lifetimeStart = challengeInstance.Status.ClaimTimestamp
+	}
+	maxExpirationTimestamp := lifetimeStart.Add(time.Duration(*challengeDescription.Spec.MaxLifetimeSeconds) * time.Second)
+	if expirationTimestamp.After(maxExpirationTimestamp) {
+		return maxExpirationTimestamp
+	}
+	return expirationTimestamp
+}
+
+// getLifetimeChallengeDescription returns the challenge description which limits the lifetime of the given challenge
+// instance. It returns nil if the challenge description does not exist, which leaves the lifetime unbounded.
+func getLifetimeChallengeDescription(ctx context.Context, k8sClient client.Client, challengeInstance *v1alpha1.ChallengeInstance) (*v1alpha1.ChallengeDescription, error) {
+	if len(description.Ref(challengeInstance).Name) == 0 {
+		return nil, nil
+	}
+	challengeDescription, err := description.Get(ctx, k8sClient, challengeInstance)
+	if err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return challengeDescription, nil
+}
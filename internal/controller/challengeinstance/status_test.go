@@ -5,6 +5,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -51,6 +52,8 @@ var _ = Describe("StatusReconciler", func() {
 			time.Now().Add(time.Duration(challengeinstance.DefaultExpirationSeconds)*time.Second),
 			testutils.DurationEpsilon,
 		))
+		Expect(instance.Status.ObservedGeneration).To(Equal(instance.Generation))
+		Expect(instance.Status.Phase).To(Equal(v1alpha1.ChallengeInstancePhasePending))
 	})
 
 	It("should set the custom expiration time when set", func(ctx SpecContext) {
@@ -136,4 +139,165 @@ var _ = Describe("StatusReconciler", func() {
 		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
 		Expect(instance.Status.ExpirationTimestamp).To(BeZero())
 	})
+
+	It("should push the expiration forward for a requested extension", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescriptionWithLifetime(ctx, ptr.To(int64(600)), nil, nil)
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionName: description.Name,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		customExpirationTimestamp := metav1.NewTime(time.Now().Add(3 * time.Minute))
+		instance.Status.ExpirationTimestamp = customExpirationTimestamp
+		Expect(k8sClient.Status().Update(ctx, &instance)).To(Succeed())
+
+		instance.Spec.Extensions = 1
+		Expect(k8sClient.Update(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.ExtensionsUsed).To(Equal(1))
+		Expect(instance.Status.ExpirationTimestamp.Time).To(BeTemporally(
+			"~",
+			customExpirationTimestamp.Add(10*time.Minute),
+			testutils.DurationEpsilon,
+		))
+	})
+
+	It("should reject decreasing the extensions", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				Extensions: 2,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("decrease the extensions")
+		instance.Spec.Extensions = 1
+		Expect(k8sClient.Update(ctx, &instance)).To(MatchError(ContainSubstring("extensions can not be decreased")))
+
+		By("reset the extensions")
+		instance.Spec.Extensions = 0
+		Expect(k8sClient.Update(ctx, &instance)).To(MatchError(ContainSubstring("extensions can not be decreased")))
+	})
+
+	It("should ignore extensions beyond the maximum number of extensions", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescriptionWithLifetime(ctx, ptr.To(int64(600)), ptr.To(1), nil)
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionName: description.Name,
+				ExpirationSeconds:        ptr.To(int64(180)),
+				Extensions:               3,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.ExtensionsUsed).To(Equal(1))
+		Expect(instance.Status.ExpirationTimestamp.Time).To(BeTemporally(
+			"~",
+			time.Now().Add(13*time.Minute),
+			testutils.DurationEpsilon,
+		))
+	})
+
+	It("should limit the expiration to the maximum lifetime", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescriptionWithLifetime(ctx, ptr.To(int64(600)), nil, ptr.To(int64(300)))
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionName: description.Name,
+				ExpirationSeconds:        ptr.To(int64(180)),
+				Extensions:               1,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.ExtensionsUsed).To(Equal(1))
+		Expect(instance.Status.ExpirationTimestamp.Time).To(BeTemporally(
+			"~",
+			instance.CreationTimestamp.Add(5*time.Minute),
+			testutils.DurationEpsilon,
+		))
+	})
+
+	It("should not set the expiration time for unclaimed instances of a pool", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := newPooledInstance()
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.ExpirationTimestamp).To(BeZero())
+		Expect(instance.Status.Phase).To(Equal(v1alpha1.ChallengeInstancePhasePending))
+	})
 })
+
+// createDescriptionWithLifetime creates a challenge description with the given lifetime settings.
+func createDescriptionWithLifetime(ctx SpecContext, extensionSeconds *int64, maxExtensions *int, maxLifetimeSeconds *int64) v1alpha1.ChallengeDescription {
+	description := v1alpha1.ChallengeDescription{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeDescriptionSpec{
+			Title:              "test",
+			Description:        "test",
+			Flag:               "CTF{test}",
+			ExtensionSeconds:   extensionSeconds,
+			MaxExtensions:      maxExtensions,
+			MaxLifetimeSeconds: maxLifetimeSeconds,
+			Manifests: []runtime.RawExtension{
+				{
+					Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`),
+				},
+			},
+		},
+	}
+	Expect(k8sClient.Create(ctx, &description)).To(Succeed())
+	return description
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
@@ -15,6 +17,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstance"
 	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
 )
 
@@ -45,6 +48,24 @@ func DeleteAllInstances(ctx context.Context) {
 	}
 }
 
+// CreateInstanceNamespace creates the namespace for the given challenge instance the same way the namespace reconciler
+// does and records it in the status of the instance.
+func CreateInstanceNamespace(ctx context.Context, instance *v1alpha1.ChallengeInstance) corev1.Namespace {
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Labels: map[string]string{
+				challengeinstance.InstanceUIDLabelName: string(instance.UID),
+			},
+		},
+	}
+	Expect(k8sClient.Create(ctx, &namespace)).To(Succeed())
+
+	instance.Status.Namespace = namespace.Name
+	Expect(k8sClient.Status().Update(ctx, instance)).To(Succeed())
+	return namespace
+}
+
 // ToRaw converts a Kubernetes object into its JSON representation.
 func ToRaw(obj client.Object) ([]byte, error) {
 	codecFactory := serializer.NewCodecFactory(clientgoscheme.Scheme)
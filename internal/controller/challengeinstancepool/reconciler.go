@@ -0,0 +1,35 @@
+package challengeinstancepool
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+// +kubebuilder:rbac:groups=core.ctf.backbone81,resources=challengeinstancepools,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.ctf.backbone81,resources=challengeinstancepools/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core.ctf.backbone81,resources=challengeinstancepools/finalizers,verbs=update
+
+func NewReconciler(client client.Client, options ...utils.ReconcilerOption[*v1alpha1.ChallengeInstancePool]) *utils.Reconciler[*v1alpha1.ChallengeInstancePool] {
+	return utils.NewReconciler[*v1alpha1.ChallengeInstancePool](
+		client,
+		func() *v1alpha1.ChallengeInstancePool {
+			return &v1alpha1.ChallengeInstancePool{}
+		},
+		options...,
+	)
+}
+
+// WithDefaultReconcilers returns a reconciler option which enables the default sub-reconcilers.
+func WithDefaultReconcilers() utils.ReconcilerOption[*v1alpha1.ChallengeInstancePool] {
+	return func(reconciler *utils.Reconciler[*v1alpha1.ChallengeInstancePool]) {
+		WithRefillReconciler()(reconciler)
+	}
+}
+
+func WithRefillReconciler() utils.ReconcilerOption[*v1alpha1.ChallengeInstancePool] {
+	return func(reconciler *utils.Reconciler[*v1alpha1.ChallengeInstancePool]) {
+		reconciler.AppendSubReconciler(NewRefillReconciler(reconciler.GetClient()))
+	}
+}
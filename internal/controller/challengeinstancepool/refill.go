@@ -0,0 +1,147 @@
+package challengeinstancepool
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstance"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+// RefillReconciler is responsible for keeping the configured number of unclaimed challenge instances in the pool.
+// Missing challenge instances are created in batches no larger than the max surge of the pool, surplus challenge
+// instances are deleted.
+type RefillReconciler struct {
+	utils.DefaultSubReconciler
+}
+
+func NewRefillReconciler(client client.Client) *RefillReconciler {
+	return &RefillReconciler{
+		DefaultSubReconciler: utils.NewDefaultSubReconciler(client),
+	}
+}
+
+// SetupWithManager watches the challenge instances of the pool, so that the pool is refilled as soon as a challenge
+// instance is claimed and the next batch is started as soon as challenge instances become ready.
+func (r *RefillReconciler) SetupWithManager(ctrlBuilder *builder.Builder) *builder.Builder {
+	return ctrlBuilder.Owns(&v1alpha1.ChallengeInstance{})
+}
+
+func (r *RefillReconciler) Reconcile(ctx context.Context, pool *v1alpha1.ChallengeInstancePool) (ctrl.Result, error) {
+	if !pool.DeletionTimestamp.IsZero() {
+		// We do not refill the pool when the resource is already being deleted. The unclaimed challenge instances are
+		// removed through their owner reference.
+		return ctrl.Result{}, nil
+	}
+
+	ready, provisioning, err := r.getUnclaimedInstances(ctx, pool)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Surplus challenge instances which are not ready are removed first, because they are the furthest away from
+	// being claimed.
+	surplus := len(ready) + len(provisioning) - int(pool.Spec.Size)
+	for surplus > 0 {
+		var challengeInstance *v1alpha1.ChallengeInstance
+		if len(provisioning) != 0 {
+			challengeInstance, provisioning = provisioning[len(provisioning)-1], provisioning[:len(provisioning)-1]
+		} else {
+			challengeInstance, ready = ready[len(ready)-1], ready[:len(ready)-1]
+		}
+		if err := r.GetClient().Delete(ctx, challengeInstance); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+		surplus--
+	}
+
+	missing := int(pool.Spec.Size) - len(ready) - len(provisioning)
+	batchSize := min(missing, max(int(pool.Spec.MaxSurge), 1)-len(provisioning))
+	for range batchSize {
+		challengeInstance, err := r.createInstance(ctx, pool)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		provisioning = append(provisioning, challengeInstance)
+	}
+
+	return ctrl.Result{}, r.updateStatus(ctx, pool, len(ready), len(provisioning))
+}
+
+// getUnclaimedInstances returns the unclaimed challenge instances of the given pool, split into those which are ready
+// to be claimed and those which are still being provisioned. Challenge instances which are being deleted are ignored.
+func (r *RefillReconciler) getUnclaimedInstances(ctx context.Context, pool *v1alpha1.ChallengeInstancePool) ([]*v1alpha1.ChallengeInstance, []*v1alpha1.ChallengeInstance, error) {
+	var challengeInstanceList v1alpha1.ChallengeInstanceList
+	if err := r.GetClient().List(
+		ctx,
+		&challengeInstanceList,
+		client.InNamespace(pool.Namespace),
+		client.MatchingLabels{
+			v1alpha1.ChallengeInstancePoolLabelName: pool.Name,
+		},
+	); err != nil {
+		return nil, nil, err
+	}
+
+	var ready []*v1alpha1.ChallengeInstance
+	var provisioning []*v1alpha1.ChallengeInstance
+	for i := range challengeInstanceList.Items {
+		challengeInstance := &challengeInstanceList.Items[i]
+		if !challengeInstance.DeletionTimestamp.IsZero() ||
+			!metav1.IsControlledBy(challengeInstance, pool) ||
+			!challengeinstance.IsUnclaimed(challengeInstance) {
+			continue
+		}
+		if meta.IsStatusConditionTrue(challengeInstance.Status.Conditions, v1alpha1.ChallengeInstanceConditionReady) {
+			ready = append(ready, challengeInstance)
+		} else {
+			provisioning = append(provisioning, challengeInstance)
+		}
+	}
+	return ready, provisioning, nil
+}
+
+// createInstance creates a new unclaimed challenge instance for the given pool.
+func (r *RefillReconciler) createInstance(ctx context.Context, pool *v1alpha1.ChallengeInstancePool) (*v1alpha1.ChallengeInstance, error) {
+	challengeInstance := &v1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: pool.Name + "-",
+			Namespace:    pool.Namespace,
+			Labels: map[string]string{
+				v1alpha1.ChallengeInstancePoolLabelName: pool.Name,
+			},
+		},
+		Spec: v1alpha1.ChallengeInstanceSpec{
+			ChallengeDescriptionName: pool.Spec.ChallengeDescriptionName,
+			ChallengeDescriptionRef:  pool.Spec.ChallengeDescriptionRef.DeepCopy(),
+			ExpirationSeconds:        pool.Spec.ExpirationSeconds,
+		},
+	}
+	if err := controllerutil.SetControllerReference(pool, challengeInstance, r.GetClient().Scheme()); err != nil {
+		return nil, err
+	}
+	if err := r.GetClient().Create(ctx, challengeInstance); err != nil {
+		return nil, err
+	}
+	return challengeInstance, nil
+}
+
+func (r *RefillReconciler) updateStatus(ctx context.Context, pool *v1alpha1.ChallengeInstancePool, ready int, provisioning int) error {
+	status := v1alpha1.ChallengeInstancePoolStatus{
+		Ready:              int32(ready),
+		Provisioning:       int32(provisioning),
+		ObservedGeneration: pool.Generation,
+	}
+	if pool.Status == status {
+		return nil
+	}
+	pool.Status = status
+	return r.GetClient().Status().Update(ctx, pool)
+}
@@ -0,0 +1,164 @@
+package challengeinstancepool_test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstancepool"
+	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+var _ = Describe("RefillReconciler", func() {
+	var reconciler *utils.Reconciler[*v1alpha1.ChallengeInstancePool]
+
+	BeforeEach(func() {
+		reconciler = challengeinstancepool.NewReconciler(k8sClient, challengeinstancepool.WithRefillReconciler())
+	})
+
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+	})
+
+	It("should create instances up to the max surge", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		pool := createPool(ctx, 3, 2)
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&pool))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		instances := listInstances(ctx, pool)
+		Expect(instances).To(HaveLen(2))
+		for _, instance := range instances {
+			Expect(instance.Spec.ChallengeDescriptionName).To(Equal(pool.Spec.ChallengeDescriptionName))
+			Expect(metav1.IsControlledBy(&instance, &pool)).To(BeTrue())
+		}
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&pool), &pool)).To(Succeed())
+		Expect(pool.Status.Ready).To(BeEquivalentTo(0))
+		Expect(pool.Status.Provisioning).To(BeEquivalentTo(2))
+	})
+
+	It("should create the next batch when instances become ready", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		pool := createPool(ctx, 3, 2)
+		_, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&pool))
+		Expect(err).ToNot(HaveOccurred())
+		for _, instance := range listInstances(ctx, pool) {
+			markReady(ctx, &instance)
+		}
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&pool))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(listInstances(ctx, pool)).To(HaveLen(3))
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&pool), &pool)).To(Succeed())
+		Expect(pool.Status.Ready).To(BeEquivalentTo(2))
+		Expect(pool.Status.Provisioning).To(BeEquivalentTo(1))
+	})
+
+	It("should replace claimed instances", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		pool := createPool(ctx, 1, 1)
+		_, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&pool))
+		Expect(err).ToNot(HaveOccurred())
+		instances := listInstances(ctx, pool)
+		Expect(instances).To(HaveLen(1))
+		claimedInstance := instances[0]
+		claimedInstance.Spec.Owner = "team-a"
+		Expect(k8sClient.Update(ctx, &claimedInstance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&pool))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		instances = listInstances(ctx, pool)
+		Expect(instances).To(HaveLen(2))
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&pool), &pool)).To(Succeed())
+		Expect(pool.Status.Provisioning).To(BeEquivalentTo(1))
+	})
+
+	It("should delete surplus instances", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		pool := createPool(ctx, 2, 2)
+		_, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&pool))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(listInstances(ctx, pool)).To(HaveLen(2))
+
+		pool.Spec.Size = 0
+		Expect(k8sClient.Update(ctx, &pool)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&pool))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&pool), &pool)).To(Succeed())
+		Expect(pool.Status.Ready).To(BeEquivalentTo(0))
+		Expect(pool.Status.Provisioning).To(BeEquivalentTo(0))
+	})
+})
+
+func createPool(ctx SpecContext, size int32, maxSurge int32) v1alpha1.ChallengeInstancePool {
+	pool := v1alpha1.ChallengeInstancePool{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeInstancePoolSpec{
+			ChallengeDescriptionName: "test",
+			Size:                     size,
+			MaxSurge:                 maxSurge,
+		},
+	}
+	Expect(k8sClient.Create(ctx, &pool)).To(Succeed())
+	return pool
+}
+
+// listInstances returns all challenge instances which carry the label of the given pool. Instances which are being
+// deleted are skipped.
+func listInstances(ctx SpecContext, pool v1alpha1.ChallengeInstancePool) []v1alpha1.ChallengeInstance {
+	var challengeInstanceList v1alpha1.ChallengeInstanceList
+	Expect(k8sClient.List(
+		ctx,
+		&challengeInstanceList,
+		client.InNamespace(pool.Namespace),
+		client.MatchingLabels{
+			v1alpha1.ChallengeInstancePoolLabelName: pool.Name,
+		},
+	)).To(Succeed())
+
+	var result []v1alpha1.ChallengeInstance
+	for _, challengeInstance := range challengeInstanceList.Items {
+		if !challengeInstance.DeletionTimestamp.IsZero() {
+			continue
+		}
+		result = append(result, challengeInstance)
+	}
+	return result
+}
+
+func markReady(ctx SpecContext, instance *v1alpha1.ChallengeInstance) {
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:   v1alpha1.ChallengeInstanceConditionReady,
+		Status: metav1.ConditionTrue,
+		Reason: "Test",
+	})
+	Expect(k8sClient.Status().Update(ctx, instance)).To(Succeed())
+}
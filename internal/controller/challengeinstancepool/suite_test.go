@@ -0,0 +1,49 @@
+package challengeinstancepool_test
+
+import (
+	"context"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
+)
+
+var (
+	testEnv   *envtest.Environment
+	k8sClient client.Client
+)
+
+func TestReconciler(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ChallengeInstancePool Suite")
+}
+
+var _ = BeforeSuite(func() {
+	testEnv, k8sClient = testutils.SetupTestEnv()
+})
+
+var _ = AfterSuite(func() {
+	Expect(testEnv.Stop()).To(Succeed())
+})
+
+func DeleteAllInstances(ctx context.Context) {
+	var challengeInstanceList v1alpha1.ChallengeInstanceList
+	Expect(k8sClient.List(ctx, &challengeInstanceList)).To(Succeed())
+
+	for _, challengeInstance := range challengeInstanceList.Items {
+		Expect(k8sClient.Delete(ctx, &challengeInstance)).To(Succeed())
+	}
+
+	var poolList v1alpha1.ChallengeInstancePoolList
+	Expect(k8sClient.List(ctx, &poolList)).To(Succeed())
+
+	for _, pool := range poolList.Items {
+		Expect(k8sClient.Delete(ctx, &pool)).To(Succeed())
+	}
+}
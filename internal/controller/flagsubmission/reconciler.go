@@ -0,0 +1,40 @@
+package flagsubmission
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+// +kubebuilder:rbac:groups=core.ctf.backbone81,resources=flagsubmissions,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.ctf.backbone81,resources=flagsubmissions/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core.ctf.backbone81,resources=flagsubmissions/finalizers,verbs=update
+
+// +kubebuilder:rbac:groups=core.ctf.backbone81,resources=challengeinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core.ctf.backbone81,resources=challengedescriptions,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core.ctf.backbone81,resources=clusterchallengedescriptions,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+func NewReconciler(client client.Client, options ...utils.ReconcilerOption[*v1alpha1.FlagSubmission]) *utils.Reconciler[*v1alpha1.FlagSubmission] {
+	return utils.NewReconciler[*v1alpha1.FlagSubmission](
+		client,
+		func() *v1alpha1.FlagSubmission {
+			return &v1alpha1.FlagSubmission{}
+		},
+		options...,
+	)
+}
+
+// WithDefaultReconcilers returns a reconciler option which enables the default sub-reconcilers.
+func WithDefaultReconcilers() utils.ReconcilerOption[*v1alpha1.FlagSubmission] {
+	return func(reconciler *utils.Reconciler[*v1alpha1.FlagSubmission]) {
+		WithVerifyReconciler()(reconciler)
+	}
+}
+
+func WithVerifyReconciler() utils.ReconcilerOption[*v1alpha1.FlagSubmission] {
+	return func(reconciler *utils.Reconciler[*v1alpha1.FlagSubmission]) {
+		reconciler.AppendSubReconciler(NewVerifyReconciler(reconciler.GetClient()))
+	}
+}
@@ -0,0 +1,47 @@
+package flagsubmission_test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/flagsubmission"
+	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+var _ = Describe("Reconciler", func() {
+	var reconciler *utils.Reconciler[*v1alpha1.FlagSubmission]
+
+	BeforeEach(func() {
+		reconciler = flagsubmission.NewReconciler(k8sClient, flagsubmission.WithDefaultReconcilers())
+	})
+
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+	})
+
+	It("should successfully reconcile the resource", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescription(ctx, "CTF{test}", nil)
+		instance := v1alpha1.FlagSubmission{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.FlagSubmissionSpec{
+				ChallengeDescriptionName: description.Name,
+				Flag:                     "CTF{test}",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+	})
+})
@@ -0,0 +1,272 @@
+package flagsubmission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/description"
+	"github.com/backbone81/ctf-challenge-operator/internal/flag"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+// +kubebuilder:rbac:groups=core.ctf.backbone81,resources=challengeinstances/status,verbs=get;update;patch
+
+// VerifyReconciler is responsible for verifying the flag of a flag submission.
+type VerifyReconciler struct {
+	utils.DefaultSubReconciler
+}
+
+func NewVerifyReconciler(client client.Client) *VerifyReconciler {
+	return &VerifyReconciler{
+		DefaultSubReconciler: utils.NewDefaultSubReconciler(client),
+	}
+}
+
+func (r *VerifyReconciler) Reconcile(ctx context.Context, flagSubmission *v1alpha1.FlagSubmission) (ctrl.Result, error) {
+	if !flagSubmission.DeletionTimestamp.IsZero() {
+		// We do not verify the flag when the resource is already being deleted.
+		return ctrl.Result{}, nil
+	}
+
+	if len(flagSubmission.Status.Result) != 0 {
+		// The flag was already verified. The result never changes.
+		return ctrl.Result{}, nil
+	}
+
+	result, err := r.verify(ctx, flagSubmission)
+	if errors.Is(err, flag.ErrInvalidRegex) {
+		// The regular expression was not validated on admission, for example because the webhooks are disabled.
+		// Trying again does not fix it, so we reject the flag submission instead.
+		err = &rejectionError{message: err.Error()}
+	}
+	if err != nil {
+		var rejection *rejectionError
+		if !errors.As(err, &rejection) {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.setResult(ctx, flagSubmission, verification{
+			message: rejection.Error(),
+		})
+	}
+	return ctrl.Result{}, r.setResult(ctx, flagSubmission, result)
+}
+
+// verification is the outcome of verifying a flag submission.
+type verification struct {
+	correct bool
+	points  int
+	stage   string
+	message string
+}
+
+func (r *VerifyReconciler) setResult(ctx context.Context, flagSubmission *v1alpha1.FlagSubmission, result verification) error {
+	flagSubmission.Status.Result = v1alpha1.FlagSubmissionResultIncorrect
+	if result.correct {
+		flagSubmission.Status.Result = v1alpha1.FlagSubmissionResultCorrect
+	}
+	flagSubmission.Status.EvaluationTimestamp = metav1.NewTime(time.Now())
+	flagSubmission.Status.PointsAwarded = result.points
+	flagSubmission.Status.Stage = result.stage
+	flagSubmission.Status.Message = result.message
+	return r.GetClient().Status().Update(ctx, flagSubmission)
+}
+
+// rejectionError is returned when the flag submission can not be verified for a reason which will not change by
+// trying again.
+type rejectionError struct {
+	message string
+}
+
+func (e *rejectionError) Error() string {
+	return e.message
+}
+
+// verify checks the flag of the given flag submission.
+func (r *VerifyReconciler) verify(ctx context.Context, flagSubmission *v1alpha1.FlagSubmission) (verification, error) {
+	if len(flagSubmission.Spec.ChallengeInstanceName) != 0 {
+		return r.verifyForInstance(ctx, flagSubmission)
+	}
+	return r.verifyForDescription(ctx, flagSubmission)
+}
+
+func (r *VerifyReconciler) verifyForInstance(ctx context.Context, flagSubmission *v1alpha1.FlagSubmission) (verification, error) {
+	var challengeInstance v1alpha1.ChallengeInstance
+	if err := r.GetClient().Get(ctx, client.ObjectKey{
+		Namespace: flagSubmission.Namespace,
+		Name:      flagSubmission.Spec.ChallengeInstanceName,
+	}, &challengeInstance); err != nil {
+		if apierrors.IsNotFound(err) {
+			return verification{}, &rejectionError{message: fmt.Sprintf("ChallengeInstance %s does not exist", flagSubmission.Spec.ChallengeInstanceName)}
+		}
+		return verification{}, err
+	}
+
+	challengeDescription, err := description.Get(ctx, r.GetClient(), &challengeInstance)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return verification{}, &rejectionError{message: fmt.Sprintf("%s does not exist", description.String(&challengeInstance))}
+		}
+		return verification{}, err
+	}
+
+	correct, points, err := r.matchInstanceFlag(ctx, &challengeInstance, challengeDescription, flagSubmission.Spec.Flag)
+	if err != nil {
+		return verification{}, err
+	}
+	if correct {
+		return verification{
+			correct: true,
+			points:  points,
+			message: "The flag is correct",
+		}, nil
+	}
+
+	stage, points, err := flag.MatchStage(ctx, r.GetClient(), challengeDescription, flagSubmission.Spec.Flag)
+	if err != nil {
+		return verification{}, err
+	}
+	if stage == nil {
+		return verification{
+			message: "The flag is incorrect",
+		}, nil
+	}
+	return r.recordStageProgress(ctx, &challengeInstance, flagSubmission, stage.Name, points)
+}
+
+// matchInstanceFlag reports if the candidate flag matches the flag of the given challenge instance. The points
+// awarded for the flag are returned as well.
+func (r *VerifyReconciler) matchInstanceFlag(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, challengeDescription *v1alpha1.ChallengeDescription, candidate string) (bool, int, error) {
+	if !flag.IsGenerated(challengeDescription.Spec.FlagGeneration) {
+		// All instances share the flags of the challenge description.
+		return flag.Match(ctx, r.GetClient(), challengeDescription, candidate)
+	}
+
+	if challengeInstance.Status.FlagSecretRef == nil {
+		return false, 0, fmt.Errorf("the flag of ChallengeInstance %s is not yet available", challengeInstance.Name)
+	}
+	expectedFlag, err := flag.GetFromSecret(ctx, r.GetClient(), challengeInstance.Status.FlagSecretRef)
+	if err != nil {
+		return false, 0, err
+	}
+	if !flag.Verify(expectedFlag, candidate) {
+		return false, 0, nil
+	}
+	return true, challengeDescription.Spec.Value, nil
+}
+
+// recordStageProgress records the completion of the given stage in the status of the challenge instance. Points are
+// only awarded to the first flag submission completing the stage.
+func (r *VerifyReconciler) recordStageProgress(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance, flagSubmission *v1alpha1.FlagSubmission, stageName string, points int) (verification, error) {
+	for _, stageStatus := range challengeInstance.Status.Stages {
+		if stageStatus.Name != stageName {
+			continue
+		}
+		if stageStatus.FlagSubmissionName == flagSubmission.Name {
+			// We recorded the progress already, but failed to update the flag submission afterwards.
+			return verification{
+				correct: true,
+				points:  stageStatus.PointsAwarded,
+				stage:   stageName,
+				message: fmt.Sprintf("Stage %s is completed", stageName),
+			}, nil
+		}
+		return verification{
+			correct: true,
+			stage:   stageName,
+			message: fmt.Sprintf("Stage %s was already completed", stageName),
+		}, nil
+	}
+
+	challengeInstance.Status.Stages = append(challengeInstance.Status.Stages, v1alpha1.ChallengeInstanceStageStatus{
+		Name:                stageName,
+		CompletionTimestamp: metav1.NewTime(time.Now()),
+		PointsAwarded:       points,
+		FlagSubmissionName:  flagSubmission.Name,
+	})
+	if err := r.GetClient().Status().Update(ctx, challengeInstance); err != nil {
+		return verification{}, err
+	}
+	return verification{
+		correct: true,
+		points:  points,
+		stage:   stageName,
+		message: fmt.Sprintf("Stage %s is completed", stageName),
+	}, nil
+}
+
+func (r *VerifyReconciler) verifyForDescription(ctx context.Context, flagSubmission *v1alpha1.FlagSubmission) (verification, error) {
+	challengeDescription, err := r.getChallengeDescription(ctx, flagSubmission)
+	if err != nil {
+		return verification{}, err
+	}
+
+	if flag.IsGenerated(challengeDescription.Spec.FlagGeneration) {
+		return verification{}, &rejectionError{message: fmt.Sprintf("%s generates flags per instance, the flag must be submitted for the ChallengeInstance", description.RefString(flagSubmission.Namespace, descriptionRef(flagSubmission)))}
+	}
+
+	correct, points, err := flag.Match(ctx, r.GetClient(), challengeDescription, flagSubmission.Spec.Flag)
+	if err != nil {
+		return verification{}, err
+	}
+	if correct {
+		return verification{
+			correct: true,
+			points:  points,
+			message: "The flag is correct",
+		}, nil
+	}
+
+	stage, points, err := flag.MatchStage(ctx, r.GetClient(), challengeDescription, flagSubmission.Spec.Flag)
+	if err != nil {
+		return verification{}, err
+	}
+	if stage == nil {
+		return verification{
+			message: "The flag is incorrect",
+		}, nil
+	}
+	return verification{
+		correct: true,
+		points:  points,
+		stage:   stage.Name,
+		message: fmt.Sprintf("Stage %s is completed", stage.Name),
+	}, nil
+}
+
+// getChallengeDescription returns the ChallengeDescription or ClusterChallengeDescription the flag submission
+// references.
+func (r *VerifyReconciler) getChallengeDescription(ctx context.Context, flagSubmission *v1alpha1.FlagSubmission) (*v1alpha1.ChallengeDescription, error) {
+	ref := descriptionRef(flagSubmission)
+	challengeDescription, err := description.GetByRef(ctx, r.GetClient(), flagSubmission.Namespace, ref)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, &rejectionError{message: fmt.Sprintf("%s does not exist", description.RefString(flagSubmission.Namespace, ref))}
+		}
+		return nil, err
+	}
+	return challengeDescription, nil
+}
+
+// descriptionRef returns the reference to the challenge description of the given flag submission. Flag submissions
+// which only provide the name of the challenge description reference a ChallengeDescription in their own namespace.
+func descriptionRef(flagSubmission *v1alpha1.FlagSubmission) v1alpha1.ChallengeDescriptionReference {
+	if flagSubmission.Spec.ChallengeDescriptionRef == nil {
+		return v1alpha1.ChallengeDescriptionReference{
+			Kind: v1alpha1.ChallengeDescriptionKind,
+			Name: flagSubmission.Spec.ChallengeDescriptionName,
+		}
+	}
+	result := *flagSubmission.Spec.ChallengeDescriptionRef
+	if len(result.Kind) == 0 {
+		result.Kind = v1alpha1.ChallengeDescriptionKind
+	}
+	return result
+}
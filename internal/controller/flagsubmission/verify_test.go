@@ -0,0 +1,587 @@
+package flagsubmission_test
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/flagsubmission"
+	"github.com/backbone81/ctf-challenge-operator/internal/flag"
+	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+var _ = Describe("VerifyReconciler", func() {
+	var reconciler *utils.Reconciler[*v1alpha1.FlagSubmission]
+
+	BeforeEach(func() {
+		reconciler = flagsubmission.NewReconciler(k8sClient, flagsubmission.WithVerifyReconciler())
+	})
+
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+	})
+
+	It("should accept the correct static flag of a challenge description", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescription(ctx, "CTF{test}", nil)
+		instance := v1alpha1.FlagSubmission{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.FlagSubmissionSpec{
+				ChallengeDescriptionName: description.Name,
+				Flag:                     "CTF{test}",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Result).To(Equal(v1alpha1.FlagSubmissionResultCorrect))
+		Expect(instance.Status.PointsAwarded).To(Equal(100))
+		Expect(instance.Status.EvaluationTimestamp.Time).To(BeTemporally("~", time.Now(), testutils.DurationEpsilon))
+	})
+
+	It("should accept the correct static flag of a cluster challenge description", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		clusterDescription := v1alpha1.ClusterChallengeDescription{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+			},
+			Spec: v1alpha1.ClusterChallengeDescriptionSpec{
+				ChallengeDescriptionSpec: v1alpha1.ChallengeDescriptionSpec{
+					Title:       "test",
+					Description: "test",
+					Value:       100,
+					FlagHash: &v1alpha1.FlagHash{
+						Algorithm: v1alpha1.FlagHashAlgorithmSHA256,
+						Salt:      "01234567",
+						Hash:      flag.Hash("01234567", "CTF{test}"),
+					},
+					Manifests: []runtime.RawExtension{
+						{
+							Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`),
+						},
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &clusterDescription)).To(Succeed())
+
+		instance := v1alpha1.FlagSubmission{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.FlagSubmissionSpec{
+				ChallengeDescriptionRef: &v1alpha1.ChallengeDescriptionReference{
+					Kind: v1alpha1.ClusterChallengeDescriptionKind,
+					Name: clusterDescription.Name,
+				},
+				Flag: "CTF{test}",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Result).To(Equal(v1alpha1.FlagSubmissionResultCorrect))
+		Expect(instance.Status.PointsAwarded).To(Equal(100))
+	})
+
+	It("should reject a flag for a missing cluster challenge description", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.FlagSubmission{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.FlagSubmissionSpec{
+				ChallengeDescriptionRef: &v1alpha1.ChallengeDescriptionReference{
+					Kind: v1alpha1.ClusterChallengeDescriptionKind,
+					Name: testutils.GenerateName("test-"),
+				},
+				Flag: "CTF{test}",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Result).To(Equal(v1alpha1.FlagSubmissionResultIncorrect))
+		Expect(instance.Status.Message).To(ContainSubstring(v1alpha1.ClusterChallengeDescriptionKind))
+	})
+
+	It("should reject an incorrect flag", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescription(ctx, "CTF{test}", nil)
+		instance := v1alpha1.FlagSubmission{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.FlagSubmissionSpec{
+				ChallengeDescriptionName: description.Name,
+				Flag:                     "CTF{wrong}",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Result).To(Equal(v1alpha1.FlagSubmissionResultIncorrect))
+		Expect(instance.Status.PointsAwarded).To(BeZero())
+	})
+
+	It("should verify the flag against a flag hash", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := v1alpha1.ChallengeDescription{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeDescriptionSpec{
+				Title:       "test",
+				Description: "test",
+				Value:       100,
+				FlagHash: &v1alpha1.FlagHash{
+					Algorithm: v1alpha1.FlagHashAlgorithmSHA256,
+					Salt:      "01234567",
+					Hash:      flag.Hash("01234567", "CTF{test}"),
+				},
+				Manifests: []runtime.RawExtension{
+					{
+						Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`),
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &description)).To(Succeed())
+		instance := v1alpha1.FlagSubmission{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.FlagSubmissionSpec{
+				ChallengeDescriptionName: description.Name,
+				Flag:                     "CTF{test}",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Result).To(Equal(v1alpha1.FlagSubmissionResultCorrect))
+	})
+
+	It("should award the points of the matching flag matcher", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := v1alpha1.ChallengeDescription{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeDescriptionSpec{
+				Title:       "test",
+				Description: "test",
+				Value:       100,
+				Flags: []v1alpha1.FlagMatcher{
+					{
+						Type:  v1alpha1.FlagMatcherTypeCaseInsensitive,
+						Value: "CTF{first}",
+					},
+					{
+						Type:   v1alpha1.FlagMatcherTypeRegex,
+						Value:  `CTF\{second-[0-9]+\}`,
+						Points: ptr.To(50),
+					},
+				},
+				Manifests: []runtime.RawExtension{
+					{
+						Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`),
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &description)).To(Succeed())
+		instance := v1alpha1.FlagSubmission{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.FlagSubmissionSpec{
+				ChallengeDescriptionName: description.Name,
+				Flag:                     "CTF{second-42}",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Result).To(Equal(v1alpha1.FlagSubmissionResultCorrect))
+		Expect(instance.Status.PointsAwarded).To(Equal(50))
+	})
+
+	It("should verify the flag of a challenge instance", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescription(ctx, "", &v1alpha1.FlagGeneration{
+			Mode:   v1alpha1.FlagGenerationModeRandom,
+			Format: "CTF{%s}",
+			Length: 16,
+		})
+
+		challengeInstance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionName: description.Name,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &challengeInstance)).To(Succeed())
+
+		secret := corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			StringData: map[string]string{
+				"flag": "CTF{instance}",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &secret)).To(Succeed())
+		challengeInstance.Status.FlagSecretRef = &v1alpha1.SecretKeyReference{
+			Namespace: secret.Namespace,
+			Name:      secret.Name,
+			Key:       "flag",
+		}
+		Expect(k8sClient.Status().Update(ctx, &challengeInstance)).To(Succeed())
+
+		instance := v1alpha1.FlagSubmission{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.FlagSubmissionSpec{
+				ChallengeInstanceName: challengeInstance.Name,
+				Flag:                  "CTF{instance}",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Result).To(Equal(v1alpha1.FlagSubmissionResultCorrect))
+		Expect(instance.Status.PointsAwarded).To(Equal(100))
+	})
+
+	It("should record the progress of a completed stage", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		challengeInstance := createInstanceWithStages(ctx)
+		instance := v1alpha1.FlagSubmission{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.FlagSubmissionSpec{
+				ChallengeInstanceName: challengeInstance.Name,
+				Flag:                  "CTF{stage-1}",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Result).To(Equal(v1alpha1.FlagSubmissionResultCorrect))
+		Expect(instance.Status.PointsAwarded).To(Equal(25))
+		Expect(instance.Status.Stage).To(Equal("first"))
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&challengeInstance), &challengeInstance)).To(Succeed())
+		Expect(challengeInstance.Status.Stages).To(HaveLen(1))
+		Expect(challengeInstance.Status.Stages[0].Name).To(Equal("first"))
+		Expect(challengeInstance.Status.Stages[0].PointsAwarded).To(Equal(25))
+		Expect(challengeInstance.Status.Stages[0].FlagSubmissionName).To(Equal(instance.Name))
+	})
+
+	It("should not award points for a stage twice", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		challengeInstance := createInstanceWithStages(ctx)
+		firstSubmission := v1alpha1.FlagSubmission{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.FlagSubmissionSpec{
+				ChallengeInstanceName: challengeInstance.Name,
+				Flag:                  "CTF{stage-1}",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &firstSubmission)).To(Succeed())
+		_, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&firstSubmission))
+		Expect(err).ToNot(HaveOccurred())
+
+		instance := v1alpha1.FlagSubmission{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.FlagSubmissionSpec{
+				ChallengeInstanceName: challengeInstance.Name,
+				Flag:                  "CTF{stage-1}",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Result).To(Equal(v1alpha1.FlagSubmissionResultCorrect))
+		Expect(instance.Status.PointsAwarded).To(BeZero())
+		Expect(instance.Status.Stage).To(Equal("first"))
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&challengeInstance), &challengeInstance)).To(Succeed())
+		Expect(challengeInstance.Status.Stages).To(HaveLen(1))
+		Expect(challengeInstance.Status.Stages[0].FlagSubmissionName).To(Equal(firstSubmission.Name))
+	})
+
+	It("should reject a flag for a generated challenge description", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescription(ctx, "", &v1alpha1.FlagGeneration{
+			Mode:   v1alpha1.FlagGenerationModeRandom,
+			Format: "CTF{%s}",
+			Length: 16,
+		})
+		instance := v1alpha1.FlagSubmission{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.FlagSubmissionSpec{
+				ChallengeDescriptionName: description.Name,
+				Flag:                     "CTF{test}",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Result).To(Equal(v1alpha1.FlagSubmissionResultIncorrect))
+		Expect(instance.Status.Message).ToNot(BeEmpty())
+	})
+
+	It("should reject a flag for an invalid regular expression", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := v1alpha1.ChallengeDescription{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeDescriptionSpec{
+				Title:       "test",
+				Description: "test",
+				Value:       100,
+				Flags: []v1alpha1.FlagMatcher{
+					{
+						Type:  v1alpha1.FlagMatcherTypeRegex,
+						Value: `CTF\{[0-9+\}`,
+					},
+				},
+				Manifests: []runtime.RawExtension{
+					{
+						Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`),
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, &description)).To(Succeed())
+
+		instance := v1alpha1.FlagSubmission{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.FlagSubmissionSpec{
+				ChallengeDescriptionName: description.Name,
+				Flag:                     "CTF{1}",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Result).To(Equal(v1alpha1.FlagSubmissionResultIncorrect))
+		Expect(instance.Status.Message).To(ContainSubstring(flag.ErrInvalidRegex.Error()))
+	})
+
+	It("should not verify the flag again", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescription(ctx, "CTF{test}", nil)
+		instance := v1alpha1.FlagSubmission{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.FlagSubmissionSpec{
+				ChallengeDescriptionName: description.Name,
+				Flag:                     "CTF{test}",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+		instance.Status.Result = v1alpha1.FlagSubmissionResultIncorrect
+		Expect(k8sClient.Status().Update(ctx, &instance)).To(Succeed())
+
+		By("run the reconciler")
+		result, err := reconciler.Reconcile(ctx, testutils.RequestFromObject(&instance))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+
+		By("verify all postconditions")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		Expect(instance.Status.Result).To(Equal(v1alpha1.FlagSubmissionResultIncorrect))
+	})
+})
+
+// createDescription creates a challenge description with the given flag and flag generation.
+func createDescription(ctx SpecContext, staticFlag string, flagGeneration *v1alpha1.FlagGeneration) v1alpha1.ChallengeDescription {
+	description := v1alpha1.ChallengeDescription{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeDescriptionSpec{
+			Title:          "test",
+			Description:    "test",
+			Value:          100,
+			Flag:           staticFlag,
+			FlagGeneration: flagGeneration,
+			Manifests: []runtime.RawExtension{
+				{
+					Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`),
+				},
+			},
+		},
+	}
+	Expect(k8sClient.Create(ctx, &description)).To(Succeed())
+	return description
+}
+
+// createInstanceWithStages creates a challenge instance for a challenge description with two stages.
+func createInstanceWithStages(ctx SpecContext) v1alpha1.ChallengeInstance {
+	description := v1alpha1.ChallengeDescription{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeDescriptionSpec{
+			Title:       "test",
+			Description: "test",
+			Value:       100,
+			Flag:        "CTF{final}",
+			Stages: []v1alpha1.ChallengeStage{
+				{
+					Name: "first",
+					Flags: []v1alpha1.FlagMatcher{
+						{
+							Type:  v1alpha1.FlagMatcherTypeStatic,
+							Value: "CTF{stage-1}",
+						},
+					},
+					Points: 25,
+				},
+				{
+					Name: "second",
+					Flags: []v1alpha1.FlagMatcher{
+						{
+							Type:  v1alpha1.FlagMatcherTypeStatic,
+							Value: "CTF{stage-2}",
+						},
+					},
+					Points: 50,
+				},
+			},
+			Manifests: []runtime.RawExtension{
+				{
+					Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`),
+				},
+			},
+		},
+	}
+	Expect(k8sClient.Create(ctx, &description)).To(Succeed())
+
+	challengeInstance := v1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeInstanceSpec{
+			ChallengeDescriptionName: description.Name,
+		},
+	}
+	Expect(k8sClient.Create(ctx, &challengeInstance)).To(Succeed())
+	return challengeInstance
+}
@@ -6,8 +6,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
 	"github.com/backbone81/ctf-challenge-operator/internal/controller/apikey"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengedescription"
 	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstance"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstancepool"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/flagsubmission"
+	"github.com/backbone81/ctf-challenge-operator/internal/portallocator"
 )
 
 // Reconciler is the main reconciler of this operator. It is responsible for registering and running all
@@ -48,11 +53,37 @@ type SubReconciler interface {
 // ReconcilerOption is an option which can be applied to the reconciler.
 type ReconcilerOption func(reconciler *Reconciler)
 
-// WithDefaultReconcilers returns a reconciler option which enables the default sub-reconcilers.
-func WithDefaultReconcilers(recorder record.EventRecorder) ReconcilerOption {
+// WithDefaultReconcilers returns a reconciler option which enables the default sub-reconcilers. The namespaces of
+// challenge instances are named with the given prefix, enforce the given pod security levels, only accept traffic
+// as given by the network policy config and are limited by the given default resource budget. Node ports are reported
+// with the given node address and services are exposed as given by the exposure config. External ports are allocated
+// with the given port allocator.
+func WithDefaultReconcilers(
+	recorder record.EventRecorder,
+	namespacePrefix string,
+	defaultPodSecurityLevel v1alpha1.PodSecurityLevel,
+	maxPodSecurityLevel v1alpha1.PodSecurityLevel,
+	networkPolicyConfig challengeinstance.NetworkPolicyConfig,
+	defaultResourceBudget v1alpha1.ResourceBudget,
+	nodeAddress string,
+	exposureConfig challengeinstance.ExposureConfig,
+	portAllocator *portallocator.Allocator,
+) ReconcilerOption {
 	return func(reconciler *Reconciler) {
 		WithAPIKeyReconciler()(reconciler)
-		WithChallengeInstanceReconciler(recorder)(reconciler)
+		WithChallengeInstanceReconciler(
+			recorder,
+			namespacePrefix,
+			defaultPodSecurityLevel,
+			maxPodSecurityLevel,
+			networkPolicyConfig,
+			defaultResourceBudget,
+			nodeAddress,
+			exposureConfig,
+			portAllocator,
+		)(reconciler)
+		WithChallengeInstancePoolReconciler()(reconciler)
+		WithFlagSubmissionReconciler()(reconciler)
 	}
 }
 
@@ -66,12 +97,68 @@ func WithAPIKeyReconciler() ReconcilerOption {
 	}
 }
 
-// WithChallengeInstanceReconciler returns a reconciler option which enables the ChallengeInstance sub-reconciler.
-func WithChallengeInstanceReconciler(recorder record.EventRecorder) ReconcilerOption {
+// WithChallengeInstanceReconciler returns a reconciler option which enables the ChallengeInstance sub-reconciler. The
+// namespaces of challenge instances are named with the given prefix, enforce the given pod security levels, only
+// accept traffic as given by the network policy config and are limited by the given default resource budget. Node ports
+// are reported with the given node address and services are exposed as given by the exposure config. External ports
+// are allocated with the given port allocator.
+func WithChallengeInstanceReconciler(
+	recorder record.EventRecorder,
+	namespacePrefix string,
+	defaultPodSecurityLevel v1alpha1.PodSecurityLevel,
+	maxPodSecurityLevel v1alpha1.PodSecurityLevel,
+	networkPolicyConfig challengeinstance.NetworkPolicyConfig,
+	defaultResourceBudget v1alpha1.ResourceBudget,
+	nodeAddress string,
+	exposureConfig challengeinstance.ExposureConfig,
+	portAllocator *portallocator.Allocator,
+) ReconcilerOption {
 	return func(reconciler *Reconciler) {
 		reconciler.subReconcilers = append(
 			reconciler.subReconcilers,
-			challengeinstance.NewReconciler(reconciler.client, challengeinstance.WithDefaultReconcilers(recorder)),
+			challengeinstance.NewReconciler(reconciler.client, challengeinstance.WithDefaultReconcilers(
+				recorder,
+				namespacePrefix,
+				defaultPodSecurityLevel,
+				maxPodSecurityLevel,
+				networkPolicyConfig,
+				defaultResourceBudget,
+				nodeAddress,
+				exposureConfig,
+				portAllocator,
+			)),
+		)
+	}
+}
+
+// WithChallengeInstancePoolReconciler returns a reconciler option which enables the ChallengeInstancePool
+// sub-reconciler.
+func WithChallengeInstancePoolReconciler() ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.subReconcilers = append(
+			reconciler.subReconcilers,
+			challengeinstancepool.NewReconciler(reconciler.client, challengeinstancepool.WithDefaultReconcilers()),
+		)
+	}
+}
+
+// WithFlagSubmissionReconciler returns a reconciler option which enables the FlagSubmission sub-reconciler.
+func WithFlagSubmissionReconciler() ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.subReconcilers = append(
+			reconciler.subReconcilers,
+			flagsubmission.NewReconciler(reconciler.client, flagsubmission.WithDefaultReconcilers()),
+		)
+	}
+}
+
+// WithFlagMigrationReconciler returns a reconciler option which enables the migration of plain text flags in
+// ChallengeDescriptions into secrets.
+func WithFlagMigrationReconciler() ReconcilerOption {
+	return func(reconciler *Reconciler) {
+		reconciler.subReconcilers = append(
+			reconciler.subReconcilers,
+			challengedescription.NewReconciler(reconciler.client, challengedescription.WithMigrateFlagReconciler()),
 		)
 	}
 }
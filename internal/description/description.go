@@ -0,0 +1,110 @@
+// Package description resolves the challenge description a challenge instance references. Challenge instances can
+// reference a ChallengeDescription in their own namespace or a cluster-scoped ClusterChallengeDescription. Both are
+// returned as ChallengeDescription, so that the rest of the operator does not need to distinguish between them.
+package description
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+)
+
+// Ref returns the reference to the challenge description of the given challenge instance. Challenge instances which
+// only provide the name of the challenge description reference a ChallengeDescription in their own namespace.
+func Ref(challengeInstance *v1alpha1.ChallengeInstance) v1alpha1.ChallengeDescriptionReference {
+	if challengeInstance.Spec.ChallengeDescriptionRef == nil {
+		return v1alpha1.ChallengeDescriptionReference{
+			Kind: v1alpha1.ChallengeDescriptionKind,
+			Name: challengeInstance.Spec.ChallengeDescriptionName,
+		}
+	}
+	result := *challengeInstance.Spec.ChallengeDescriptionRef
+	if len(result.Kind) == 0 {
+		result.Kind = v1alpha1.ChallengeDescriptionKind
+	}
+	return result
+}
+
+// IsSame returns true if both challenge instances reference the same challenge description.
+func IsSame(lhs *v1alpha1.ChallengeInstance, rhs *v1alpha1.ChallengeInstance) bool {
+	lhsRef := Ref(lhs)
+	if lhsRef != Ref(rhs) {
+		return false
+	}
+	return lhsRef.Kind == v1alpha1.ClusterChallengeDescriptionKind || lhs.Namespace == rhs.Namespace
+}
+
+// String returns a human readable identification of the challenge description of the given challenge instance.
+func String(challengeInstance *v1alpha1.ChallengeInstance) string {
+	return RefString(challengeInstance.Namespace, Ref(challengeInstance))
+}
+
+// RefString returns a human readable identification of the referenced challenge description. The namespace is the
+// namespace a ChallengeDescription is looked up in.
+func RefString(namespace string, ref v1alpha1.ChallengeDescriptionReference) string {
+	if ref.Kind == v1alpha1.ClusterChallengeDescriptionKind {
+		return fmt.Sprintf("%s %s", ref.Kind, ref.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", ref.Kind, namespace, ref.Name)
+}
+
+// Get returns the challenge description the given challenge instance references. A ClusterChallengeDescription is
+// returned as ChallengeDescription in its secret namespace, so that the secrets it references are looked up there.
+func Get(ctx context.Context, k8sClient client.Client, challengeInstance *v1alpha1.ChallengeInstance) (*v1alpha1.ChallengeDescription, error) {
+	return GetByRef(ctx, k8sClient, challengeInstance.Namespace, Ref(challengeInstance))
+}
+
+// GetByRef returns the referenced challenge description. A ChallengeDescription is looked up in the given namespace,
+// a ClusterChallengeDescription is returned as ChallengeDescription in its secret namespace.
+func GetByRef(ctx context.Context, k8sClient client.Client, namespace string, ref v1alpha1.ChallengeDescriptionReference) (*v1alpha1.ChallengeDescription, error) {
+	if len(ref.Kind) == 0 {
+		ref.Kind = v1alpha1.ChallengeDescriptionKind
+	}
+	if len(ref.Name) == 0 {
+		return nil, apierrors.NewNotFound(v1alpha1.GroupVersion.WithResource("challengedescriptions").GroupResource(), ref.Name)
+	}
+	switch ref.Kind {
+	case v1alpha1.ChallengeDescriptionKind:
+		var challengeDescription v1alpha1.ChallengeDescription
+		if err := k8sClient.Get(ctx, client.ObjectKey{
+			Namespace: namespace,
+			Name:      ref.Name,
+		}, &challengeDescription); err != nil {
+			return nil, err
+		}
+		return &challengeDescription, nil
+	case v1alpha1.ClusterChallengeDescriptionKind:
+		var clusterChallengeDescription v1alpha1.ClusterChallengeDescription
+		if err := k8sClient.Get(ctx, client.ObjectKey{
+			Name: ref.Name,
+		}, &clusterChallengeDescription); err != nil {
+			return nil, err
+		}
+		return FromCluster(&clusterChallengeDescription), nil
+	default:
+		return nil, fmt.Errorf("unsupported challenge description kind %s", ref.Kind)
+	}
+}
+
+// FromCluster converts the given cluster challenge description into a challenge description. The namespace of the
+// result is the secret namespace of the cluster challenge description.
+func FromCluster(clusterChallengeDescription *v1alpha1.ClusterChallengeDescription) *v1alpha1.ChallengeDescription {
+	return &v1alpha1.ChallengeDescription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              clusterChallengeDescription.Name,
+			Namespace:         clusterChallengeDescription.Spec.SecretNamespace,
+			UID:               clusterChallengeDescription.UID,
+			ResourceVersion:   clusterChallengeDescription.ResourceVersion,
+			Generation:        clusterChallengeDescription.Generation,
+			CreationTimestamp: clusterChallengeDescription.CreationTimestamp,
+			Labels:            clusterChallengeDescription.Labels,
+			Annotations:       clusterChallengeDescription.Annotations,
+		},
+		Spec: clusterChallengeDescription.Spec.ChallengeDescriptionSpec,
+	}
+}
@@ -0,0 +1,171 @@
+package description_test
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/description"
+)
+
+var _ = Describe("Ref", func() {
+	It("should reference a challenge description by name", func() {
+		instance := newInstance("default", "test", nil)
+		Expect(description.Ref(&instance)).To(Equal(v1alpha1.ChallengeDescriptionReference{
+			Kind: v1alpha1.ChallengeDescriptionKind,
+			Name: "test",
+		}))
+	})
+
+	It("should default the kind of the reference", func() {
+		instance := newInstance("default", "", &v1alpha1.ChallengeDescriptionReference{
+			Name: "test",
+		})
+		Expect(description.Ref(&instance).Kind).To(Equal(v1alpha1.ChallengeDescriptionKind))
+	})
+
+	It("should return the reference to a cluster challenge description", func() {
+		ref := v1alpha1.ChallengeDescriptionReference{
+			Kind: v1alpha1.ClusterChallengeDescriptionKind,
+			Name: "test",
+		}
+		instance := newInstance("default", "", &ref)
+		Expect(description.Ref(&instance)).To(Equal(ref))
+	})
+})
+
+var _ = Describe("IsSame", func() {
+	It("should treat the name and the reference of a challenge description alike", func() {
+		lhs := newInstance("default", "test", nil)
+		rhs := newInstance("default", "", &v1alpha1.ChallengeDescriptionReference{
+			Kind: v1alpha1.ChallengeDescriptionKind,
+			Name: "test",
+		})
+		Expect(description.IsSame(&lhs, &rhs)).To(BeTrue())
+	})
+
+	It("should distinguish challenge descriptions in different namespaces", func() {
+		lhs := newInstance("team-a", "test", nil)
+		rhs := newInstance("team-b", "test", nil)
+		Expect(description.IsSame(&lhs, &rhs)).To(BeFalse())
+	})
+
+	It("should not distinguish cluster challenge descriptions by namespace", func() {
+		ref := v1alpha1.ChallengeDescriptionReference{
+			Kind: v1alpha1.ClusterChallengeDescriptionKind,
+			Name: "test",
+		}
+		lhs := newInstance("team-a", "", &ref)
+		rhs := newInstance("team-b", "", &ref)
+		Expect(description.IsSame(&lhs, &rhs)).To(BeTrue())
+	})
+
+	It("should distinguish challenge descriptions from cluster challenge descriptions", func() {
+		lhs := newInstance("default", "test", nil)
+		rhs := newInstance("default", "", &v1alpha1.ChallengeDescriptionReference{
+			Kind: v1alpha1.ClusterChallengeDescriptionKind,
+			Name: "test",
+		})
+		Expect(description.IsSame(&lhs, &rhs)).To(BeFalse())
+	})
+})
+
+var _ = Describe("FromCluster", func() {
+	It("should place the challenge description in the secret namespace", func() {
+		clusterDescription := v1alpha1.ClusterChallengeDescription{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test",
+			},
+			Spec: v1alpha1.ClusterChallengeDescriptionSpec{
+				ChallengeDescriptionSpec: v1alpha1.ChallengeDescriptionSpec{
+					Title: "title",
+				},
+				SecretNamespace: "secrets",
+			},
+		}
+		challengeDescription := description.FromCluster(&clusterDescription)
+		Expect(challengeDescription.Name).To(Equal("test"))
+		Expect(challengeDescription.Namespace).To(Equal("secrets"))
+		Expect(challengeDescription.Spec.Title).To(Equal("title"))
+	})
+})
+
+var _ = Describe("GetByRef", func() {
+	var k8sClient client.Client
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+		k8sClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(
+				&v1alpha1.ChallengeDescription{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "default",
+						Name:      "test",
+					},
+					Spec: v1alpha1.ChallengeDescriptionSpec{
+						Title: "namespaced",
+					},
+				},
+				&v1alpha1.ClusterChallengeDescription{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+					Spec: v1alpha1.ClusterChallengeDescriptionSpec{
+						ChallengeDescriptionSpec: v1alpha1.ChallengeDescriptionSpec{
+							Title: "cluster",
+						},
+						SecretNamespace: "secrets",
+					},
+				},
+			).
+			Build()
+	})
+
+	It("should return the challenge description in the given namespace", func(ctx SpecContext) {
+		challengeDescription, err := description.GetByRef(ctx, k8sClient, "default", v1alpha1.ChallengeDescriptionReference{
+			Name: "test",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(challengeDescription.Namespace).To(Equal("default"))
+		Expect(challengeDescription.Spec.Title).To(Equal("namespaced"))
+	})
+
+	It("should return the cluster challenge description", func(ctx SpecContext) {
+		challengeDescription, err := description.GetByRef(ctx, k8sClient, "team-a", v1alpha1.ChallengeDescriptionReference{
+			Kind: v1alpha1.ClusterChallengeDescriptionKind,
+			Name: "test",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(challengeDescription.Namespace).To(Equal("secrets"))
+		Expect(challengeDescription.Spec.Title).To(Equal("cluster"))
+	})
+
+	It("should not find a challenge description in another namespace", func(ctx SpecContext) {
+		_, err := description.GetByRef(ctx, k8sClient, "team-a", v1alpha1.ChallengeDescriptionReference{
+			Kind: v1alpha1.ChallengeDescriptionKind,
+			Name: "test",
+		})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+})
+
+func newInstance(namespace string, name string, ref *v1alpha1.ChallengeDescriptionReference) v1alpha1.ChallengeInstance {
+	return v1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "instance",
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ChallengeInstanceSpec{
+			ChallengeDescriptionName: name,
+			ChallengeDescriptionRef:  ref,
+		},
+	}
+}
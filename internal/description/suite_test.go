@@ -0,0 +1,13 @@
+package description_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDescription(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Description Suite")
+}
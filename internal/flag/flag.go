@@ -0,0 +1,274 @@
+// Package flag provides the generation of flags for challenge instances.
+package flag
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+)
+
+const (
+	// DefaultFormat is the format of the generated flag when none is configured.
+	DefaultFormat = "CTF{%s}"
+
+	// DefaultLength is the number of bytes of the generated value when none is configured.
+	DefaultLength = 16
+)
+
+// IsGenerated returns true if the given flag generation produces a dedicated flag per challenge instance.
+func IsGenerated(flagGeneration *v1alpha1.FlagGeneration) bool {
+	return flagGeneration != nil && getMode(flagGeneration) != v1alpha1.FlagGenerationModeStatic
+}
+
+// Generate returns the flag for a challenge instance. The given static flag is returned when no flag generation is
+// configured. The HMAC key is only used for mode HMAC, the instance UID is the message authenticated by the HMAC.
+func Generate(flagGeneration *v1alpha1.FlagGeneration, staticFlag string, instanceUID string, hmacKey []byte) (string, error) {
+	if !IsGenerated(flagGeneration) {
+		if len(staticFlag) == 0 {
+			return "", errors.New("no static flag provided")
+		}
+		return staticFlag, nil
+	}
+
+	var value []byte
+	switch mode := getMode(flagGeneration); mode {
+	case v1alpha1.FlagGenerationModeRandom:
+		value = make([]byte, getLength(flagGeneration))
+		if _, err := rand.Read(value); err != nil {
+			return "", fmt.Errorf("reading crypto rand: %w", err)
+		}
+	case v1alpha1.FlagGenerationModeHMAC:
+		if len(hmacKey) == 0 {
+			return "", errors.New("no HMAC key provided")
+		}
+		mac := hmac.New(sha256.New, hmacKey)
+		mac.Write([]byte(instanceUID))
+		value = mac.Sum(nil)[:getLength(flagGeneration)]
+	default:
+		return "", fmt.Errorf("unknown flag generation mode %q", mode)
+	}
+	return Format(flagGeneration, hex.EncodeToString(value)), nil
+}
+
+// Format places the given value into the format of the flag generation.
+func Format(flagGeneration *v1alpha1.FlagGeneration, value string) string {
+	if len(flagGeneration.Prefix) != 0 {
+		value = flagGeneration.Prefix + "_" + value
+	}
+	format := flagGeneration.Format
+	if len(format) == 0 {
+		format = DefaultFormat
+	}
+	return strings.Replace(format, "%s", value, 1)
+}
+
+func getMode(flagGeneration *v1alpha1.FlagGeneration) v1alpha1.FlagGenerationMode {
+	if len(flagGeneration.Mode) == 0 {
+		return v1alpha1.FlagGenerationModeStatic
+	}
+	return flagGeneration.Mode
+}
+
+func getLength(flagGeneration *v1alpha1.FlagGeneration) int {
+	if flagGeneration.Length <= 0 {
+		return DefaultLength
+	}
+	return min(flagGeneration.Length, sha256.Size)
+}
+
+// Verify reports if the candidate flag matches the expected flag. The comparison is done in constant time, which does
+// not even leak the length of the expected flag.
+func Verify(expected string, candidate string) bool {
+	expectedSum := sha256.Sum256([]byte(expected))
+	candidateSum := sha256.Sum256([]byte(candidate))
+	return subtle.ConstantTimeCompare(expectedSum[:], candidateSum[:]) == 1
+}
+
+// GetFromSecret returns the flag stored in the referenced secret key.
+func GetFromSecret(ctx context.Context, k8sClient client.Client, secretRef *v1alpha1.SecretKeyReference) (string, error) {
+	var secret corev1.Secret
+	if err := k8sClient.Get(ctx, client.ObjectKey{
+		Namespace: secretRef.Namespace,
+		Name:      secretRef.Name,
+	}, &secret); err != nil {
+		return "", fmt.Errorf("getting flag secret: %w", err)
+	}
+	return string(secret.Data[secretRef.Key]), nil
+}
+
+// ErrNoPlainText is returned when the static flag of a challenge description is not available in plain text. This is
+// the case when the flag is only known as hash or when only flag matchers are provided.
+var ErrNoPlainText = errors.New("the flag is not available in plain text")
+
+// ErrInvalidRegex is returned when the value of a flag matcher of type regex is not a valid regular expression.
+var ErrInvalidRegex = errors.New("invalid regular expression")
+
+// GetStatic returns the static flag of the given challenge description in plain text. ErrNoPlainText is returned when
+// the flag is not available in plain text.
+func GetStatic(ctx context.Context, k8sClient client.Client, challengeDescription *v1alpha1.ChallengeDescription) (string, error) {
+	switch {
+	case len(challengeDescription.Spec.Flag) != 0:
+		return challengeDescription.Spec.Flag, nil
+	case challengeDescription.Spec.FlagSecretRef != nil:
+		return getFromSecretKeySelector(ctx, k8sClient, challengeDescription.Namespace, challengeDescription.Spec.FlagSecretRef)
+	default:
+		return "", ErrNoPlainText
+	}
+}
+
+// hasStatic reports if the given challenge description provides a static flag in any form.
+func hasStatic(challengeDescription *v1alpha1.ChallengeDescription) bool {
+	return len(challengeDescription.Spec.Flag) != 0 ||
+		challengeDescription.Spec.FlagSecretRef != nil ||
+		challengeDescription.Spec.FlagHash != nil
+}
+
+// VerifyStatic reports if the candidate flag matches the static flag of the given challenge description. All forms
+// of static flags are supported.
+func VerifyStatic(ctx context.Context, k8sClient client.Client, challengeDescription *v1alpha1.ChallengeDescription, candidate string) (bool, error) {
+	if challengeDescription.Spec.FlagHash != nil {
+		return VerifyHash(challengeDescription.Spec.FlagHash, candidate)
+	}
+	expected, err := GetStatic(ctx, k8sClient, challengeDescription)
+	if err != nil {
+		return false, err
+	}
+	return Verify(expected, candidate), nil
+}
+
+// Match reports if the candidate flag matches the static flag or any of the flag matchers of the given challenge
+// description. The points awarded for the matching flag are returned as well.
+func Match(ctx context.Context, k8sClient client.Client, challengeDescription *v1alpha1.ChallengeDescription, candidate string) (bool, int, error) {
+	if hasStatic(challengeDescription) {
+		correct, err := VerifyStatic(ctx, k8sClient, challengeDescription, candidate)
+		if err != nil {
+			return false, 0, err
+		}
+		if correct {
+			return true, challengeDescription.Spec.Value, nil
+		}
+	}
+
+	for i := range challengeDescription.Spec.Flags {
+		flagMatcher := &challengeDescription.Spec.Flags[i]
+		matched, err := matchFlagMatcher(ctx, k8sClient, challengeDescription.Namespace, flagMatcher, candidate)
+		if err != nil {
+			return false, 0, fmt.Errorf("flag matcher %d: %w", i, err)
+		}
+		if matched {
+			points := challengeDescription.Spec.Value
+			if flagMatcher.Points != nil {
+				points = *flagMatcher.Points
+			}
+			return true, points, nil
+		}
+	}
+	return false, 0, nil
+}
+
+// matchFlagMatcher reports if the candidate flag matches the given flag matcher. The namespace is used for looking up
+// the secret referenced by the flag matcher.
+func matchFlagMatcher(ctx context.Context, k8sClient client.Client, namespace string, flagMatcher *v1alpha1.FlagMatcher, candidate string) (bool, error) {
+	value := flagMatcher.Value
+	if flagMatcher.ValueSecretRef != nil {
+		var err error
+		value, err = getFromSecretKeySelector(ctx, k8sClient, namespace, flagMatcher.ValueSecretRef)
+		if err != nil {
+			return false, err
+		}
+	}
+	return MatchValue(flagMatcher.Type, value, candidate)
+}
+
+// MatchValue reports if the candidate flag matches the given value with the given matcher type. Static and
+// case-insensitive comparisons are done in constant time, regular expressions are not.
+func MatchValue(matcherType v1alpha1.FlagMatcherType, value string, candidate string) (bool, error) {
+	switch matcherType {
+	case v1alpha1.FlagMatcherTypeStatic, "":
+		return Verify(value, candidate), nil
+	case v1alpha1.FlagMatcherTypeCaseInsensitive:
+		return Verify(strings.ToLower(value), strings.ToLower(candidate)), nil
+	case v1alpha1.FlagMatcherTypeRegex:
+		regex, err := CompileRegex(value)
+		if err != nil {
+			return false, fmt.Errorf("%w: %w", ErrInvalidRegex, err)
+		}
+		return regex.MatchString(candidate), nil
+	default:
+		return false, fmt.Errorf("unknown flag matcher type %q", matcherType)
+	}
+}
+
+// CompileRegex compiles the value of a flag matcher of type regex. The regular expression must match the whole flag.
+func CompileRegex(value string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + value + ")$")
+}
+
+func getFromSecretKeySelector(ctx context.Context, k8sClient client.Client, namespace string, secretKeySelector *corev1.SecretKeySelector) (string, error) {
+	value, err := GetFromSecret(ctx, k8sClient, &v1alpha1.SecretKeyReference{
+		Namespace: namespace,
+		Name:      secretKeySelector.Name,
+		Key:       secretKeySelector.Key,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(value) == 0 {
+		return "", fmt.Errorf("secret %s does not contain key %s", secretKeySelector.Name, secretKeySelector.Key)
+	}
+	return value, nil
+}
+
+// Hash returns the hex encoded hash of the salt followed by the flag. This is the format expected by FlagHash.
+func Hash(salt string, value string) string {
+	sum := sha256.Sum256([]byte(salt + value))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyHash reports if the candidate flag matches the given flag hash. The comparison is done in constant time.
+func VerifyHash(flagHash *v1alpha1.FlagHash, candidate string) (bool, error) {
+	if len(flagHash.Algorithm) != 0 && flagHash.Algorithm != v1alpha1.FlagHashAlgorithmSHA256 {
+		return false, fmt.Errorf("unknown flag hash algorithm %q", flagHash.Algorithm)
+	}
+	expectedSum, err := hex.DecodeString(flagHash.Hash)
+	if err != nil {
+		return false, fmt.Errorf("decoding flag hash: %w", err)
+	}
+	candidateSum := sha256.Sum256([]byte(flagHash.Salt + candidate))
+	return subtle.ConstantTimeCompare(expectedSum, candidateSum[:]) == 1, nil
+}
+
+// MatchStage returns the first stage of the given challenge description with a flag matching the candidate flag. The
+// points awarded for the matching flag are returned as well. Nil is returned when no stage matches.
+func MatchStage(ctx context.Context, k8sClient client.Client, challengeDescription *v1alpha1.ChallengeDescription, candidate string) (*v1alpha1.ChallengeStage, int, error) {
+	for i := range challengeDescription.Spec.Stages {
+		stage := &challengeDescription.Spec.Stages[i]
+		for j := range stage.Flags {
+			flagMatcher := &stage.Flags[j]
+			matched, err := matchFlagMatcher(ctx, k8sClient, challengeDescription.Namespace, flagMatcher, candidate)
+			if err != nil {
+				return nil, 0, fmt.Errorf("stage %s flag matcher %d: %w", stage.Name, j, err)
+			}
+			if matched {
+				points := stage.Points
+				if flagMatcher.Points != nil {
+					points = *flagMatcher.Points
+				}
+				return stage, points, nil
+			}
+		}
+	}
+	return nil, 0, nil
+}
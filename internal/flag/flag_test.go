@@ -0,0 +1,135 @@
+package flag_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/flag"
+)
+
+var _ = Describe("Generate", func() {
+	It("should return the static flag without flag generation", func() {
+		value, err := flag.Generate(nil, "CTF{static}", "uid", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value).To(Equal("CTF{static}"))
+	})
+
+	It("should return the static flag for mode static", func() {
+		value, err := flag.Generate(&v1alpha1.FlagGeneration{
+			Mode: v1alpha1.FlagGenerationModeStatic,
+		}, "CTF{static}", "uid", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value).To(Equal("CTF{static}"))
+	})
+
+	It("should fail without a static flag", func() {
+		_, err := flag.Generate(nil, "", "uid", nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should generate unique random flags", func() {
+		flagGeneration := v1alpha1.FlagGeneration{
+			Mode:   v1alpha1.FlagGenerationModeRandom,
+			Format: "CTF{%s}",
+			Prefix: "web",
+			Length: 8,
+		}
+		first, err := flag.Generate(&flagGeneration, "", "uid", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(first).To(MatchRegexp(`^CTF\{web_[0-9a-f]{16}\}$`))
+
+		second, err := flag.Generate(&flagGeneration, "", "uid", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(second).ToNot(Equal(first))
+	})
+
+	It("should generate deterministic HMAC flags", func() {
+		flagGeneration := v1alpha1.FlagGeneration{
+			Mode: v1alpha1.FlagGenerationModeHMAC,
+		}
+		first, err := flag.Generate(&flagGeneration, "", "uid-1", []byte("key"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(first).To(MatchRegexp(`^CTF\{[0-9a-f]{32}\}$`))
+
+		second, err := flag.Generate(&flagGeneration, "", "uid-1", []byte("key"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(second).To(Equal(first))
+
+		third, err := flag.Generate(&flagGeneration, "", "uid-2", []byte("key"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(third).ToNot(Equal(first))
+	})
+
+	It("should fail for mode HMAC without a key", func() {
+		_, err := flag.Generate(&v1alpha1.FlagGeneration{
+			Mode: v1alpha1.FlagGenerationModeHMAC,
+		}, "", "uid", nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Verify", func() {
+	It("should accept the expected flag", func() {
+		Expect(flag.Verify("CTF{test}", "CTF{test}")).To(BeTrue())
+	})
+
+	It("should reject a different flag", func() {
+		Expect(flag.Verify("CTF{test}", "CTF{tes}")).To(BeFalse())
+		Expect(flag.Verify("CTF{test}", "")).To(BeFalse())
+	})
+})
+
+var _ = Describe("VerifyHash", func() {
+	It("should accept the flag matching the hash", func() {
+		correct, err := flag.VerifyHash(&v1alpha1.FlagHash{
+			Algorithm: v1alpha1.FlagHashAlgorithmSHA256,
+			Salt:      "01234567",
+			Hash:      flag.Hash("01234567", "CTF{test}"),
+		}, "CTF{test}")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(correct).To(BeTrue())
+	})
+
+	It("should reject a flag not matching the hash", func() {
+		correct, err := flag.VerifyHash(&v1alpha1.FlagHash{
+			Algorithm: v1alpha1.FlagHashAlgorithmSHA256,
+			Salt:      "01234567",
+			Hash:      flag.Hash("01234567", "CTF{test}"),
+		}, "CTF{wrong}")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(correct).To(BeFalse())
+	})
+
+	It("should reject a flag hashed with a different salt", func() {
+		correct, err := flag.VerifyHash(&v1alpha1.FlagHash{
+			Algorithm: v1alpha1.FlagHashAlgorithmSHA256,
+			Salt:      "01234567",
+			Hash:      flag.Hash("76543210", "CTF{test}"),
+		}, "CTF{test}")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(correct).To(BeFalse())
+	})
+})
+
+var _ = Describe("MatchValue", func() {
+	DescribeTable("matcher types",
+		func(matcherType v1alpha1.FlagMatcherType, value string, candidate string, expected bool) {
+			matched, err := flag.MatchValue(matcherType, value, candidate)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matched).To(Equal(expected))
+		},
+		Entry("static with identical flag", v1alpha1.FlagMatcherTypeStatic, "CTF{test}", "CTF{test}", true),
+		Entry("static with different case", v1alpha1.FlagMatcherTypeStatic, "CTF{test}", "ctf{TEST}", false),
+		Entry("case-insensitive with different case", v1alpha1.FlagMatcherTypeCaseInsensitive, "CTF{test}", "ctf{TEST}", true),
+		Entry("case-insensitive with different flag", v1alpha1.FlagMatcherTypeCaseInsensitive, "CTF{test}", "ctf{other}", false),
+		Entry("regex with complete match", v1alpha1.FlagMatcherTypeRegex, `CTF\{[0-9]+\}`, "CTF{123}", true),
+		Entry("regex with partial match", v1alpha1.FlagMatcherTypeRegex, `CTF\{[0-9]+\}`, "xCTF{123}x", false),
+		Entry("regex with alternatives", v1alpha1.FlagMatcherTypeRegex, `CTF\{a\}|CTF\{b\}`, "CTF{b}", true),
+	)
+
+	It("should fail for an invalid regular expression", func() {
+		_, err := flag.MatchValue(v1alpha1.FlagMatcherTypeRegex, `CTF\{[0-9+\}`, "CTF{1}")
+		Expect(err).To(MatchError(flag.ErrInvalidRegex))
+	})
+})
@@ -0,0 +1,13 @@
+package flag_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestFlag(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Flag Suite")
+}
@@ -0,0 +1,197 @@
+// Package portallocator hands out unique ports from a configured range. The allocator itself only keeps the
+// allocations in memory. Callers persist the allocated ports elsewhere and restore them after a restart, so that no port
+// is handed out twice.
+package portallocator
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrExhausted is returned when all ports of the range are allocated.
+var ErrExhausted = errors.New("all ports of the range are allocated")
+
+// ErrConflict is returned when a port which should be restored is already allocated to somebody else.
+var ErrConflict = errors.New("the port is already allocated")
+
+// Range is an inclusive range of ports.
+type Range struct {
+	Min int32
+	Max int32
+}
+
+// ParseRange parses a port range in the form "<min>-<max>", for example "31000-31999". A single port is accepted as
+// well.
+func ParseRange(value string) (Range, error) {
+	minValue, maxValue, found := strings.Cut(value, "-")
+	if !found {
+		maxValue = minValue
+	}
+	minPort, err := parsePort(minValue)
+	if err != nil {
+		return Range{}, fmt.Errorf("parsing port range %q: %w", value, err)
+	}
+	maxPort, err := parsePort(maxValue)
+	if err != nil {
+		return Range{}, fmt.Errorf("parsing port range %q: %w", value, err)
+	}
+	if minPort > maxPort {
+		return Range{}, fmt.Errorf("parsing port range %q: the first port must not be greater than the last port", value)
+	}
+	return Range{
+		Min: minPort,
+		Max: maxPort,
+	}, nil
+}
+
+func parsePort(value string) (int32, error) {
+	port, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	if port < 1 || port > 65535 {
+		return 0, fmt.Errorf("port %d is out of range", port)
+	}
+	return int32(port), nil
+}
+
+// Contains returns true if the port is part of the range.
+func (r Range) Contains(port int32) bool {
+	return r.Min <= port && port <= r.Max
+}
+
+// Size returns the number of ports in the range.
+func (r Range) Size() int {
+	return int(r.Max-r.Min) + 1
+}
+
+// String returns the range in the form accepted by ParseRange.
+func (r Range) String() string {
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+// Allocator hands out unique ports of a range. Every allocation belongs to a key, which identifies the owner of the
+// port. The allocator is safe for concurrent use.
+type Allocator struct {
+	mutex     sync.Mutex
+	portRange Range
+	ports     map[int32]string
+	keys      map[string]int32
+
+	// next is the port the search for a free port starts with. Ports are handed out round-robin, so that a port which
+	// was just released is not immediately handed out again.
+	next int32
+}
+
+// New returns a new allocator for the given port range without any allocations.
+func New(portRange Range) *Allocator {
+	return &Allocator{
+		portRange: portRange,
+		ports:     make(map[int32]string),
+		keys:      make(map[string]int32),
+		next:      portRange.Min,
+	}
+}
+
+// Range returns the port range of the allocator.
+func (a *Allocator) Range() Range {
+	return a.portRange
+}
+
+// Allocate returns the port allocated to the given key. When the key does not have a port yet, the next free port is
+// allocated. ErrExhausted is returned when no port is free.
+func (a *Allocator) Allocate(key string) (int32, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if port, ok := a.keys[key]; ok {
+		return port, nil
+	}
+	for i := 0; i < a.portRange.Size(); i++ {
+		port := a.next
+		a.next++
+		if a.next > a.portRange.Max {
+			a.next = a.portRange.Min
+		}
+		if _, ok := a.ports[port]; ok {
+			continue
+		}
+		a.ports[port] = key
+		a.keys[key] = port
+		return port, nil
+	}
+	return 0, ErrExhausted
+}
+
+// Restore records a port which was allocated to the given key before, for example before a restart. Restoring the same
+// allocation again is a no-op. ErrConflict is returned when the port is allocated to another key, and an error is
+// returned when the port is not part of the range. A key which had a different port before loses that port.
+func (a *Allocator) Restore(key string, port int32) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if !a.portRange.Contains(port) {
+		return fmt.Errorf("port %d is not part of the range %s", port, a.portRange)
+	}
+	if owner, ok := a.ports[port]; ok {
+		if owner == key {
+			return nil
+		}
+		return fmt.Errorf("port %d: %w", port, ErrConflict)
+	}
+	if previousPort, ok := a.keys[key]; ok {
+		delete(a.ports, previousPort)
+	}
+	a.ports[port] = key
+	a.keys[key] = port
+	return nil
+}
+
+// Lookup returns the port allocated to the given key. The second return value is false if the key does not have a
+// port.
+func (a *Allocator) Lookup(key string) (int32, bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	port, ok := a.keys[key]
+	return port, ok
+}
+
+// Release frees the port allocated to the given key. Releasing a key without port is a no-op.
+func (a *Allocator) Release(key string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	port, ok := a.keys[key]
+	if !ok {
+		return
+	}
+	delete(a.ports, port)
+	delete(a.keys, key)
+}
+
+// Retain frees the ports of all keys which are not part of the given keys. This cleans up allocations whose owner
+// disappeared without releasing its port.
+func (a *Allocator) Retain(keys map[string]bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for key, port := range a.keys {
+		if keys[key] {
+			continue
+		}
+		delete(a.ports, port)
+		delete(a.keys, key)
+	}
+}
+
+// Len returns the number of allocated ports.
+func (a *Allocator) Len() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return len(a.keys)
+}
@@ -0,0 +1,104 @@
+package portallocator_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/internal/portallocator"
+)
+
+var _ = Describe("ParseRange", func() {
+	It("should parse a range", func() {
+		portRange, err := portallocator.ParseRange("31000-31999")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(portRange).To(Equal(portallocator.Range{Min: 31000, Max: 31999}))
+		Expect(portRange.Size()).To(Equal(1000))
+		Expect(portRange.String()).To(Equal("31000-31999"))
+	})
+
+	It("should parse a single port", func() {
+		portRange, err := portallocator.ParseRange("31337")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(portRange).To(Equal(portallocator.Range{Min: 31337, Max: 31337}))
+	})
+
+	It("should reject invalid ranges", func() {
+		for _, value := range []string{"", "abc", "31000-", "0-10", "1-65536", "32000-31000"} {
+			_, err := portallocator.ParseRange(value)
+			Expect(err).To(HaveOccurred(), value)
+		}
+	})
+})
+
+var _ = Describe("Allocator", func() {
+	It("should allocate unique ports", func() {
+		allocator := portallocator.New(portallocator.Range{Min: 31000, Max: 31002})
+
+		first, err := allocator.Allocate("a")
+		Expect(err).ToNot(HaveOccurred())
+		second, err := allocator.Allocate("b")
+		Expect(err).ToNot(HaveOccurred())
+		third, err := allocator.Allocate("c")
+		Expect(err).ToNot(HaveOccurred())
+		Expect([]int32{first, second, third}).To(ConsistOf(int32(31000), int32(31001), int32(31002)))
+
+		_, err = allocator.Allocate("d")
+		Expect(err).To(MatchError(portallocator.ErrExhausted))
+	})
+
+	It("should return the same port for the same key", func() {
+		allocator := portallocator.New(portallocator.Range{Min: 31000, Max: 31999})
+
+		first, err := allocator.Allocate("a")
+		Expect(err).ToNot(HaveOccurred())
+		second, err := allocator.Allocate("a")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(second).To(Equal(first))
+		Expect(allocator.Len()).To(Equal(1))
+	})
+
+	It("should hand out released ports again", func() {
+		allocator := portallocator.New(portallocator.Range{Min: 31000, Max: 31000})
+
+		port, err := allocator.Allocate("a")
+		Expect(err).ToNot(HaveOccurred())
+		allocator.Release("a")
+		_, ok := allocator.Lookup("a")
+		Expect(ok).To(BeFalse())
+
+		reallocated, err := allocator.Allocate("b")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reallocated).To(Equal(port))
+	})
+
+	It("should not hand out restored ports", func() {
+		allocator := portallocator.New(portallocator.Range{Min: 31000, Max: 31001})
+		Expect(allocator.Restore("a", 31000)).To(Succeed())
+		Expect(allocator.Restore("a", 31000)).To(Succeed())
+
+		port, err := allocator.Allocate("b")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(port).To(Equal(int32(31001)))
+	})
+
+	It("should reject conflicting restores", func() {
+		allocator := portallocator.New(portallocator.Range{Min: 31000, Max: 31001})
+		Expect(allocator.Restore("a", 31000)).To(Succeed())
+		Expect(allocator.Restore("b", 31000)).To(MatchError(portallocator.ErrConflict))
+		Expect(allocator.Restore("b", 32000)).ToNot(Succeed())
+	})
+
+	It("should release ports of keys which are not retained", func() {
+		allocator := portallocator.New(portallocator.Range{Min: 31000, Max: 31999})
+		_, err := allocator.Allocate("a")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = allocator.Allocate("b")
+		Expect(err).ToNot(HaveOccurred())
+
+		allocator.Retain(map[string]bool{"b": true})
+		_, ok := allocator.Lookup("a")
+		Expect(ok).To(BeFalse())
+		_, ok = allocator.Lookup("b")
+		Expect(ok).To(BeTrue())
+	})
+})
@@ -0,0 +1,13 @@
+package portallocator_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPortAllocator(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Port Allocator Suite")
+}
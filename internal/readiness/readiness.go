@@ -0,0 +1,300 @@
+// Package readiness evaluates if Kubernetes objects reached their desired state. The rules follow the ideas of kstatus:
+// built-in workload kinds are evaluated with rules specific to their kind, while all other kinds fall back to
+// evaluating their status conditions.
+package readiness
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Status is the readiness of a single Kubernetes object.
+type Status string
+
+const (
+	// StatusCurrent means that the object reached its desired state.
+	StatusCurrent Status = "Current"
+
+	// StatusInProgress means that the object is still working towards its desired state.
+	StatusInProgress Status = "InProgress"
+
+	// StatusFailed means that the object failed to reach its desired state.
+	StatusFailed Status = "Failed"
+)
+
+// Result is the outcome of evaluating the readiness of a single Kubernetes object.
+type Result struct {
+	Status  Status
+	Message string
+}
+
+// evaluateFunc evaluates the readiness of an object of a specific kind.
+type evaluateFunc func(obj *unstructured.Unstructured) (Result, error)
+
+var evaluateFuncs = map[schema.GroupKind]evaluateFunc{
+	{Group: appsv1.GroupName, Kind: "Deployment"}:            evaluateDeployment,
+	{Group: appsv1.GroupName, Kind: "StatefulSet"}:           evaluateStatefulSet,
+	{Group: appsv1.GroupName, Kind: "DaemonSet"}:             evaluateDaemonSet,
+	{Group: appsv1.GroupName, Kind: "ReplicaSet"}:            evaluateReplicaSet,
+	{Group: batchv1.GroupName, Kind: "Job"}:                  evaluateJob,
+	{Group: corev1.GroupName, Kind: "Pod"}:                   evaluatePod,
+	{Group: corev1.GroupName, Kind: "PersistentVolumeClaim"}: evaluatePersistentVolumeClaim,
+	{Group: corev1.GroupName, Kind: "Service"}:               evaluateService,
+}
+
+// Evaluate returns the readiness of the given object.
+func Evaluate(obj *unstructured.Unstructured) (Result, error) {
+	if result, ok := evaluateGeneration(obj); !ok {
+		return result, nil
+	}
+	if evaluate, ok := evaluateFuncs[obj.GroupVersionKind().GroupKind()]; ok {
+		return evaluate(obj)
+	}
+	return evaluateGeneric(obj)
+}
+
+// evaluateGeneration checks if the controller responsible for the object already observed the latest generation of
+// the object. It returns false when this is not the case.
+func evaluateGeneration(obj *unstructured.Unstructured) (Result, bool) {
+	observedGeneration, found, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if err != nil || !found {
+		return Result{}, true
+	}
+	if observedGeneration < obj.GetGeneration() {
+		return Result{
+			Status:  StatusInProgress,
+			Message: fmt.Sprintf("generation %d is not yet observed", obj.GetGeneration()),
+		}, false
+	}
+	return Result{}, true
+}
+
+func evaluateDeployment(obj *unstructured.Unstructured) (Result, error) {
+	var deployment appsv1.Deployment
+	if err := fromUnstructured(obj, &deployment); err != nil {
+		return Result{}, err
+	}
+
+	progressing := findDeploymentCondition(deployment.Status.Conditions, appsv1.DeploymentProgressing)
+	if progressing != nil && progressing.Reason == "ProgressDeadlineExceeded" {
+		return failed("progress deadline exceeded: %s", progressing.Message), nil
+	}
+
+	replicas := replicasOrDefault(deployment.Spec.Replicas)
+	if deployment.Status.UpdatedReplicas < replicas {
+		return inProgress("%d of %d replicas are updated", deployment.Status.UpdatedReplicas, replicas), nil
+	}
+	if deployment.Status.Replicas > deployment.Status.UpdatedReplicas {
+		return inProgress("%d old replicas are pending termination", deployment.Status.Replicas-deployment.Status.UpdatedReplicas), nil
+	}
+	if deployment.Status.AvailableReplicas < replicas {
+		return inProgress("%d of %d replicas are available", deployment.Status.AvailableReplicas, replicas), nil
+	}
+	return current("%d replicas are available", replicas), nil
+}
+
+func findDeploymentCondition(conditions []appsv1.DeploymentCondition, conditionType appsv1.DeploymentConditionType) *appsv1.DeploymentCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+func evaluateStatefulSet(obj *unstructured.Unstructured) (Result, error) {
+	var statefulSet appsv1.StatefulSet
+	if err := fromUnstructured(obj, &statefulSet); err != nil {
+		return Result{}, err
+	}
+
+	replicas := replicasOrDefault(statefulSet.Spec.Replicas)
+	if statefulSet.Status.ReadyReplicas < replicas {
+		return inProgress("%d of %d replicas are ready", statefulSet.Status.ReadyReplicas, replicas), nil
+	}
+	if statefulSet.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		// With the OnDelete strategy pods are not updated automatically, so there is nothing to wait for.
+		return current("%d replicas are ready", replicas), nil
+	}
+	if statefulSet.Status.UpdatedReplicas < replicas {
+		return inProgress("%d of %d replicas are updated", statefulSet.Status.UpdatedReplicas, replicas), nil
+	}
+	if statefulSet.Status.UpdateRevision != "" && statefulSet.Status.CurrentRevision != statefulSet.Status.UpdateRevision {
+		return inProgress("waiting for revision %s to be rolled out", statefulSet.Status.UpdateRevision), nil
+	}
+	return current("%d replicas are ready", replicas), nil
+}
+
+func evaluateDaemonSet(obj *unstructured.Unstructured) (Result, error) {
+	var daemonSet appsv1.DaemonSet
+	if err := fromUnstructured(obj, &daemonSet); err != nil {
+		return Result{}, err
+	}
+
+	desired := daemonSet.Status.DesiredNumberScheduled
+	if daemonSet.Status.UpdatedNumberScheduled < desired {
+		return inProgress("%d of %d pods are updated", daemonSet.Status.UpdatedNumberScheduled, desired), nil
+	}
+	if daemonSet.Status.NumberAvailable < desired {
+		return inProgress("%d of %d pods are available", daemonSet.Status.NumberAvailable, desired), nil
+	}
+	return current("%d pods are available", desired), nil
+}
+
+func evaluateReplicaSet(obj *unstructured.Unstructured) (Result, error) {
+	var replicaSet appsv1.ReplicaSet
+	if err := fromUnstructured(obj, &replicaSet); err != nil {
+		return Result{}, err
+	}
+
+	replicas := replicasOrDefault(replicaSet.Spec.Replicas)
+	if replicaSet.Status.AvailableReplicas < replicas {
+		return inProgress("%d of %d replicas are available", replicaSet.Status.AvailableReplicas, replicas), nil
+	}
+	return current("%d replicas are available", replicas), nil
+}
+
+func evaluateJob(obj *unstructured.Unstructured) (Result, error) {
+	var job batchv1.Job
+	if err := fromUnstructured(obj, &job); err != nil {
+		return Result{}, err
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type { //nolint:exhaustive // We are only interested in terminal conditions.
+		case batchv1.JobFailed:
+			return failed("job failed: %s", condition.Message), nil
+		case batchv1.JobComplete:
+			return current("job completed"), nil
+		}
+	}
+	return inProgress("job is not yet completed: %d active, %d succeeded", job.Status.Active, job.Status.Succeeded), nil
+}
+
+func evaluatePod(obj *unstructured.Unstructured) (Result, error) {
+	var pod corev1.Pod
+	if err := fromUnstructured(obj, &pod); err != nil {
+		return Result{}, err
+	}
+
+	switch pod.Status.Phase { //nolint:exhaustive // All other phases are handled below.
+	case corev1.PodSucceeded:
+		return current("pod succeeded"), nil
+	case corev1.PodFailed:
+		return failed("pod failed: %s", pod.Status.Message), nil
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+			return current("pod is ready"), nil
+		}
+	}
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.State.Waiting != nil && containerStatus.State.Waiting.Reason != "" {
+			return inProgress("container %s is waiting: %s", containerStatus.Name, containerStatus.State.Waiting.Reason), nil
+		}
+	}
+	return inProgress("pod is not yet ready"), nil
+}
+
+func evaluatePersistentVolumeClaim(obj *unstructured.Unstructured) (Result, error) {
+	var persistentVolumeClaim corev1.PersistentVolumeClaim
+	if err := fromUnstructured(obj, &persistentVolumeClaim); err != nil {
+		return Result{}, err
+	}
+
+	switch persistentVolumeClaim.Status.Phase { //nolint:exhaustive // All other phases are handled below.
+	case corev1.ClaimBound:
+		return current("claim is bound"), nil
+	case corev1.ClaimLost:
+		return failed("claim lost its volume"), nil
+	}
+	return inProgress("claim is not yet bound"), nil
+}
+
+func evaluateService(obj *unstructured.Unstructured) (Result, error) {
+	var service corev1.Service
+	if err := fromUnstructured(obj, &service); err != nil {
+		return Result{}, err
+	}
+
+	if service.Spec.Type == corev1.ServiceTypeLoadBalancer && len(service.Status.LoadBalancer.Ingress) == 0 {
+		return inProgress("load balancer is not yet provisioned"), nil
+	}
+	return current("service is ready"), nil
+}
+
+// evaluateGeneric evaluates objects of kinds without specific rules. It uses the conventions of status conditions:
+// Stalled signals a failure, Reconciling signals progress and Ready signals readiness. Objects without any of those
+// conditions are considered to be current.
+func evaluateGeneric(obj *unstructured.Unstructured) (Result, error) {
+	rawConditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil || !found {
+		return current("no status conditions"), nil //nolint:nilerr // Objects without valid conditions are current.
+	}
+
+	var conditions []metav1.Condition
+	for _, rawCondition := range rawConditions {
+		conditionMap, ok := rawCondition.(map[string]any)
+		if !ok {
+			continue
+		}
+		var condition metav1.Condition
+		condition.Type, _, _ = unstructured.NestedString(conditionMap, "type")
+		status, _, _ := unstructured.NestedString(conditionMap, "status")
+		condition.Status = metav1.ConditionStatus(status)
+		condition.Message, _, _ = unstructured.NestedString(conditionMap, "message")
+		conditions = append(conditions, condition)
+	}
+
+	if condition := meta.FindStatusCondition(conditions, "Stalled"); condition != nil && condition.Status == metav1.ConditionTrue {
+		return failed("stalled: %s", condition.Message), nil
+	}
+	if condition := meta.FindStatusCondition(conditions, "Reconciling"); condition != nil && condition.Status == metav1.ConditionTrue {
+		return inProgress("reconciling: %s", condition.Message), nil
+	}
+	if condition := meta.FindStatusCondition(conditions, "Ready"); condition != nil {
+		if condition.Status == metav1.ConditionTrue {
+			return current("ready"), nil
+		}
+		return inProgress("not ready: %s", condition.Message), nil
+	}
+	return current("no readiness conditions"), nil
+}
+
+func fromUnstructured(obj *unstructured.Unstructured, target any) error {
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, target); err != nil {
+		return fmt.Errorf("converting %s %s from unstructured: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return nil
+}
+
+func replicasOrDefault(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+func current(format string, args ...any) Result {
+	return Result{Status: StatusCurrent, Message: fmt.Sprintf(format, args...)}
+}
+
+func inProgress(format string, args ...any) Result {
+	return Result{Status: StatusInProgress, Message: fmt.Sprintf(format, args...)}
+}
+
+func failed(format string, args ...any) Result {
+	return Result{Status: StatusFailed, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,225 @@
+package readiness_test
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/internal/readiness"
+)
+
+var _ = Describe("Evaluate", func() {
+	DescribeTable("built-in kinds",
+		func(obj runtime.Object, expectedStatus readiness.Status) {
+			result, err := readiness.Evaluate(toUnstructured(obj))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Status).To(Equal(expectedStatus))
+			Expect(result.Message).ToNot(BeEmpty())
+		},
+		Entry("deployment with all replicas available",
+			&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				Spec:     appsv1.DeploymentSpec{Replicas: ptr.To[int32](2)},
+				Status:   appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+			},
+			readiness.StatusCurrent,
+		),
+		Entry("deployment with missing replicas",
+			&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				Spec:     appsv1.DeploymentSpec{Replicas: ptr.To[int32](2)},
+				Status:   appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1},
+			},
+			readiness.StatusInProgress,
+		),
+		Entry("deployment with unobserved generation",
+			&appsv1.Deployment{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+			},
+			readiness.StatusInProgress,
+		),
+		Entry("deployment with exceeded progress deadline",
+			&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				Status: appsv1.DeploymentStatus{
+					Conditions: []appsv1.DeploymentCondition{
+						{
+							Type:   appsv1.DeploymentProgressing,
+							Status: corev1.ConditionFalse,
+							Reason: "ProgressDeadlineExceeded",
+						},
+					},
+				},
+			},
+			readiness.StatusFailed,
+		),
+		Entry("statefulset with all replicas ready",
+			&appsv1.StatefulSet{
+				TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"},
+				Status: appsv1.StatefulSetStatus{
+					ReadyReplicas:   1,
+					UpdatedReplicas: 1,
+					CurrentRevision: "rev-1",
+					UpdateRevision:  "rev-1",
+				},
+			},
+			readiness.StatusCurrent,
+		),
+		Entry("statefulset with pending rollout",
+			&appsv1.StatefulSet{
+				TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"},
+				Status: appsv1.StatefulSetStatus{
+					ReadyReplicas:   1,
+					UpdatedReplicas: 1,
+					CurrentRevision: "rev-1",
+					UpdateRevision:  "rev-2",
+				},
+			},
+			readiness.StatusInProgress,
+		),
+		Entry("daemonset with all pods available",
+			&appsv1.DaemonSet{
+				TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "DaemonSet"},
+				Status:   appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3},
+			},
+			readiness.StatusCurrent,
+		),
+		Entry("daemonset with missing pods",
+			&appsv1.DaemonSet{
+				TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "DaemonSet"},
+				Status:   appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 2},
+			},
+			readiness.StatusInProgress,
+		),
+		Entry("completed job",
+			&batchv1.Job{
+				TypeMeta: metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+				Status: batchv1.JobStatus{
+					Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+				},
+			},
+			readiness.StatusCurrent,
+		),
+		Entry("failed job",
+			&batchv1.Job{
+				TypeMeta: metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+				Status: batchv1.JobStatus{
+					Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}},
+				},
+			},
+			readiness.StatusFailed,
+		),
+		Entry("running job",
+			&batchv1.Job{
+				TypeMeta: metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+				Status:   batchv1.JobStatus{Active: 1},
+			},
+			readiness.StatusInProgress,
+		),
+		Entry("ready pod",
+			&corev1.Pod{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+				Status: corev1.PodStatus{
+					Phase:      corev1.PodRunning,
+					Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+				},
+			},
+			readiness.StatusCurrent,
+		),
+		Entry("pod pulling its image",
+			&corev1.Pod{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodPending,
+					ContainerStatuses: []corev1.ContainerStatus{
+						{
+							Name: "app",
+							State: corev1.ContainerState{
+								Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"},
+							},
+						},
+					},
+				},
+			},
+			readiness.StatusInProgress,
+		),
+		Entry("failed pod",
+			&corev1.Pod{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+				Status:   corev1.PodStatus{Phase: corev1.PodFailed},
+			},
+			readiness.StatusFailed,
+		),
+		Entry("bound persistent volume claim",
+			&corev1.PersistentVolumeClaim{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+				Status:   corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+			},
+			readiness.StatusCurrent,
+		),
+		Entry("cluster ip service",
+			&corev1.Service{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+				Spec:     corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP},
+			},
+			readiness.StatusCurrent,
+		),
+		Entry("load balancer service without ingress",
+			&corev1.Service{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+				Spec:     corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			},
+			readiness.StatusInProgress,
+		),
+		Entry("config map",
+			&corev1.ConfigMap{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			},
+			readiness.StatusCurrent,
+		),
+	)
+
+	DescribeTable("custom resources",
+		func(conditions []any, expectedStatus readiness.Status) {
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion("example.com/v1")
+			obj.SetKind("Example")
+			if conditions != nil {
+				Expect(unstructured.SetNestedSlice(obj.Object, conditions, "status", "conditions")).To(Succeed())
+			}
+
+			result, err := readiness.Evaluate(obj)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Status).To(Equal(expectedStatus))
+		},
+		Entry("without conditions", nil, readiness.StatusCurrent),
+		Entry("with ready condition", []any{
+			map[string]any{"type": "Ready", "status": "True"},
+		}, readiness.StatusCurrent),
+		Entry("with not ready condition", []any{
+			map[string]any{"type": "Ready", "status": "False"},
+		}, readiness.StatusInProgress),
+		Entry("with reconciling condition", []any{
+			map[string]any{"type": "Reconciling", "status": "True"},
+			map[string]any{"type": "Ready", "status": "True"},
+		}, readiness.StatusInProgress),
+		Entry("with stalled condition", []any{
+			map[string]any{"type": "Stalled", "status": "True"},
+		}, readiness.StatusFailed),
+	)
+})
+
+func toUnstructured(obj runtime.Object) *unstructured.Unstructured {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	Expect(err).ToNot(HaveOccurred())
+	return &unstructured.Unstructured{Object: content}
+}
@@ -0,0 +1,210 @@
+// Package rendering renders the manifests of a challenge description as Go templates for a specific challenge
+// instance.
+//
+// Rendering is applied to every string value of a decoded manifest. Keys and values of other types are left untouched.
+// This keeps the manifest structurally valid no matter which characters the rendered values contain.
+package rendering
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Data is the data context which is available to the templates.
+type Data struct {
+	// Instance provides details about the challenge instance the manifests are rendered for.
+	Instance Instance
+
+	// Description provides details about the challenge description the manifests are taken from.
+	Description Description
+
+	// Namespace is the namespace the manifests are placed in.
+	Namespace string
+
+	// Flag is the flag of the challenge instance.
+	Flag string
+
+	// ExpirationTimestamp is the point in time the challenge instance expires.
+	ExpirationTimestamp time.Time
+
+	// Seed is a random value which is stable for the lifetime of the challenge instance. It consists of 16 lowercase
+	// hex characters and can be used for unique host names or passwords.
+	Seed string
+}
+
+// Instance provides details about the challenge instance.
+type Instance struct {
+	// Name is the name of the challenge instance.
+	Name string
+
+	// Namespace is the namespace of the challenge instance resource itself.
+	Namespace string
+
+	// UID is the UID of the challenge instance.
+	UID string
+
+	// Owner is the owner of the challenge instance. It is empty when no owner is recorded.
+	Owner string
+
+	// Labels are the labels of the challenge instance.
+	Labels map[string]string
+
+	// Annotations are the annotations of the challenge instance.
+	Annotations map[string]string
+}
+
+// Description provides details about the challenge description.
+type Description struct {
+	// Name is the name of the challenge description.
+	Name string
+
+	// Title is the title of the challenge.
+	Title string
+
+	// Category is the category of the challenge.
+	Category string
+
+	// Value is the number of points for solving the challenge.
+	Value int
+}
+
+// ConnectionData is the data context which is available to the connection info template. It does not provide the
+// flag, because the connection info is shown to the players.
+type ConnectionData struct {
+	// Host is the host of the first endpoint. It is empty when no endpoint was discovered.
+	Host string
+
+	// Port is the port of the first endpoint. It is zero when no endpoint was discovered.
+	Port int32
+
+	// URL is the URL of the first endpoint. It is empty for endpoints which are not HTTP.
+	URL string
+
+	// Endpoints are all endpoints which were discovered for the challenge instance.
+	Endpoints []Endpoint
+
+	// Instance provides details about the challenge instance.
+	Instance Instance
+
+	// Description provides details about the challenge description.
+	Description Description
+
+	// Namespace is the namespace the workload of the challenge instance is placed in.
+	Namespace string
+}
+
+// Endpoint is an endpoint players can connect to.
+type Endpoint struct {
+	// Kind is the kind of the object the endpoint was discovered from.
+	Kind string
+
+	// Name is the name of the object the endpoint was discovered from.
+	Name string
+
+	// Host is the host name or IP address of the endpoint.
+	Host string
+
+	// Port is the port of the endpoint.
+	Port int32
+
+	// Protocol is the protocol of the endpoint.
+	Protocol string
+
+	// URL is the URL of HTTP endpoints.
+	URL string
+}
+
+// SeedFromUID returns a seed which is derived from the given UID. The same UID always results in the same seed.
+func SeedFromUID(uid string) string {
+	sum := sha256.Sum256([]byte(uid))
+	return hex.EncodeToString(sum[:8])
+}
+
+// funcMap provides a small set of helper functions in addition to the functions built into text/template.
+var funcMap = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix string, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix string, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old string, new string, s string) string { return strings.ReplaceAll(s, old, new) },
+	"b64enc":     func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"sha256sum": func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	},
+	"default": func(defaultValue string, value string) string {
+		if len(value) == 0 {
+			return defaultValue
+		}
+		return value
+	},
+}
+
+// Render renders all string values of the given object as templates with the given data context. The object is
+// modified in place.
+func Render(obj *unstructured.Unstructured, data Data) error {
+	rendered, err := renderValue(obj.Object, data)
+	if err != nil {
+		return err
+	}
+	obj.Object = rendered.(map[string]any) //nolint:forcetypeassert // renderValue preserves the type of maps.
+	return nil
+}
+
+// RenderConnectionInfo renders the given connection info template with the given data context.
+func RenderConnectionInfo(value string, data ConnectionData) (string, error) {
+	return renderString(value, data)
+}
+
+func renderValue(value any, data Data) (any, error) {
+	switch typedValue := value.(type) {
+	case map[string]any:
+		for key, item := range typedValue {
+			renderedItem, err := renderValue(item, data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			typedValue[key] = renderedItem
+		}
+		return typedValue, nil
+	case []any:
+		for i, item := range typedValue {
+			renderedItem, err := renderValue(item, data)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			typedValue[i] = renderedItem
+		}
+		return typedValue, nil
+	case string:
+		return renderString(typedValue, data)
+	default:
+		return value, nil
+	}
+}
+
+func renderString(value string, data any) (string, error) {
+	if !strings.Contains(value, "{{") {
+		// Most values do not contain any template. We skip parsing them.
+		return value, nil
+	}
+
+	tmpl, err := template.New("").Funcs(funcMap).Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("parsing template: %w", err)
+	}
+
+	var builder strings.Builder
+	if err := tmpl.Execute(&builder, data); err != nil {
+		return "", fmt.Errorf("executing template: %w", err)
+	}
+	return builder.String(), nil
+}
@@ -0,0 +1,134 @@
+package rendering_test
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/internal/rendering"
+)
+
+var _ = Describe("Render", func() {
+	var data rendering.Data
+
+	BeforeEach(func() {
+		data = rendering.Data{
+			Instance: rendering.Instance{
+				Name: "test-instance",
+				UID:  "1234",
+			},
+			Description: rendering.Description{
+				Title: "Test Challenge",
+			},
+			Namespace: "test-instance",
+			Flag:      `CTF{"quoted"}`,
+			Seed:      rendering.SeedFromUID("1234"),
+		}
+	})
+
+	It("should render nested string values", func() {
+		obj := unstructured.Unstructured{
+			Object: map[string]any{
+				"metadata": map[string]any{
+					"name": "web-{{ .Instance.Name }}",
+					"labels": map[string]any{
+						"title": "{{ .Description.Title | lower | replace \" \" \"-\" }}",
+					},
+				},
+				"spec": map[string]any{
+					"replicas": int64(1),
+					"env": []any{
+						map[string]any{
+							"name":  "FLAG",
+							"value": "{{ .Flag }}",
+						},
+					},
+				},
+			},
+		}
+		Expect(rendering.Render(&obj, data)).To(Succeed())
+		Expect(obj.GetName()).To(Equal("web-test-instance"))
+		Expect(obj.GetLabels()).To(HaveKeyWithValue("title", "test-challenge"))
+		env, found, err := unstructured.NestedSlice(obj.Object, "spec", "env")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(env[0]).To(HaveKeyWithValue("value", `CTF{"quoted"}`))
+		replicas, _, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(replicas).To(Equal(int64(1)))
+	})
+
+	It("should fail on unknown fields", func() {
+		obj := unstructured.Unstructured{
+			Object: map[string]any{
+				"metadata": map[string]any{
+					"name": "{{ .Unknown }}",
+				},
+			},
+		}
+		Expect(rendering.Render(&obj, data)).ToNot(Succeed())
+	})
+
+	It("should fail on invalid templates", func() {
+		obj := unstructured.Unstructured{
+			Object: map[string]any{
+				"metadata": map[string]any{
+					"name": "{{ .Instance.Name",
+				},
+			},
+		}
+		Expect(rendering.Render(&obj, data)).ToNot(Succeed())
+	})
+
+	It("should derive a stable seed", func() {
+		Expect(rendering.SeedFromUID("1234")).To(Equal(rendering.SeedFromUID("1234")))
+		Expect(rendering.SeedFromUID("1234")).ToNot(Equal(rendering.SeedFromUID("5678")))
+		Expect(rendering.SeedFromUID("1234")).To(MatchRegexp(`^[0-9a-f]{16}$`))
+	})
+})
+
+var _ = Describe("RenderConnectionInfo", func() {
+	var data rendering.ConnectionData
+
+	BeforeEach(func() {
+		data = rendering.ConnectionData{
+			Host: "203.0.113.10",
+			Port: 31337,
+			Endpoints: []rendering.Endpoint{
+				{
+					Kind:     "Service",
+					Name:     "challenge",
+					Host:     "203.0.113.10",
+					Port:     31337,
+					Protocol: "TCP",
+				},
+				{
+					Kind:     "Ingress",
+					Name:     "challenge",
+					Host:     "challenge.example.com",
+					Port:     443,
+					Protocol: "HTTPS",
+					URL:      "https://challenge.example.com",
+				},
+			},
+		}
+	})
+
+	It("should render the first endpoint", func() {
+		connectionInfo, err := rendering.RenderConnectionInfo("nc {{ .Host }} {{ .Port }}", data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(connectionInfo).To(Equal("nc 203.0.113.10 31337"))
+	})
+
+	It("should render all endpoints", func() {
+		connectionInfo, err := rendering.RenderConnectionInfo("{{ range .Endpoints }}{{ .Kind }}={{ .Host }} {{ end }}", data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(connectionInfo).To(Equal("Service=203.0.113.10 Ingress=challenge.example.com "))
+	})
+
+	It("should not provide the flag", func() {
+		_, err := rendering.RenderConnectionInfo("{{ .Flag }}", data)
+		Expect(err).To(HaveOccurred())
+	})
+})
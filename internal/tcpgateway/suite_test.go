@@ -0,0 +1,13 @@
+package tcpgateway_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTCPGateway(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TCP Gateway Suite")
+}
@@ -0,0 +1,367 @@
+// Package tcpgateway implements a TCP gateway which routes players to the port exposure of their challenge instance.
+// This allows exposing any number of challenge instances with raw TCP protocols on a single port.
+//
+// Players connect either with TLS or with plain TCP. With TLS, the challenge instance is selected through the server
+// name indication of the TLS handshake, and the player sends its API key as first line within the TLS connection.
+// With plain TCP, the player sends the challenge instance and its API key separated by a space as first line. In both
+// cases, the challenge instance is identified by the name of its namespace, optionally followed by the domain of the
+// gateway. All data after the first line is forwarded to the challenge instance as is.
+package tcpgateway
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/access"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstance"
+)
+
+const (
+	// DefaultHandshakeTimeout is the time a player has to complete the handshake after connecting.
+	DefaultHandshakeTimeout = 10 * time.Second
+
+	// DefaultCheckInterval is the interval in which established connections are checked for challenge instances which
+	// were deleted or expired.
+	DefaultCheckInterval = 5 * time.Second
+
+	// maxLineLength is the maximum length of the first line sent by the player.
+	maxLineLength = 512
+
+	// tlsRecordTypeHandshake is the first byte sent by a client which starts a TLS handshake.
+	tlsRecordTypeHandshake = 0x16
+)
+
+// errAccessDenied is reported to players for unknown challenge instances and invalid API keys alike, so that players
+// can not probe for challenge instances of others.
+var errAccessDenied = errors.New("access denied")
+
+// Options configure the TCP gateway.
+type Options struct {
+	// BindAddress is the address the gateway listens on, for example ":7000".
+	BindAddress string
+
+	// Domain is the domain of the host names the challenge instances are reachable under. The host name of a challenge
+	// instance is the name of its namespace followed by the domain.
+	Domain string
+
+	// CertDir is the directory which contains the certificate tls.crt and the private key tls.key. The certificate
+	// should be a wildcard certificate for the domain. Without a directory, only plain TCP is accepted.
+	CertDir string
+
+	// HandshakeTimeout is the time a player has to complete the handshake.
+	HandshakeTimeout time.Duration
+
+	// CheckInterval is the interval in which established connections are checked.
+	CheckInterval time.Duration
+}
+
+// Gateway accepts connections from players and forwards them to the challenge instance the player asked for, once the
+// player is authorized.
+type Gateway struct {
+	client    client.Client
+	logger    logr.Logger
+	options   Options
+	tlsConfig *tls.Config
+	dialer    net.Dialer
+}
+
+// New returns a new gateway with the given options. The certificate is loaded from the certificate directory if one
+// is configured.
+func New(client client.Client, logger logr.Logger, options Options) (*Gateway, error) {
+	if options.HandshakeTimeout == 0 {
+		options.HandshakeTimeout = DefaultHandshakeTimeout
+	}
+	if options.CheckInterval == 0 {
+		options.CheckInterval = DefaultCheckInterval
+	}
+	result := &Gateway{
+		client:  client,
+		logger:  logger,
+		options: options,
+	}
+	if len(options.CertDir) != 0 {
+		certificate, err := tls.LoadX509KeyPair(
+			filepath.Join(options.CertDir, "tls.crt"),
+			filepath.Join(options.CertDir, "tls.key"),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("loading TCP gateway certificate: %w", err)
+		}
+		result.tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+	return result, nil
+}
+
+// NeedLeaderElection returns false, because every replica of the operator can serve players.
+func (g *Gateway) NeedLeaderElection() bool {
+	return false
+}
+
+// Start listens on the bind address and serves players until the context is done.
+func (g *Gateway) Start(ctx context.Context) error {
+	var listenConfig net.ListenConfig
+	listener, err := listenConfig.Listen(ctx, "tcp", g.options.BindAddress)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", g.options.BindAddress, err)
+	}
+	g.logger.Info("TCP gateway listening", "address", listener.Addr().String())
+	return g.Serve(ctx, listener)
+}
+
+// Serve accepts connections from the given listener until the context is done. The listener is closed afterwards.
+func (g *Gateway) Serve(ctx context.Context, listener net.Listener) error {
+	var waitGroup sync.WaitGroup
+	defer waitGroup.Wait()
+
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			g.handle(ctx, conn)
+		}()
+	}
+}
+
+// handle authorizes a single player connection and forwards it to the challenge instance.
+func (g *Gateway) handle(ctx context.Context, conn net.Conn) {
+	logger := g.logger.WithValues("remote-address", conn.RemoteAddr().String())
+	defer conn.Close() //nolint:errcheck // The connection is done, there is nothing left to do about errors.
+
+	if err := conn.SetDeadline(time.Now().Add(g.options.HandshakeTimeout)); err != nil {
+		return
+	}
+	playerConn, namespace, key, err := g.handshake(ctx, conn)
+	if err != nil {
+		logger.V(1).Info("TCP gateway handshake failed", "error", err.Error())
+		return
+	}
+	logger = logger.WithValues("namespace", namespace)
+
+	challengeInstance, backendAddress, err := g.authorize(ctx, namespace, key)
+	if err != nil {
+		logger.Info("TCP gateway denied connection", "error", err.Error())
+		g.reject(playerConn, err)
+		return
+	}
+	logger = logger.WithValues("challenge-instance", client.ObjectKeyFromObject(challengeInstance))
+
+	backendConn, err := g.dialer.DialContext(ctx, "tcp", backendAddress)
+	if err != nil {
+		logger.Info("TCP gateway failed to connect to challenge instance", "error", err.Error())
+		g.reject(playerConn, errors.New("the challenge instance is not reachable"))
+		return
+	}
+	defer backendConn.Close() //nolint:errcheck // The connection is done, there is nothing left to do about errors.
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return
+	}
+	logger.V(1).Info("TCP gateway forwarding connection")
+	g.forward(ctx, playerConn, backendConn, challengeInstance)
+	logger.V(1).Info("TCP gateway closed connection")
+}
+
+// handshake reads the challenge instance and the API key from the player. It returns the connection all further data
+// is to be read from, which is the TLS connection for TLS and the buffered plain connection otherwise.
+func (g *Gateway) handshake(ctx context.Context, conn net.Conn) (net.Conn, string, string, error) {
+	reader := bufio.NewReader(conn)
+	firstByte, err := reader.Peek(1)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	if firstByte[0] == tlsRecordTypeHandshake && g.tlsConfig != nil {
+		tlsConn := tls.Server(&bufferedConn{Conn: conn, reader: reader}, g.tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, "", "", fmt.Errorf("TLS handshake: %w", err)
+		}
+		namespace, ok := access.ParseHost(tlsConn.ConnectionState().ServerName, g.options.Domain)
+		if !ok {
+			return nil, "", "", fmt.Errorf("unknown server name %q", tlsConn.ConnectionState().ServerName)
+		}
+		tlsReader := bufio.NewReader(tlsConn)
+		line, err := readLine(tlsReader)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return &bufferedConn{Conn: tlsConn, reader: tlsReader}, namespace, line, nil
+	}
+
+	line, err := readLine(reader)
+	if err != nil {
+		return nil, "", "", err
+	}
+	host, key, found := strings.Cut(line, " ")
+	if !found {
+		return nil, "", "", errors.New("the first line must contain the challenge instance and the API key")
+	}
+	namespace, ok := access.ParseHost(host, g.options.Domain)
+	if !ok {
+		return nil, "", "", fmt.Errorf("unknown host %q", host)
+	}
+	return &bufferedConn{Conn: conn, reader: reader}, namespace, strings.TrimSpace(key), nil
+}
+
+// authorize returns the challenge instance in the given namespace and the address of its backend service, if the given
+// API key may access the challenge instance.
+func (g *Gateway) authorize(ctx context.Context, namespace string, key string) (*v1alpha1.ChallengeInstance, string, error) {
+	challengeInstance, err := access.FindChallengeInstance(ctx, g.client, namespace)
+	if err != nil {
+		if errors.Is(err, access.ErrUnknownInstance) {
+			return nil, "", errAccessDenied
+		}
+		return nil, "", err
+	}
+	apiKey, err := access.FindAPIKey(ctx, g.client, challengeInstance.Namespace, key)
+	if err != nil {
+		if errors.Is(err, access.ErrInvalidAPIKey) {
+			return nil, "", errAccessDenied
+		}
+		return nil, "", err
+	}
+	if err := access.Authorize(challengeInstance, apiKey); err != nil {
+		return nil, "", errAccessDenied
+	}
+	if !access.IsActive(challengeInstance, time.Now()) {
+		return nil, "", access.ErrInactive
+	}
+
+	backendAddress, err := g.getBackendAddress(ctx, namespace)
+	if err != nil {
+		return nil, "", err
+	}
+	return challengeInstance, backendAddress, nil
+}
+
+// getBackendAddress returns the cluster internal address of the service of the port exposure in the given namespace.
+func (g *Gateway) getBackendAddress(ctx context.Context, namespace string) (string, error) {
+	var service corev1.Service
+	if err := g.client.Get(ctx, client.ObjectKey{
+		Namespace: namespace,
+		Name:      challengeinstance.PortServiceName,
+	}, &service); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", errors.New("the challenge instance does not expose a port")
+		}
+		return "", err
+	}
+	if len(service.Spec.ClusterIP) == 0 || service.Spec.ClusterIP == corev1.ClusterIPNone || len(service.Spec.Ports) == 0 {
+		return "", errors.New("the challenge instance does not expose a port")
+	}
+	return net.JoinHostPort(service.Spec.ClusterIP, strconv.Itoa(int(service.Spec.Ports[0].Port))), nil
+}
+
+// reject tells the player why the connection is closed.
+func (g *Gateway) reject(conn net.Conn, err error) {
+	_, _ = fmt.Fprintf(conn, "ERROR: %s\n", err)
+}
+
+// forward copies data between the player and the challenge instance until one side closes the connection or the
+// challenge instance is deleted or expires.
+func (g *Gateway) forward(ctx context.Context, playerConn net.Conn, backendConn net.Conn, challengeInstance *v1alpha1.ChallengeInstance) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		_, _ = io.Copy(backendConn, playerConn)
+		cancel()
+	}()
+	go func() {
+		_, _ = io.Copy(playerConn, backendConn)
+		cancel()
+	}()
+	go g.watchChallengeInstance(ctx, cancel, client.ObjectKeyFromObject(challengeInstance))
+
+	<-ctx.Done()
+	// Closing both connections unblocks the copy operations above.
+	_ = playerConn.Close()
+	_ = backendConn.Close()
+}
+
+// watchChallengeInstance cancels the context when the challenge instance is deleted or expires.
+func (g *Gateway) watchChallengeInstance(ctx context.Context, cancel context.CancelFunc, key client.ObjectKey) {
+	ticker := time.NewTicker(g.options.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var challengeInstance v1alpha1.ChallengeInstance
+		if err := g.client.Get(ctx, key, &challengeInstance); err != nil {
+			if apierrors.IsNotFound(err) {
+				cancel()
+				return
+			}
+			// Other errors are most likely temporary. We keep the connection and check again later.
+			continue
+		}
+		if !access.IsActive(&challengeInstance, time.Now()) {
+			cancel()
+			return
+		}
+	}
+}
+
+// readLine reads a single line terminated by a line feed. A trailing carriage return is removed.
+func readLine(reader *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		fragment, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		line = append(line, fragment...)
+		if len(line) > maxLineLength {
+			return "", errors.New("the first line is too long")
+		}
+		if !isPrefix {
+			return string(line), nil
+		}
+	}
+}
+
+// bufferedConn is a connection whose reads are served from a buffered reader. This keeps data which was read ahead
+// during the handshake.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
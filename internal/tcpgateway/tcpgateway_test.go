@@ -0,0 +1,215 @@
+package tcpgateway_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/access"
+	"github.com/backbone81/ctf-challenge-operator/internal/controller/challengeinstance"
+	"github.com/backbone81/ctf-challenge-operator/internal/tcpgateway"
+)
+
+var _ = Describe("Gateway", func() {
+	var k8sClient client.Client
+	var gatewayAddress string
+	var cancel context.CancelFunc
+
+	BeforeEach(func() {
+		backendAddress := startEchoServer()
+		host, port, err := net.SplitHostPort(backendAddress)
+		Expect(err).ToNot(HaveOccurred())
+		var backendPort int32
+		_, err = fmt.Sscanf(port, "%d", &backendPort)
+		Expect(err).ToNot(HaveOccurred())
+
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+		k8sClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithIndex(&v1alpha1.ChallengeInstance{}, access.StatusNamespaceField, access.IndexStatusNamespace).
+			WithObjects(
+				&v1alpha1.APIKey{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "default",
+						Name:      "team-a",
+					},
+					Spec: v1alpha1.APIKeySpec{
+						Owner: "team-a",
+					},
+					Status: v1alpha1.APIKeyStatus{
+						Key:                 "key-a",
+						ExpirationTimestamp: metav1.NewTime(time.Now().Add(time.Hour)),
+					},
+				},
+				&v1alpha1.APIKey{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "default",
+						Name:      "team-b",
+					},
+					Spec: v1alpha1.APIKeySpec{
+						Owner: "team-b",
+					},
+					Status: v1alpha1.APIKeyStatus{
+						Key:                 "key-b",
+						ExpirationTimestamp: metav1.NewTime(time.Now().Add(time.Hour)),
+					},
+				},
+				&v1alpha1.ChallengeInstance{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "default",
+						Name:      "test",
+					},
+					Spec: v1alpha1.ChallengeInstanceSpec{
+						Owner: "team-a",
+					},
+					Status: v1alpha1.ChallengeInstanceStatus{
+						Namespace:           "ctf-instance-abc",
+						ExpirationTimestamp: metav1.NewTime(time.Now().Add(time.Hour)),
+					},
+				},
+				&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "ctf-instance-abc",
+						Name:      challengeinstance.PortServiceName,
+					},
+					Spec: corev1.ServiceSpec{
+						Type:      corev1.ServiceTypeClusterIP,
+						ClusterIP: host,
+						Ports: []corev1.ServicePort{
+							{
+								Port: backendPort,
+							},
+						},
+					},
+				},
+			).
+			Build()
+
+		gateway, err := tcpgateway.New(k8sClient, logr.Discard(), tcpgateway.Options{
+			Domain:        "ctf.example.com",
+			CheckInterval: 100 * time.Millisecond,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		gatewayAddress = listener.Addr().String()
+
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			defer GinkgoRecover()
+			Expect(gateway.Serve(ctx, listener)).To(Succeed())
+		}()
+		DeferCleanup(func() {
+			cancel()
+			Eventually(done).Should(BeClosed())
+		})
+	})
+
+	It("should forward players with a valid API key", func() {
+		conn := dial(gatewayAddress)
+		_, err := fmt.Fprintf(conn, "ctf-instance-abc.ctf.example.com key-a\nhello\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readLine(conn)).To(Equal("hello"))
+	})
+
+	It("should accept the namespace without domain", func() {
+		conn := dial(gatewayAddress)
+		_, err := fmt.Fprintf(conn, "ctf-instance-abc key-a\nhello\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readLine(conn)).To(Equal("hello"))
+	})
+
+	It("should reject API keys of other owners", func() {
+		conn := dial(gatewayAddress)
+		_, err := fmt.Fprintf(conn, "ctf-instance-abc key-b\nhello\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readLine(conn)).To(Equal("ERROR: access denied"))
+	})
+
+	It("should reject unknown API keys", func() {
+		conn := dial(gatewayAddress)
+		_, err := fmt.Fprintf(conn, "ctf-instance-abc key-x\nhello\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readLine(conn)).To(Equal("ERROR: access denied"))
+	})
+
+	It("should reject unknown challenge instances", func() {
+		conn := dial(gatewayAddress)
+		_, err := fmt.Fprintf(conn, "ctf-instance-xyz key-a\nhello\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readLine(conn)).To(Equal("ERROR: access denied"))
+	})
+
+	It("should close connections when the challenge instance is deleted", func(ctx SpecContext) {
+		conn := dial(gatewayAddress)
+		_, err := fmt.Fprintf(conn, "ctf-instance-abc key-a\nhello\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readLine(conn)).To(Equal("hello"))
+
+		Expect(k8sClient.Delete(ctx, &v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "test",
+			},
+		})).To(Succeed())
+		Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+		_, err = io.ReadAll(conn)
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
+
+// startEchoServer starts a TCP server which sends back everything it receives. It returns the address of the server.
+func startEchoServer() string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+	DeferCleanup(listener.Close)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func dial(address string) net.Conn {
+	conn, err := net.Dial("tcp", address)
+	Expect(err).ToNot(HaveOccurred())
+	DeferCleanup(conn.Close)
+	Expect(conn.SetDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+	return conn
+}
+
+func readLine(conn net.Conn) string {
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	Expect(err).ToNot(HaveOccurred())
+	return line[:len(line)-1]
+}
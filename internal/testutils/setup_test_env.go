@@ -1,11 +1,16 @@
 package testutils
 
 import (
+	"context"
+
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	. "github.com/onsi/ginkgo/v2" //nolint:staticcheck // Dot imports are fine for testutils.
 	. "github.com/onsi/gomega"    //nolint:staticcheck // Dot imports are fine for testutils.
@@ -37,3 +42,55 @@ func SetupTestEnv() (*envtest.Environment, client.Client) {
 	k8sClient = utils.NewLoggingClient(k8sClient, logger)
 	return testEnv, k8sClient
 }
+
+// SetupTestEnvWithWebhooks prepares envtest with the webhook configurations of this operator and a kubernetes client
+// for interacting with the envtest instance. The webhook server is run by a manager, which is set up with the given
+// function. The returned function stops the manager. The client is uncached.
+func SetupTestEnvWithWebhooks(setup func(mgr ctrl.Manager) error) (*envtest.Environment, client.Client, context.CancelFunc) {
+	Expect(MoveToProjectRoot()).To(Succeed())
+	Expect(MakeBinDirAvailable()).To(Succeed())
+
+	logger := zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true))
+	ctrllog.SetLogger(logger)
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths:     []string{"manifests/ctf-challenge-operator-crd.yaml"},
+		ErrorIfCRDPathMissing: true,
+		BinaryAssetsDirectory: "bin",
+		WebhookInstallOptions: envtest.WebhookInstallOptions{
+			Paths: []string{"manifests/ctf-challenge-operator-webhook.yaml"},
+		},
+	}
+	cfg, err := testEnv.Start()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cfg).NotTo(BeNil())
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme: clientgoscheme.Scheme,
+		Metrics: metricsserver.Options{
+			BindAddress: "0",
+		},
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Host:    testEnv.WebhookInstallOptions.LocalServingHost,
+			Port:    testEnv.WebhookInstallOptions.LocalServingPort,
+			CertDir: testEnv.WebhookInstallOptions.LocalServingCertDir,
+		}),
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(setup(mgr)).To(Succeed())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer GinkgoRecover()
+		Expect(mgr.Start(ctx)).To(Succeed())
+	}()
+	Eventually(func() error {
+		return mgr.GetWebhookServer().StartedChecker()(nil)
+	}).Should(Succeed())
+
+	k8sClient, err := client.New(cfg, client.Options{Scheme: clientgoscheme.Scheme})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(k8sClient).NotTo(BeNil())
+	k8sClient = utils.NewLoggingClient(k8sClient, logger)
+	return testEnv, k8sClient, cancel
+}
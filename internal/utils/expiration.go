@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"fmt"
+)
+
+// ExpirationBounds limit the duration of validity which can be requested for a resource.
+type ExpirationBounds struct {
+	// DefaultSeconds is used when no duration is requested.
+	DefaultSeconds int64
+
+	// MinSeconds is the shortest duration which can be requested.
+	MinSeconds int64
+
+	// MaxSeconds is the longest duration which can be requested.
+	MaxSeconds int64
+}
+
+// Validate returns an error if the given duration is outside the bounds.
+func (b ExpirationBounds) Validate(expirationSeconds int64) error {
+	if expirationSeconds < b.MinSeconds || expirationSeconds > b.MaxSeconds {
+		return fmt.Errorf("must be between %d and %d seconds", b.MinSeconds, b.MaxSeconds)
+	}
+	return nil
+}
@@ -50,13 +50,31 @@ func (r *Reconciler[T]) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		return ctrl.Result{}, nil
 	}
 
+	// An error stops the chain of sub reconcilers. A requested requeue does not, so that sub reconcilers which are
+	// waiting for something do not prevent later sub reconcilers from doing their work. The earliest requeue wins. This
+	// applies to all controllers built on this reconciler.
+	var result ctrl.Result
 	for _, subReconciler := range r.subReconcilers {
-		result, err := subReconciler.Reconcile(ctx, obj)
-		if err != nil || !result.IsZero() {
-			return result, err
+		subResult, err := subReconciler.Reconcile(ctx, obj)
+		if err != nil {
+			return subResult, err
 		}
+		result = MergeResults(result, subResult)
 	}
-	return ctrl.Result{}, nil
+	return result, nil
+}
+
+// MergeResults combines two reconcile results into one which requeues at the earliest point in time requested by any
+// of them.
+func MergeResults(lhs ctrl.Result, rhs ctrl.Result) ctrl.Result {
+	result := ctrl.Result{
+		Requeue:      lhs.Requeue || rhs.Requeue,
+		RequeueAfter: lhs.RequeueAfter,
+	}
+	if result.RequeueAfter == 0 || (rhs.RequeueAfter != 0 && rhs.RequeueAfter < result.RequeueAfter) {
+		result.RequeueAfter = rhs.RequeueAfter
+	}
+	return result
 }
 
 func (r *Reconciler[T]) getObject(ctx context.Context, req ctrl.Request) (T, error) {
@@ -0,0 +1,113 @@
+package utils_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+var _ = DescribeTable("MergeResults",
+	func(lhs ctrl.Result, rhs ctrl.Result, expected ctrl.Result) {
+		Expect(utils.MergeResults(lhs, rhs)).To(Equal(expected))
+	},
+	Entry("empty results",
+		ctrl.Result{}, ctrl.Result{}, ctrl.Result{}),
+	Entry("requeue of the left result",
+		ctrl.Result{Requeue: true}, ctrl.Result{}, ctrl.Result{Requeue: true}),
+	Entry("requeue of the right result",
+		ctrl.Result{}, ctrl.Result{Requeue: true}, ctrl.Result{Requeue: true}),
+	Entry("requeue after of the left result",
+		ctrl.Result{RequeueAfter: time.Minute}, ctrl.Result{}, ctrl.Result{RequeueAfter: time.Minute}),
+	Entry("requeue after of the right result",
+		ctrl.Result{}, ctrl.Result{RequeueAfter: time.Minute}, ctrl.Result{RequeueAfter: time.Minute}),
+	Entry("earlier requeue after of the left result",
+		ctrl.Result{RequeueAfter: time.Second}, ctrl.Result{RequeueAfter: time.Minute}, ctrl.Result{RequeueAfter: time.Second}),
+	Entry("earlier requeue after of the right result",
+		ctrl.Result{RequeueAfter: time.Minute}, ctrl.Result{RequeueAfter: time.Second}, ctrl.Result{RequeueAfter: time.Second}),
+)
+
+var _ = Describe("Reconciler", func() {
+	var k8sClient client.Client
+	var configMap corev1.ConfigMap
+
+	BeforeEach(func(ctx SpecContext) {
+		k8sClient = fake.NewClientBuilder().Build()
+		configMap = corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test",
+				Namespace: corev1.NamespaceDefault,
+			},
+		}
+		Expect(k8sClient.Create(ctx, &configMap)).To(Succeed())
+	})
+
+	It("should continue the chain after a requeue and return the earliest requeue", func(ctx SpecContext) {
+		first := &fakeSubReconciler{result: ctrl.Result{RequeueAfter: time.Minute}}
+		second := &fakeSubReconciler{result: ctrl.Result{RequeueAfter: time.Second}}
+		third := &fakeSubReconciler{}
+		reconciler := newReconciler(k8sClient, first, second, third)
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&configMap)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{RequeueAfter: time.Second}))
+		Expect(first.calls).To(Equal(1))
+		Expect(second.calls).To(Equal(1))
+		Expect(third.calls).To(Equal(1))
+	})
+
+	It("should stop the chain on errors", func(ctx SpecContext) {
+		first := &fakeSubReconciler{err: errors.New("test")}
+		second := &fakeSubReconciler{}
+		reconciler := newReconciler(k8sClient, first, second)
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&configMap)})
+		Expect(err).To(MatchError("test"))
+		Expect(first.calls).To(Equal(1))
+		Expect(second.calls).To(Equal(0))
+	})
+
+	It("should not run the chain for deleted objects", func(ctx SpecContext) {
+		first := &fakeSubReconciler{}
+		reconciler := newReconciler(k8sClient, first)
+		Expect(k8sClient.Delete(ctx, &configMap)).To(Succeed())
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&configMap)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeZero())
+		Expect(first.calls).To(Equal(0))
+	})
+})
+
+func newReconciler(k8sClient client.Client, subReconcilers ...*fakeSubReconciler) *utils.Reconciler[*corev1.ConfigMap] {
+	reconciler := utils.NewReconciler(k8sClient, func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{}
+	})
+	for _, subReconciler := range subReconcilers {
+		reconciler.AppendSubReconciler(subReconciler)
+	}
+	return reconciler
+}
+
+// fakeSubReconciler returns the configured result and counts how often it was called.
+type fakeSubReconciler struct {
+	utils.DefaultSubReconciler
+	result ctrl.Result
+	err    error
+	calls  int
+}
+
+func (r *fakeSubReconciler) Reconcile(ctx context.Context, configMap *corev1.ConfigMap) (ctrl.Result, error) {
+	r.calls++
+	return r.result, r.err
+}
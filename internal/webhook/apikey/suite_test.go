@@ -0,0 +1,38 @@
+package apikey_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+var (
+	testEnv   *envtest.Environment
+	k8sClient client.Client
+)
+
+var expirationBounds = utils.ExpirationBounds{
+	DefaultSeconds: 12 * 60 * 60,
+	MinSeconds:     60,
+	MaxSeconds:     7 * 24 * 60 * 60,
+}
+
+func TestWebhook(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "APIKey Webhook Suite")
+}
+
+var _ = BeforeSuite(func() {
+	testEnv, k8sClient = testutils.SetupTestEnv()
+})
+
+var _ = AfterSuite(func() {
+	Expect(testEnv.Stop()).To(Succeed())
+})
@@ -0,0 +1,106 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+// +kubebuilder:webhook:path=/mutate-core-ctf-backbone81-v1alpha1-apikey,mutating=true,failurePolicy=fail,sideEffects=None,groups=core.ctf.backbone81,resources=apikeys,verbs=create,versions=v1alpha1,name=mapikey.ctf.backbone81,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-core-ctf-backbone81-v1alpha1-apikey,mutating=false,failurePolicy=fail,sideEffects=None,groups=core.ctf.backbone81,resources=apikeys,verbs=create;update,versions=v1alpha1,name=vapikey.ctf.backbone81,admissionReviewVersions=v1
+
+// Webhook is responsible for defaulting and validating API keys on admission.
+type Webhook struct {
+	client           client.Client
+	expirationBounds utils.ExpirationBounds
+}
+
+// NewWebhook creates a new webhook instance. The requested expiration of API keys is defaulted and validated with the
+// given bounds.
+func NewWebhook(client client.Client, expirationBounds utils.ExpirationBounds) *Webhook {
+	return &Webhook{
+		client:           client,
+		expirationBounds: expirationBounds,
+	}
+}
+
+// SetupWithManager registers the webhook with the webhook server of the given manager.
+func (w *Webhook) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1alpha1.APIKey{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+// Default sets the default expiration of the API key, if no expiration was given.
+func (w *Webhook) Default(ctx context.Context, obj runtime.Object) error {
+	apiKey, ok := obj.(*v1alpha1.APIKey)
+	if !ok {
+		return fmt.Errorf("expected an APIKey but got %T", obj)
+	}
+
+	if apiKey.Spec.ExpirationSeconds == nil {
+		expirationSeconds := w.expirationBounds.DefaultSeconds
+		apiKey.Spec.ExpirationSeconds = &expirationSeconds
+	}
+	return nil
+}
+
+// ValidateCreate validates the expiration of the API key.
+func (w *Webhook) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	apiKey, ok := obj.(*v1alpha1.APIKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an APIKey but got %T", obj)
+	}
+	return nil, w.validateExpiration(nil, apiKey)
+}
+
+// ValidateUpdate validates a changed expiration of the API key.
+func (w *Webhook) ValidateUpdate(ctx context.Context, oldObj runtime.Object, newObj runtime.Object) (admission.Warnings, error) {
+	oldAPIKey, ok := oldObj.(*v1alpha1.APIKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an APIKey but got %T", oldObj)
+	}
+	apiKey, ok := newObj.(*v1alpha1.APIKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an APIKey but got %T", newObj)
+	}
+	return nil, w.validateExpiration(oldAPIKey, apiKey)
+}
+
+// ValidateDelete does not validate anything. API keys can always be deleted.
+func (w *Webhook) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateExpiration checks the requested expiration against the bounds. The old API key is nil on creation. API keys
+// which were created with other bounds are not rejected as long as they do not change their expiration.
+func (w *Webhook) validateExpiration(oldAPIKey *v1alpha1.APIKey, apiKey *v1alpha1.APIKey) error {
+	expirationSeconds := apiKey.Spec.ExpirationSeconds
+	if expirationSeconds == nil {
+		return nil
+	}
+	if oldAPIKey != nil && oldAPIKey.Spec.ExpirationSeconds != nil && *oldAPIKey.Spec.ExpirationSeconds == *expirationSeconds {
+		return nil
+	}
+	if err := w.expirationBounds.Validate(*expirationSeconds); err != nil {
+		return apierrors.NewInvalid(
+			v1alpha1.GroupVersion.WithKind("APIKey").GroupKind(),
+			apiKey.Name,
+			field.ErrorList{
+				field.Invalid(field.NewPath("spec", "expirationSeconds"), *expirationSeconds, err.Error()),
+			},
+		)
+	}
+	return nil
+}
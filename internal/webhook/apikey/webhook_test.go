@@ -0,0 +1,104 @@
+package apikey_test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/webhook/apikey"
+)
+
+var _ = Describe("Webhook", func() {
+	var webhook *apikey.Webhook
+
+	BeforeEach(func() {
+		webhook = apikey.NewWebhook(k8sClient, expirationBounds)
+	})
+
+	It("should default the expiration", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		apiKey := newAPIKey(nil)
+
+		By("run the webhook")
+		Expect(webhook.Default(ctx, &apiKey)).To(Succeed())
+
+		By("verify all postconditions")
+		Expect(apiKey.Spec.ExpirationSeconds).To(Equal(ptr.To(expirationBounds.DefaultSeconds)))
+	})
+
+	It("should keep an explicitly given expiration", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		apiKey := newAPIKey(ptr.To(int64(120)))
+
+		By("run the webhook")
+		Expect(webhook.Default(ctx, &apiKey)).To(Succeed())
+
+		By("verify all postconditions")
+		Expect(apiKey.Spec.ExpirationSeconds).To(Equal(ptr.To(int64(120))))
+	})
+
+	DescribeTable("expiration bounds on create",
+		func(ctx SpecContext, expirationSeconds int64, valid bool) {
+			By("prepare test with all preconditions")
+			apiKey := newAPIKey(ptr.To(expirationSeconds))
+
+			By("run the webhook")
+			_, err := webhook.ValidateCreate(ctx, &apiKey)
+
+			By("verify all postconditions")
+			if valid {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(apierrors.IsInvalid(err)).To(BeTrue())
+				Expect(err.Error()).To(ContainSubstring("spec.expirationSeconds"))
+			}
+		},
+		Entry("below minimum", expirationBounds.MinSeconds-1, false),
+		Entry("at minimum", expirationBounds.MinSeconds, true),
+		Entry("at maximum", expirationBounds.MaxSeconds, true),
+		Entry("above maximum", expirationBounds.MaxSeconds+1, false),
+	)
+
+	It("should reject a changed expiration outside the bounds", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		oldAPIKey := newAPIKey(ptr.To(expirationBounds.DefaultSeconds))
+		apiKey := newAPIKey(ptr.To(expirationBounds.MaxSeconds + 1))
+
+		By("run the webhook")
+		_, err := webhook.ValidateUpdate(ctx, &oldAPIKey, &apiKey)
+
+		By("verify all postconditions")
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+	})
+
+	It("should accept an unchanged expiration outside the bounds", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		oldAPIKey := newAPIKey(ptr.To(expirationBounds.MaxSeconds + 1))
+		apiKey := newAPIKey(ptr.To(expirationBounds.MaxSeconds + 1))
+
+		By("run the webhook")
+		_, err := webhook.ValidateUpdate(ctx, &oldAPIKey, &apiKey)
+
+		By("verify all postconditions")
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
+
+// newAPIKey returns an API key with the given expiration without creating it.
+func newAPIKey(expirationSeconds *int64) v1alpha1.APIKey {
+	return v1alpha1.APIKey{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.APIKeySpec{
+			Owner:             "team-a",
+			ExpirationSeconds: expirationSeconds,
+		},
+	}
+}
@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/internal/certificate"
+)
+
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations;validatingwebhookconfigurations,verbs=get;list;watch;update;patch
+
+// CertificateOptions configure the self-signed certificate of the webhook server.
+type CertificateOptions struct {
+	// CertDir is the directory the webhook server reads tls.crt and tls.key from.
+	CertDir string
+
+	// SecretNamespace and SecretName identify the secret which stores the certificate. The secret is shared by all
+	// replicas of the operator.
+	SecretNamespace string
+	SecretName      string
+
+	// ServiceNamespace and ServiceName identify the service the API server uses to reach the webhook server.
+	ServiceNamespace string
+	ServiceName      string
+
+	// WebhookConfigurationName is the name of the mutating and validating webhook configurations which need to trust
+	// the certificate.
+	WebhookConfigurationName string
+}
+
+// EnsureCertificate makes sure that a self-signed certificate for the webhook service exists and is not about to
+// expire. The certificate is placed into the certificate directory of the webhook server and the certificate
+// authority is injected into the webhook configurations. This needs to happen before the webhook server starts.
+func EnsureCertificate(ctx context.Context, k8sClient client.Client, options CertificateOptions) error {
+	bundle, err := getOrCreateBundle(ctx, k8sClient, options)
+	if err != nil {
+		return fmt.Errorf("getting certificate: %w", err)
+	}
+	if err := writeBundle(options.CertDir, bundle); err != nil {
+		return fmt.Errorf("writing certificate: %w", err)
+	}
+	if err := injectCABundle(ctx, k8sClient, options.WebhookConfigurationName, bundle.CACert); err != nil {
+		return fmt.Errorf("injecting certificate authority: %w", err)
+	}
+	return nil
+}
+
+// getDNSNames returns the DNS names the API server might use for reaching the webhook service.
+func getDNSNames(options CertificateOptions) []string {
+	return []string{
+		fmt.Sprintf("%s.%s.svc", options.ServiceName, options.ServiceNamespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", options.ServiceName, options.ServiceNamespace),
+	}
+}
+
+func getOrCreateBundle(ctx context.Context, k8sClient client.Client, options CertificateOptions) (certificate.Bundle, error) {
+	dnsNames := getDNSNames(options)
+
+	var secret corev1.Secret
+	err := k8sClient.Get(ctx, client.ObjectKey{
+		Namespace: options.SecretNamespace,
+		Name:      options.SecretName,
+	}, &secret)
+	if client.IgnoreNotFound(err) != nil {
+		return certificate.Bundle{}, err
+	}
+	secretExists := err == nil
+
+	bundle := certificate.Bundle{
+		CACert: secret.Data["ca.crt"],
+		Cert:   secret.Data[corev1.TLSCertKey],
+		Key:    secret.Data[corev1.TLSPrivateKeyKey],
+	}
+	if !certificate.NeedsRenewal(bundle, dnsNames, certificate.DefaultRenewBefore) {
+		return bundle, nil
+	}
+
+	bundle, err = certificate.Generate(dnsNames, certificate.DefaultValidity)
+	if err != nil {
+		return certificate.Bundle{}, err
+	}
+	secret.Type = corev1.SecretTypeTLS
+	secret.Data = map[string][]byte{
+		"ca.crt":                bundle.CACert,
+		corev1.TLSCertKey:       bundle.Cert,
+		corev1.TLSPrivateKeyKey: bundle.Key,
+	}
+	if secretExists {
+		err = k8sClient.Update(ctx, &secret)
+	} else {
+		secret.ObjectMeta = metav1.ObjectMeta{
+			Namespace: options.SecretNamespace,
+			Name:      options.SecretName,
+		}
+		err = k8sClient.Create(ctx, &secret)
+	}
+	if apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) {
+		// Another replica was faster. We use the certificate of that replica instead.
+		return getOrCreateBundle(ctx, k8sClient, options)
+	}
+	if err != nil {
+		return certificate.Bundle{}, err
+	}
+	return bundle, nil
+}
+
+func writeBundle(certDir string, bundle certificate.Bundle) error {
+	if err := os.MkdirAll(certDir, 0o700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(certDir, corev1.TLSCertKey), bundle.Cert, 0o600); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(certDir, corev1.TLSPrivateKeyKey), bundle.Key, 0o600)
+}
+
+// injectCABundle places the given certificate authority into all webhooks of the mutating and validating webhook
+// configurations with the given name. Missing webhook configurations are skipped.
+func injectCABundle(ctx context.Context, k8sClient client.Client, name string, caBundle []byte) error {
+	var mutatingWebhookConfiguration admissionregistrationv1.MutatingWebhookConfiguration
+	if err := k8sClient.Get(ctx, client.ObjectKey{Name: name}, &mutatingWebhookConfiguration); client.IgnoreNotFound(err) != nil {
+		return err
+	} else if err == nil {
+		for i := range mutatingWebhookConfiguration.Webhooks {
+			mutatingWebhookConfiguration.Webhooks[i].ClientConfig.CABundle = caBundle
+		}
+		if err := k8sClient.Update(ctx, &mutatingWebhookConfiguration); err != nil {
+			return err
+		}
+	}
+
+	var validatingWebhookConfiguration admissionregistrationv1.ValidatingWebhookConfiguration
+	if err := k8sClient.Get(ctx, client.ObjectKey{Name: name}, &validatingWebhookConfiguration); client.IgnoreNotFound(err) != nil {
+		return err
+	} else if err == nil {
+		for i := range validatingWebhookConfiguration.Webhooks {
+			validatingWebhookConfiguration.Webhooks[i].ClientConfig.CABundle = caBundle
+		}
+		if err := k8sClient.Update(ctx, &validatingWebhookConfiguration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
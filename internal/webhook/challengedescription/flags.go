@@ -0,0 +1,32 @@
+package challengedescription
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/flag"
+)
+
+// validateFlagMatchers checks that the values of all flag matchers of type regex are valid regular expressions. Values
+// which are stored in secrets are only checked when a flag is submitted.
+func validateFlagMatchers(spec *v1alpha1.ChallengeDescriptionSpec) field.ErrorList {
+	specPath := field.NewPath("spec")
+	errs := validateFlagMatcherList(specPath.Child("flags"), spec.Flags)
+	for i, stage := range spec.Stages {
+		errs = append(errs, validateFlagMatcherList(specPath.Child("stages").Index(i).Child("flags"), stage.Flags)...)
+	}
+	return errs
+}
+
+func validateFlagMatcherList(flagsPath *field.Path, flagMatchers []v1alpha1.FlagMatcher) field.ErrorList {
+	var errs field.ErrorList
+	for i, flagMatcher := range flagMatchers {
+		if flagMatcher.Type != v1alpha1.FlagMatcherTypeRegex || len(flagMatcher.Value) == 0 {
+			continue
+		}
+		if _, err := flag.CompileRegex(flagMatcher.Value); err != nil {
+			errs = append(errs, field.Invalid(flagsPath.Index(i).Child("value"), flagMatcher.Value, err.Error()))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,93 @@
+package challengedescription
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+// clusterScopedKinds are well known kinds which are not namespaced. They are used when the kind is unknown to the API
+// server, for example because a CRD is not yet installed.
+var clusterScopedKinds = map[schema.GroupKind]bool{
+	{Group: "", Kind: "Namespace"}:                                                  true,
+	{Group: "", Kind: "Node"}:                                                       true,
+	{Group: "", Kind: "PersistentVolume"}:                                           true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:                       true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}:                true,
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}:               true,
+	{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}:   true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}: true,
+	{Group: "storage.k8s.io", Kind: "StorageClass"}:                                 true,
+	{Group: "scheduling.k8s.io", Kind: "PriorityClass"}:                             true,
+}
+
+// validateManifests checks that every manifest can be decoded, is namespaced, is of an allowed kind and is unique.
+// All problems are reported at once.
+func (w *Webhook) validateManifests(manifests []runtime.RawExtension) field.ErrorList {
+	codecFactory := serializer.NewCodecFactory(clientgoscheme.Scheme)
+	decoder := codecFactory.UniversalDeserializer()
+
+	var errs field.ErrorList
+	manifestsPath := field.NewPath("spec", "manifests")
+	seen := make(map[string]int, len(manifests))
+	for i, raw := range manifests {
+		manifestPath := manifestsPath.Index(i)
+
+		var manifest unstructured.Unstructured
+		if _, _, err := decoder.Decode(raw.Raw, nil, &manifest); err != nil {
+			errs = append(errs, field.Invalid(manifestPath, "", fmt.Sprintf("manifest can not be decoded: %s", err)))
+			continue
+		}
+
+		groupKind := manifest.GroupVersionKind().GroupKind()
+		if len(manifest.GetName()) == 0 {
+			errs = append(errs, field.Required(manifestPath.Child("metadata", "name"), "every manifest needs a name"))
+		}
+		if w.isClusterScoped(&manifest) {
+			errs = append(errs, field.Forbidden(manifestPath.Child("kind"), fmt.Sprintf("cluster scoped kind %s is not allowed", formatGroupKind(groupKind))))
+		} else if len(w.allowedManifestKind) != 0 && !w.allowedManifestKind[groupKind] {
+			errs = append(errs, field.NotSupported(manifestPath.Child("kind"), formatGroupKind(groupKind), w.getAllowedManifestKinds()))
+		}
+
+		if len(manifest.GetName()) != 0 {
+			key := formatGroupKind(groupKind) + "/" + manifest.GetName()
+			if first, ok := seen[key]; ok {
+				errs = append(errs, field.Duplicate(manifestPath, fmt.Sprintf("%s is already defined at index %d", key, first)))
+			} else {
+				seen[key] = i
+			}
+		}
+	}
+	return errs
+}
+
+// isClusterScoped reports if the given object is not namespaced. The API server is asked first and the list of well
+// known cluster scoped kinds is used when the API server does not know the kind.
+func (w *Webhook) isClusterScoped(obj *unstructured.Unstructured) bool {
+	namespaced, err := w.client.IsObjectNamespaced(obj)
+	if err != nil {
+		return clusterScopedKinds[obj.GroupVersionKind().GroupKind()]
+	}
+	return !namespaced
+}
+
+func (w *Webhook) getAllowedManifestKinds() []string {
+	result := make([]string, 0, len(w.allowedManifestKind))
+	for groupKind := range w.allowedManifestKind {
+		result = append(result, formatGroupKind(groupKind))
+	}
+	sort.Strings(result)
+	return result
+}
+
+// formatGroupKind returns the group kind in the form Kind.group, which is also used on the command line.
+func formatGroupKind(groupKind schema.GroupKind) string {
+	return strings.TrimSuffix(groupKind.Kind+"."+groupKind.Group, ".")
+}
@@ -0,0 +1,52 @@
+package challengedescription_test
+
+import (
+	"context"
+	"testing"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
+	"github.com/backbone81/ctf-challenge-operator/internal/webhook"
+	"github.com/backbone81/ctf-challenge-operator/internal/webhook/challengedescription"
+)
+
+var (
+	testEnv     *envtest.Environment
+	k8sClient   client.Client
+	stopManager context.CancelFunc
+)
+
+func TestWebhook(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ChallengeDescription Webhook Suite")
+}
+
+var _ = BeforeSuite(func() {
+	testEnv, k8sClient, stopManager = testutils.SetupTestEnvWithWebhooks(func(mgr ctrl.Manager) error {
+		return webhook.NewWebhook(
+			mgr.GetClient(),
+			webhook.WithChallengeDescriptionWebhook(challengedescription.DefaultAllowedManifestKinds),
+		).SetupWithManager(mgr)
+	})
+})
+
+var _ = AfterSuite(func() {
+	stopManager()
+	Expect(testEnv.Stop()).To(Succeed())
+})
+
+func DeleteAllInstances(ctx context.Context) {
+	var challengeDescriptionList v1alpha1.ChallengeDescriptionList
+	Expect(k8sClient.List(ctx, &challengeDescriptionList)).To(Succeed())
+
+	for _, challengeDescription := range challengeDescriptionList.Items {
+		Expect(k8sClient.Delete(ctx, &challengeDescription)).To(Succeed())
+	}
+}
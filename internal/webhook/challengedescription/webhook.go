@@ -0,0 +1,110 @@
+package challengedescription
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+)
+
+// +kubebuilder:webhook:path=/validate-core-ctf-backbone81-v1alpha1-challengedescription,mutating=false,failurePolicy=fail,sideEffects=None,groups=core.ctf.backbone81,resources=challengedescriptions,verbs=create;update,versions=v1alpha1,name=vchallengedescription.ctf.backbone81,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-core-ctf-backbone81-v1alpha1-clusterchallengedescription,mutating=false,failurePolicy=fail,sideEffects=None,groups=core.ctf.backbone81,resources=clusterchallengedescriptions,verbs=create;update,versions=v1alpha1,name=vclusterchallengedescription.ctf.backbone81,admissionReviewVersions=v1
+
+// DefaultAllowedManifestKinds are the kinds which are allowed in the manifests of a challenge description, unless
+// configured otherwise.
+var DefaultAllowedManifestKinds = []schema.GroupKind{
+	{Group: "", Kind: "ConfigMap"},
+	{Group: "", Kind: "Secret"},
+	{Group: "", Kind: "Service"},
+	{Group: "", Kind: "Pod"},
+	{Group: "", Kind: "PersistentVolumeClaim"},
+	{Group: "apps", Kind: "Deployment"},
+	{Group: "apps", Kind: "StatefulSet"},
+	{Group: "apps", Kind: "DaemonSet"},
+	{Group: "apps", Kind: "ReplicaSet"},
+	{Group: "batch", Kind: "Job"},
+	{Group: "networking.k8s.io", Kind: "Ingress"},
+}
+
+// Webhook is responsible for validating challenge descriptions and cluster challenge descriptions on admission.
+type Webhook struct {
+	client              client.Client
+	allowedManifestKind map[schema.GroupKind]bool
+}
+
+// NewWebhook creates a new webhook instance. Only manifests of the given kinds are accepted. An empty list accepts
+// all namespaced kinds.
+func NewWebhook(client client.Client, allowedManifestKinds []schema.GroupKind) *Webhook {
+	allowedManifestKind := make(map[schema.GroupKind]bool, len(allowedManifestKinds))
+	for _, groupKind := range allowedManifestKinds {
+		allowedManifestKind[groupKind] = true
+	}
+	return &Webhook{
+		client:              client,
+		allowedManifestKind: allowedManifestKind,
+	}
+}
+
+// SetupWithManager registers the webhook with the webhook server of the given manager.
+func (w *Webhook) SetupWithManager(mgr ctrl.Manager) error {
+	if err := ctrl.NewWebhookManagedBy(mgr).
+		For(&v1alpha1.ChallengeDescription{}).
+		WithValidator(w).
+		Complete(); err != nil {
+		return err
+	}
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1alpha1.ClusterChallengeDescription{}).
+		WithValidator(w).
+		Complete()
+}
+
+// ValidateCreate validates the manifests and flags of the challenge description or cluster challenge description.
+func (w *Webhook) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, w.validate(obj)
+}
+
+// ValidateUpdate validates the manifests and flags of the challenge description or cluster challenge description.
+func (w *Webhook) ValidateUpdate(ctx context.Context, oldObj runtime.Object, newObj runtime.Object) (admission.Warnings, error) {
+	return nil, w.validate(newObj)
+}
+
+// ValidateDelete does not validate anything. Challenge descriptions can always be deleted.
+func (w *Webhook) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate checks the given challenge description or cluster challenge description.
+func (w *Webhook) validate(obj runtime.Object) error {
+	var (
+		kind string
+		name string
+		spec *v1alpha1.ChallengeDescriptionSpec
+	)
+	switch challengeDescription := obj.(type) {
+	case *v1alpha1.ChallengeDescription:
+		kind, name, spec = v1alpha1.ChallengeDescriptionKind, challengeDescription.Name, &challengeDescription.Spec
+	case *v1alpha1.ClusterChallengeDescription:
+		kind, name, spec = v1alpha1.ClusterChallengeDescriptionKind, challengeDescription.Name, &challengeDescription.Spec.ChallengeDescriptionSpec
+	default:
+		return fmt.Errorf("expected a ChallengeDescription or ClusterChallengeDescription but got %T", obj)
+	}
+
+	errs := w.validateManifests(spec.Manifests)
+	errs = append(errs, validateFlagMatchers(spec)...)
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(
+		v1alpha1.GroupVersion.WithKind(kind).GroupKind(),
+		name,
+		errs,
+	)
+}
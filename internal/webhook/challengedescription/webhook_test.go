@@ -0,0 +1,276 @@
+package challengedescription_test
+
+import (
+	"os"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/flag"
+	"github.com/backbone81/ctf-challenge-operator/internal/webhook/challengedescription"
+)
+
+var _ = Describe("Webhook", func() {
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+	})
+
+	DescribeTable("manifests",
+		func(ctx SpecContext, manifests []string, valid bool) {
+			instance := newDescription(manifests...)
+			if valid {
+				Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+			} else {
+				err := k8sClient.Create(ctx, &instance)
+				Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			}
+		},
+		Entry("allowed kinds",
+			[]string{
+				`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`,
+				`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"test"}}`,
+			},
+			true,
+		),
+		Entry("same name with different kinds",
+			[]string{
+				`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`,
+				`{"apiVersion":"v1","kind":"Service","metadata":{"name":"test"}}`,
+			},
+			true,
+		),
+		Entry("malformed manifest",
+			[]string{
+				`{"metadata":{"name":"test"}}`,
+			},
+			false,
+		),
+		Entry("missing name",
+			[]string{
+				`{"apiVersion":"v1","kind":"ConfigMap","metadata":{}}`,
+			},
+			false,
+		),
+		Entry("namespace",
+			[]string{
+				`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"test"}}`,
+			},
+			false,
+		),
+		Entry("cluster role",
+			[]string{
+				`{"apiVersion":"rbac.authorization.k8s.io/v1","kind":"ClusterRole","metadata":{"name":"test"}}`,
+			},
+			false,
+		),
+		Entry("cluster role binding",
+			[]string{
+				`{"apiVersion":"rbac.authorization.k8s.io/v1","kind":"ClusterRoleBinding","metadata":{"name":"test"}}`,
+			},
+			false,
+		),
+		Entry("custom resource definition",
+			[]string{
+				`{"apiVersion":"apiextensions.k8s.io/v1","kind":"CustomResourceDefinition","metadata":{"name":"tests.example.com"}}`,
+			},
+			false,
+		),
+		Entry("kind not allowed",
+			[]string{
+				`{"apiVersion":"rbac.authorization.k8s.io/v1","kind":"Role","metadata":{"name":"test"}}`,
+			},
+			false,
+		),
+		Entry("duplicate manifests",
+			[]string{
+				`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`,
+				`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`,
+			},
+			false,
+		),
+	)
+
+	DescribeTable("flag matchers",
+		func(ctx SpecContext, flagMatcher v1alpha1.FlagMatcher, valid bool) {
+			instance := newDescription(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`)
+			instance.Spec.Flags = []v1alpha1.FlagMatcher{flagMatcher}
+			if valid {
+				Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+			} else {
+				err := k8sClient.Create(ctx, &instance)
+				Expect(apierrors.IsInvalid(err)).To(BeTrue())
+				Expect(err.Error()).To(ContainSubstring("spec.flags[0].value"))
+			}
+		},
+		Entry("static flag",
+			v1alpha1.FlagMatcher{Type: v1alpha1.FlagMatcherTypeStatic, Value: "CTF{[0-9+}"},
+			true,
+		),
+		Entry("valid regular expression",
+			v1alpha1.FlagMatcher{Type: v1alpha1.FlagMatcherTypeRegex, Value: `CTF\{[0-9]+\}`},
+			true,
+		),
+		Entry("invalid regular expression",
+			v1alpha1.FlagMatcher{Type: v1alpha1.FlagMatcherTypeRegex, Value: `CTF\{[0-9+\}`},
+			false,
+		),
+	)
+
+	It("should validate the regular expressions of stages", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := newDescription(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`)
+		instance.Spec.Flag = ""
+		instance.Spec.Stages = []v1alpha1.ChallengeStage{
+			{
+				Name:   "first",
+				Points: 100,
+				Flags: []v1alpha1.FlagMatcher{
+					{Type: v1alpha1.FlagMatcherTypeRegex, Value: `CTF\{[0-9+\}`},
+				},
+			},
+		}
+
+		By("create the challenge description")
+		err := k8sClient.Create(ctx, &instance)
+
+		By("verify all postconditions")
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.stages[0].flags[0].value"))
+	})
+
+	It("should report all problems at once", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := newDescription(
+			`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"test"}}`,
+			`{"apiVersion":"rbac.authorization.k8s.io/v1","kind":"Role","metadata":{"name":"test"}}`,
+			`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`,
+			`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`,
+		)
+
+		By("create the challenge description")
+		err := k8sClient.Create(ctx, &instance)
+
+		By("verify all postconditions")
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.manifests[0].kind"))
+		Expect(err.Error()).To(ContainSubstring("spec.manifests[1].kind"))
+		Expect(err.Error()).To(ContainSubstring("spec.manifests[3]"))
+	})
+
+	It("should validate the manifests on update", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := newDescription(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`)
+		Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+
+		By("update the challenge description")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&instance), &instance)).To(Succeed())
+		instance.Spec.Manifests = append(instance.Spec.Manifests, runtime.RawExtension{
+			Raw: []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"test"}}`),
+		})
+		err := k8sClient.Update(ctx, &instance)
+
+		By("verify all postconditions")
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+	})
+
+	It("should validate the manifests of cluster challenge descriptions", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := newDescription(`{"apiVersion":"rbac.authorization.k8s.io/v1","kind":"Role","metadata":{"name":"test"}}`)
+		clusterDescription := v1alpha1.ClusterChallengeDescription{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+			},
+			Spec: v1alpha1.ClusterChallengeDescriptionSpec{
+				ChallengeDescriptionSpec: description.Spec,
+			},
+		}
+		clusterDescription.Spec.Flag = ""
+		clusterDescription.Spec.FlagHash = &v1alpha1.FlagHash{
+			Algorithm: v1alpha1.FlagHashAlgorithmSHA256,
+			Salt:      "01234567",
+			Hash:      flag.Hash("01234567", "CTF{test}"),
+		}
+
+		By("create the cluster challenge description")
+		err := k8sClient.Create(ctx, &clusterDescription)
+
+		By("verify all postconditions")
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.manifests[0].kind"))
+	})
+})
+
+var _ = Describe("DefaultAllowedManifestKinds", func() {
+	It("should only contain kinds the operator is allowed to manage", func() {
+		By("prepare test with all preconditions")
+		clusterRole := readClusterRole("manifests/ctf-challenge-operator-clusterrole.yaml")
+
+		By("verify all postconditions")
+		for _, groupKind := range challengedescription.DefaultAllowedManifestKinds {
+			resource, _ := meta.UnsafeGuessKindToResource(groupKind.WithVersion(""))
+			for _, verb := range []string{"get", "list", "watch", "create", "update", "patch", "delete"} {
+				Expect(isAllowed(clusterRole, resource.Group, resource.Resource, verb)).To(
+					BeTrue(),
+					"the ClusterRole does not allow %s on %s", verb, resource.GroupResource(),
+				)
+			}
+		}
+	})
+})
+
+// readClusterRole reads the cluster role from the file with the given name.
+func readClusterRole(name string) rbacv1.ClusterRole {
+	file, err := os.Open(name)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var clusterRole rbacv1.ClusterRole
+	Expect(utilyaml.NewYAMLOrJSONDecoder(file, 4096).Decode(&clusterRole)).To(Succeed())
+	return clusterRole
+}
+
+// isAllowed returns true if one of the rules of the cluster role allows the verb on the resource of the group.
+func isAllowed(clusterRole rbacv1.ClusterRole, group string, resource string, verb string) bool {
+	for _, rule := range clusterRole.Rules {
+		if slices.Contains(rule.APIGroups, group) &&
+			slices.Contains(rule.Resources, resource) &&
+			slices.Contains(rule.Verbs, verb) {
+			return true
+		}
+	}
+	return false
+}
+
+// newDescription returns a challenge description with the given manifests without creating it.
+func newDescription(manifests ...string) v1alpha1.ChallengeDescription {
+	description := v1alpha1.ChallengeDescription{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeDescriptionSpec{
+			Title:       "test",
+			Description: "test",
+			Flag:        "CTF{test}",
+		},
+	}
+	for _, manifest := range manifests {
+		description.Spec.Manifests = append(description.Spec.Manifests, runtime.RawExtension{
+			Raw: []byte(manifest),
+		})
+	}
+	return description
+}
@@ -0,0 +1,90 @@
+package challengeinstance
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/description"
+)
+
+// validateQuota rejects the given challenge instance if its owner already reached the operator wide limit or the
+// limit of the challenge description on concurrently running instances. Instances without owner are not limited.
+func (w *Webhook) validateQuota(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) error {
+	if len(challengeInstance.Spec.Owner) == 0 {
+		return nil
+	}
+
+	challengeDescription, err := description.Get(ctx, w.client, challengeInstance)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+
+	runningInstances, err := w.getRunningInstances(ctx, challengeInstance.Spec.Owner)
+	if err != nil {
+		return err
+	}
+
+	if w.maxInstancesPerOwner > 0 && len(runningInstances) >= w.maxInstancesPerOwner {
+		return newQuotaExceededError(challengeInstance, fmt.Sprintf(
+			"owner %s already has %d running challenge instances, the limit is %d",
+			challengeInstance.Spec.Owner,
+			len(runningInstances),
+			w.maxInstancesPerOwner,
+		))
+	}
+
+	if challengeDescription == nil || challengeDescription.Spec.MaxInstancesPerOwner == nil {
+		return nil
+	}
+	maxInstancesPerOwner := challengeDescription.Spec.MaxInstancesPerOwner
+	runningDescriptionInstances := 0
+	for _, runningInstance := range runningInstances {
+		if description.IsSame(&runningInstance, challengeInstance) {
+			runningDescriptionInstances++
+		}
+	}
+	if runningDescriptionInstances >= *maxInstancesPerOwner {
+		return newQuotaExceededError(challengeInstance, fmt.Sprintf(
+			"owner %s already has %d running instances of challenge %s, the limit is %d",
+			challengeInstance.Spec.Owner,
+			runningDescriptionInstances,
+			challengeDescription.Name,
+			*maxInstancesPerOwner,
+		))
+	}
+	return nil
+}
+
+// getRunningInstances returns all challenge instances of the given owner across all namespaces, which are not being
+// deleted.
+func (w *Webhook) getRunningInstances(ctx context.Context, owner string) ([]v1alpha1.ChallengeInstance, error) {
+	var challengeInstanceList v1alpha1.ChallengeInstanceList
+	if err := w.client.List(ctx, &challengeInstanceList); err != nil {
+		return nil, err
+	}
+
+	var result []v1alpha1.ChallengeInstance
+	for _, challengeInstance := range challengeInstanceList.Items {
+		if challengeInstance.Spec.Owner != owner || !challengeInstance.DeletionTimestamp.IsZero() {
+			continue
+		}
+		result = append(result, challengeInstance)
+	}
+	return result, nil
+}
+
+func newQuotaExceededError(challengeInstance *v1alpha1.ChallengeInstance, message string) error {
+	name := challengeInstance.Name
+	if len(name) == 0 {
+		name = challengeInstance.GenerateName
+	}
+	return apierrors.NewForbidden(
+		v1alpha1.GroupVersion.WithResource("challengeinstances").GroupResource(),
+		name,
+		fmt.Errorf("quota exceeded: %s", message),
+	)
+}
@@ -0,0 +1,235 @@
+package challengeinstance_test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/flag"
+	"github.com/backbone81/ctf-challenge-operator/internal/webhook/challengeinstance"
+)
+
+var _ = Describe("Quota", func() {
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+	})
+
+	It("should accept instances below the operator wide limit", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		webhook := challengeinstance.NewWebhook(k8sClient, 2, expirationBounds)
+		description := createDescription(ctx, nil)
+		createInstance(ctx, description, "team-a")
+		instance := newInstance(description, "team-a")
+
+		By("run the webhook")
+		_, err := webhook.ValidateCreate(ctx, &instance)
+
+		By("verify all postconditions")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should reject instances above the operator wide limit", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		webhook := challengeinstance.NewWebhook(k8sClient, 2, expirationBounds)
+		description := createDescription(ctx, nil)
+		otherDescription := createDescription(ctx, nil)
+		createInstance(ctx, description, "team-a")
+		createInstance(ctx, otherDescription, "team-a")
+		instance := newInstance(description, "team-a")
+
+		By("run the webhook")
+		_, err := webhook.ValidateCreate(ctx, &instance)
+
+		By("verify all postconditions")
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("quota exceeded"))
+	})
+
+	It("should reject instances above the limit of the challenge description", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		webhook := challengeinstance.NewWebhook(k8sClient, 0, expirationBounds)
+		description := createDescription(ctx, ptr.To(1))
+		createInstance(ctx, description, "team-a")
+		instance := newInstance(description, "team-a")
+
+		By("run the webhook")
+		_, err := webhook.ValidateCreate(ctx, &instance)
+
+		By("verify all postconditions")
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(description.Name))
+	})
+
+	It("should reject instances above the limit of the cluster challenge description", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		webhook := challengeinstance.NewWebhook(k8sClient, 0, expirationBounds)
+		clusterDescription := createClusterDescription(ctx, ptr.To(1))
+		existingInstance := newClusterInstance(clusterDescription, "team-a")
+		Expect(k8sClient.Create(ctx, &existingInstance)).To(Succeed())
+		instance := newClusterInstance(clusterDescription, "team-a")
+
+		By("run the webhook")
+		_, err := webhook.ValidateCreate(ctx, &instance)
+
+		By("verify all postconditions")
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(clusterDescription.Name))
+	})
+
+	It("should not count instances of other owners", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		webhook := challengeinstance.NewWebhook(k8sClient, 1, expirationBounds)
+		description := createDescription(ctx, ptr.To(1))
+		createInstance(ctx, description, "team-a")
+		instance := newInstance(description, "team-b")
+
+		By("run the webhook")
+		_, err := webhook.ValidateCreate(ctx, &instance)
+
+		By("verify all postconditions")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should not limit instances without owner", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		webhook := challengeinstance.NewWebhook(k8sClient, 1, expirationBounds)
+		description := createDescription(ctx, ptr.To(1))
+		createInstance(ctx, description, "")
+		instance := newInstance(description, "")
+
+		By("run the webhook")
+		_, err := webhook.ValidateCreate(ctx, &instance)
+
+		By("verify all postconditions")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should reject claims above the limit of the challenge description", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		webhook := challengeinstance.NewWebhook(k8sClient, 0, expirationBounds)
+		description := createDescription(ctx, ptr.To(1))
+		createInstance(ctx, description, "team-a")
+		oldInstance := createInstance(ctx, description, "")
+		instance := oldInstance.DeepCopy()
+		instance.Spec.Owner = "team-a"
+
+		By("run the webhook")
+		_, err := webhook.ValidateUpdate(ctx, &oldInstance, instance)
+
+		By("verify all postconditions")
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(description.Name))
+	})
+
+	It("should not apply the quota to updates of owned instances", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		webhook := challengeinstance.NewWebhook(k8sClient, 0, expirationBounds)
+		description := createDescription(ctx, ptr.To(1))
+		oldInstance := createInstance(ctx, description, "team-a")
+		createInstance(ctx, description, "team-a")
+		instance := oldInstance.DeepCopy()
+		instance.Spec.Extensions = 1
+
+		By("run the webhook")
+		_, err := webhook.ValidateUpdate(ctx, &oldInstance, instance)
+
+		By("verify all postconditions")
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
+
+// createDescription creates a challenge description with the given limit of instances per owner.
+func createDescription(ctx SpecContext, maxInstancesPerOwner *int) v1alpha1.ChallengeDescription {
+	description := v1alpha1.ChallengeDescription{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeDescriptionSpec{
+			Title:                "test",
+			Description:          "test",
+			Flag:                 "CTF{test}",
+			MaxInstancesPerOwner: maxInstancesPerOwner,
+			Manifests: []runtime.RawExtension{
+				{
+					Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`),
+				},
+			},
+		},
+	}
+	Expect(k8sClient.Create(ctx, &description)).To(Succeed())
+	return description
+}
+
+// newInstance returns a challenge instance of the given challenge description and owner without creating it.
+func newInstance(description v1alpha1.ChallengeDescription, owner string) v1alpha1.ChallengeInstance {
+	return v1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    description.Namespace,
+		},
+		Spec: v1alpha1.ChallengeInstanceSpec{
+			ChallengeDescriptionName: description.Name,
+			Owner:                    owner,
+		},
+	}
+}
+
+// createInstance creates a challenge instance of the given challenge description and owner.
+func createInstance(ctx SpecContext, description v1alpha1.ChallengeDescription, owner string) v1alpha1.ChallengeInstance {
+	instance := newInstance(description, owner)
+	Expect(k8sClient.Create(ctx, &instance)).To(Succeed())
+	return instance
+}
+
+// createClusterDescription creates a cluster challenge description with the given limit of instances per owner.
+func createClusterDescription(ctx SpecContext, maxInstancesPerOwner *int) v1alpha1.ClusterChallengeDescription {
+	clusterDescription := v1alpha1.ClusterChallengeDescription{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+		},
+		Spec: v1alpha1.ClusterChallengeDescriptionSpec{
+			ChallengeDescriptionSpec: v1alpha1.ChallengeDescriptionSpec{
+				Title:       "test",
+				Description: "test",
+				FlagHash: &v1alpha1.FlagHash{
+					Algorithm: v1alpha1.FlagHashAlgorithmSHA256,
+					Salt:      "01234567",
+					Hash:      flag.Hash("01234567", "CTF{test}"),
+				},
+				MaxInstancesPerOwner: maxInstancesPerOwner,
+				Manifests: []runtime.RawExtension{
+					{
+						Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`),
+					},
+				},
+			},
+		},
+	}
+	Expect(k8sClient.Create(ctx, &clusterDescription)).To(Succeed())
+	return clusterDescription
+}
+
+// newClusterInstance returns a challenge instance of the given cluster challenge description and owner without
+// creating it.
+func newClusterInstance(clusterDescription v1alpha1.ClusterChallengeDescription, owner string) v1alpha1.ChallengeInstance {
+	return v1alpha1.ChallengeInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "test-",
+			Namespace:    corev1.NamespaceDefault,
+		},
+		Spec: v1alpha1.ChallengeInstanceSpec{
+			ChallengeDescriptionRef: &v1alpha1.ChallengeDescriptionReference{
+				Kind: v1alpha1.ClusterChallengeDescriptionKind,
+				Name: clusterDescription.Name,
+			},
+			Owner: owner,
+		},
+	}
+}
@@ -0,0 +1,49 @@
+package challengeinstance_test
+
+import (
+	"context"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/testutils"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+var (
+	testEnv   *envtest.Environment
+	k8sClient client.Client
+)
+
+var expirationBounds = utils.ExpirationBounds{
+	DefaultSeconds: 15 * 60,
+	MinSeconds:     60,
+	MaxSeconds:     24 * 60 * 60,
+}
+
+func TestWebhook(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ChallengeInstance Webhook Suite")
+}
+
+var _ = BeforeSuite(func() {
+	testEnv, k8sClient = testutils.SetupTestEnv()
+})
+
+var _ = AfterSuite(func() {
+	Expect(testEnv.Stop()).To(Succeed())
+})
+
+func DeleteAllInstances(ctx context.Context) {
+	var challengeInstanceList v1alpha1.ChallengeInstanceList
+	Expect(k8sClient.List(ctx, &challengeInstanceList)).To(Succeed())
+
+	for _, challengeInstance := range challengeInstanceList.Items {
+		Expect(k8sClient.Delete(ctx, &challengeInstance)).To(Succeed())
+	}
+}
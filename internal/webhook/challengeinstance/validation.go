@@ -0,0 +1,74 @@
+package challengeinstance
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/description"
+)
+
+// validateSpec checks the spec of the given challenge instance. The old challenge instance is nil on creation. All
+// problems are reported at once.
+func (w *Webhook) validateSpec(ctx context.Context, oldChallengeInstance *v1alpha1.ChallengeInstance, challengeInstance *v1alpha1.ChallengeInstance) error {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	challengeDescriptionPath := specPath.Child("challengeDescriptionName")
+	if challengeInstance.Spec.ChallengeDescriptionRef != nil {
+		challengeDescriptionPath = specPath.Child("challengeDescriptionRef")
+	}
+	if oldChallengeInstance == nil {
+		exists, err := w.challengeDescriptionExists(ctx, challengeInstance)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			errs = append(errs, field.NotFound(challengeDescriptionPath, description.String(challengeInstance)))
+		}
+	} else if description.Ref(oldChallengeInstance) != description.Ref(challengeInstance) {
+		errs = append(errs, field.Forbidden(challengeDescriptionPath, "the challenge description is immutable"))
+	}
+
+	// The owner and the API key can be set once when an instance without owner is claimed. Clearing them would take
+	// the instance out of the quota of its owner.
+	if oldChallengeInstance != nil {
+		if len(oldChallengeInstance.Spec.Owner) != 0 && oldChallengeInstance.Spec.Owner != challengeInstance.Spec.Owner {
+			errs = append(errs, field.Forbidden(specPath.Child("owner"), "the owner can not be changed once it is set"))
+		}
+		if len(oldChallengeInstance.Spec.APIKeyName) != 0 && oldChallengeInstance.Spec.APIKeyName != challengeInstance.Spec.APIKeyName {
+			errs = append(errs, field.Forbidden(specPath.Child("apiKeyName"), "the API key can not be changed once it is set"))
+		}
+	}
+
+	// Instances which were created with other bounds are not rejected as long as they do not change their expiration.
+	expirationSeconds := challengeInstance.Spec.ExpirationSeconds
+	if expirationSeconds != nil && (oldChallengeInstance == nil ||
+		oldChallengeInstance.Spec.ExpirationSeconds == nil ||
+		*oldChallengeInstance.Spec.ExpirationSeconds != *expirationSeconds) {
+		if err := w.expirationBounds.Validate(*expirationSeconds); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("expirationSeconds"), *expirationSeconds, err.Error()))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(
+		v1alpha1.GroupVersion.WithKind("ChallengeInstance").GroupKind(),
+		challengeInstance.Name,
+		errs,
+	)
+}
+
+func (w *Webhook) challengeDescriptionExists(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) (bool, error) {
+	if _, err := description.Get(ctx, w.client, challengeInstance); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
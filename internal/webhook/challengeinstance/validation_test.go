@@ -0,0 +1,229 @@
+package challengeinstance_test
+
+import (
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/webhook/challengeinstance"
+)
+
+var _ = Describe("Validation", func() {
+	var webhook *challengeinstance.Webhook
+
+	BeforeEach(func() {
+		webhook = challengeinstance.NewWebhook(k8sClient, 0, expirationBounds)
+	})
+
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+	})
+
+	It("should default the expiration", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionName: "test",
+				Owner:                    "team-a",
+			},
+		}
+
+		By("run the webhook")
+		Expect(webhook.Default(ctx, &instance)).To(Succeed())
+
+		By("verify all postconditions")
+		Expect(instance.Spec.ExpirationSeconds).To(Equal(ptr.To(expirationBounds.DefaultSeconds)))
+	})
+
+	It("should keep an explicitly given expiration", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionName: "test",
+				Owner:                    "team-a",
+				ExpirationSeconds:        ptr.To(int64(120)),
+			},
+		}
+
+		By("run the webhook")
+		Expect(webhook.Default(ctx, &instance)).To(Succeed())
+
+		By("verify all postconditions")
+		Expect(instance.Spec.ExpirationSeconds).To(Equal(ptr.To(int64(120))))
+	})
+
+	It("should reject an unknown challenge description", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionName: "does-not-exist",
+				Owner:                    "team-a",
+			},
+		}
+
+		By("run the webhook")
+		_, err := webhook.ValidateCreate(ctx, &instance)
+
+		By("verify all postconditions")
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.challengeDescriptionName"))
+	})
+
+	It("should accept a cluster challenge description", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		clusterDescription := createClusterDescription(ctx, nil)
+		instance := newClusterInstance(clusterDescription, "team-a")
+
+		By("run the webhook")
+		_, err := webhook.ValidateCreate(ctx, &instance)
+
+		By("verify all postconditions")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should reject an unknown cluster challenge description", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := newClusterInstance(v1alpha1.ClusterChallengeDescription{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "does-not-exist",
+			},
+		}, "team-a")
+
+		By("run the webhook")
+		_, err := webhook.ValidateCreate(ctx, &instance)
+
+		By("verify all postconditions")
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.challengeDescriptionRef"))
+	})
+
+	DescribeTable("expiration bounds on create",
+		func(ctx SpecContext, expirationSeconds int64, valid bool) {
+			By("prepare test with all preconditions")
+			description := createDescription(ctx, nil)
+			instance := newInstance(description, "team-a")
+			instance.Spec.ExpirationSeconds = ptr.To(expirationSeconds)
+
+			By("run the webhook")
+			_, err := webhook.ValidateCreate(ctx, &instance)
+
+			By("verify all postconditions")
+			if valid {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(apierrors.IsInvalid(err)).To(BeTrue())
+				Expect(err.Error()).To(ContainSubstring("spec.expirationSeconds"))
+			}
+		},
+		Entry("below minimum", expirationBounds.MinSeconds-1, false),
+		Entry("at minimum", expirationBounds.MinSeconds, true),
+		Entry("at maximum", expirationBounds.MaxSeconds, true),
+		Entry("above maximum", expirationBounds.MaxSeconds+1, false),
+	)
+
+	It("should reject a changed challenge description", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescription(ctx, nil)
+		otherDescription := createDescription(ctx, nil)
+		oldInstance := newInstance(description, "team-a")
+		instance := newInstance(otherDescription, "team-a")
+
+		By("run the webhook")
+		_, err := webhook.ValidateUpdate(ctx, &oldInstance, &instance)
+
+		By("verify all postconditions")
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("immutable"))
+	})
+
+	DescribeTable("should reject changing the owner or the API key once it is set",
+		func(ctx SpecContext, oldOwner string, oldAPIKeyName string, owner string, apiKeyName string, fieldPath string) {
+			By("prepare test with all preconditions")
+			description := createDescription(ctx, nil)
+			oldInstance := newInstance(description, oldOwner)
+			oldInstance.Spec.APIKeyName = oldAPIKeyName
+			instance := newInstance(description, owner)
+			instance.Spec.APIKeyName = apiKeyName
+
+			By("run the webhook")
+			_, err := webhook.ValidateUpdate(ctx, &oldInstance, &instance)
+
+			By("verify all postconditions")
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			var statusErr *apierrors.StatusError
+			Expect(errors.As(err, &statusErr)).To(BeTrue())
+			Expect(statusErr.ErrStatus.Details.Causes).To(ContainElement(SatisfyAll(
+				HaveField("Type", metav1.CauseType(field.ErrorTypeForbidden)),
+				HaveField("Field", fieldPath),
+			)))
+		},
+		Entry("clearing the owner", "team-a", "", "", "", "spec.owner"),
+		Entry("changing the owner", "team-a", "", "team-b", "", "spec.owner"),
+		Entry("clearing the API key", "team-a", "key-a", "team-a", "", "spec.apiKeyName"),
+		Entry("changing the API key", "team-a", "key-a", "team-a", "key-b", "spec.apiKeyName"),
+	)
+
+	It("should accept claiming an instance without owner", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescription(ctx, nil)
+		oldInstance := newInstance(description, "")
+		instance := newInstance(description, "team-a")
+		instance.Spec.APIKeyName = "key-a"
+
+		By("run the webhook")
+		_, err := webhook.ValidateUpdate(ctx, &oldInstance, &instance)
+
+		By("verify all postconditions")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should reject a changed expiration outside the bounds", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescription(ctx, nil)
+		oldInstance := newInstance(description, "team-a")
+		oldInstance.Spec.ExpirationSeconds = ptr.To(expirationBounds.DefaultSeconds)
+		instance := newInstance(description, "team-a")
+		instance.Spec.ExpirationSeconds = ptr.To(expirationBounds.MaxSeconds + 1)
+
+		By("run the webhook")
+		_, err := webhook.ValidateUpdate(ctx, &oldInstance, &instance)
+
+		By("verify all postconditions")
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+	})
+
+	It("should accept an unchanged expiration outside the bounds", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		description := createDescription(ctx, nil)
+		oldInstance := newInstance(description, "team-a")
+		oldInstance.Spec.ExpirationSeconds = ptr.To(expirationBounds.MaxSeconds + 1)
+		instance := newInstance(description, "team-a")
+		instance.Spec.ExpirationSeconds = ptr.To(expirationBounds.MaxSeconds + 1)
+
+		By("run the webhook")
+		_, err := webhook.ValidateUpdate(ctx, &oldInstance, &instance)
+
+		By("verify all postconditions")
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
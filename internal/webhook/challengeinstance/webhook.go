@@ -0,0 +1,129 @@
+package challengeinstance
+
+import (
+	"context"
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+)
+
+// +kubebuilder:webhook:path=/mutate-core-ctf-backbone81-v1alpha1-challengeinstance,mutating=true,failurePolicy=fail,sideEffects=None,groups=core.ctf.backbone81,resources=challengeinstances,verbs=create;update,versions=v1alpha1,name=mchallengeinstance.ctf.backbone81,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-core-ctf-backbone81-v1alpha1-challengeinstance,mutating=false,failurePolicy=fail,sideEffects=None,groups=core.ctf.backbone81,resources=challengeinstances,verbs=create;update,versions=v1alpha1,name=vchallengeinstance.ctf.backbone81,admissionReviewVersions=v1
+
+// Webhook is responsible for defaulting and validating challenge instances on admission.
+type Webhook struct {
+	client               client.Client
+	maxInstancesPerOwner int
+	expirationBounds     utils.ExpirationBounds
+}
+
+// NewWebhook creates a new webhook instance. The given number limits the concurrently running instances per owner
+// across all challenges. Zero disables the limit. The requested expiration of challenge instances is defaulted and
+// validated with the given bounds.
+func NewWebhook(client client.Client, maxInstancesPerOwner int, expirationBounds utils.ExpirationBounds) *Webhook {
+	return &Webhook{
+		client:               client,
+		maxInstancesPerOwner: maxInstancesPerOwner,
+		expirationBounds:     expirationBounds,
+	}
+}
+
+// SetupWithManager registers the webhook with the webhook server of the given manager.
+func (w *Webhook) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1alpha1.ChallengeInstance{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+// Default sets the default expiration and derives the owner of the challenge instance from the referenced API key,
+// if no owner was given. On updates, only challenge instances which are claimed from their pool are defaulted.
+func (w *Webhook) Default(ctx context.Context, obj runtime.Object) error {
+	challengeInstance, ok := obj.(*v1alpha1.ChallengeInstance)
+	if !ok {
+		return fmt.Errorf("expected a ChallengeInstance but got %T", obj)
+	}
+
+	update := isUpdate(ctx)
+	if !update && challengeInstance.Spec.ExpirationSeconds == nil {
+		expirationSeconds := w.expirationBounds.DefaultSeconds
+		challengeInstance.Spec.ExpirationSeconds = &expirationSeconds
+	}
+
+	if len(challengeInstance.Spec.Owner) != 0 || len(challengeInstance.Spec.APIKeyName) == 0 {
+		return nil
+	}
+	if _, ok := challengeInstance.Labels[v1alpha1.ChallengeInstancePoolLabelName]; update && !ok {
+		// The owner was already derived when the challenge instance was created.
+		return nil
+	}
+
+	var apiKey v1alpha1.APIKey
+	if err := w.client.Get(ctx, client.ObjectKey{
+		Namespace: challengeInstance.Namespace,
+		Name:      challengeInstance.Spec.APIKeyName,
+	}, &apiKey); err != nil {
+		if apierrors.IsNotFound(err) {
+			return apierrors.NewBadRequest(fmt.Sprintf("APIKey %s does not exist", challengeInstance.Spec.APIKeyName))
+		}
+		return err
+	}
+	challengeInstance.Spec.Owner = apiKey.Spec.Owner
+	return nil
+}
+
+// ValidateCreate validates the spec of the challenge instance and enforces the quotas on concurrently running
+// instances per owner.
+func (w *Webhook) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	challengeInstance, ok := obj.(*v1alpha1.ChallengeInstance)
+	if !ok {
+		return nil, fmt.Errorf("expected a ChallengeInstance but got %T", obj)
+	}
+	if err := w.validateSpec(ctx, nil, challengeInstance); err != nil {
+		return nil, err
+	}
+	return nil, w.validateQuota(ctx, challengeInstance)
+}
+
+// ValidateUpdate validates the changes to the spec of the challenge instance. The quota applies when an instance
+// without owner is claimed. The owner and the API key can not be changed or removed afterwards.
+func (w *Webhook) ValidateUpdate(ctx context.Context, oldObj runtime.Object, newObj runtime.Object) (admission.Warnings, error) {
+	oldChallengeInstance, ok := oldObj.(*v1alpha1.ChallengeInstance)
+	if !ok {
+		return nil, fmt.Errorf("expected a ChallengeInstance but got %T", oldObj)
+	}
+	challengeInstance, ok := newObj.(*v1alpha1.ChallengeInstance)
+	if !ok {
+		return nil, fmt.Errorf("expected a ChallengeInstance but got %T", newObj)
+	}
+	if err := w.validateSpec(ctx, oldChallengeInstance, challengeInstance); err != nil {
+		return nil, err
+	}
+	if len(oldChallengeInstance.Spec.Owner) != 0 {
+		return nil, nil
+	}
+	return nil, w.validateQuota(ctx, challengeInstance)
+}
+
+// isUpdate returns true if the admission request in the given context updates an existing challenge instance.
+func isUpdate(ctx context.Context) bool {
+	request, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return false
+	}
+	return request.Operation == admissionv1.Update
+}
+
+// ValidateDelete does not validate anything. Instances can always be deleted.
+func (w *Webhook) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
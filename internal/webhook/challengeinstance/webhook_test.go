@@ -0,0 +1,96 @@
+package challengeinstance_test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/webhook/challengeinstance"
+)
+
+var _ = Describe("Webhook", func() {
+	var webhook *challengeinstance.Webhook
+
+	BeforeEach(func() {
+		webhook = challengeinstance.NewWebhook(k8sClient, 0, expirationBounds)
+	})
+
+	AfterEach(func(ctx SpecContext) {
+		DeleteAllInstances(ctx)
+	})
+
+	It("should derive the owner from the API key", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		apiKey := v1alpha1.APIKey{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.APIKeySpec{
+				Owner: "team-a",
+			},
+		}
+		Expect(k8sClient.Create(ctx, &apiKey)).To(Succeed())
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionName: "test",
+				APIKeyName:               apiKey.Name,
+			},
+		}
+
+		By("run the webhook")
+		Expect(webhook.Default(ctx, &instance)).To(Succeed())
+
+		By("verify all postconditions")
+		Expect(instance.Spec.Owner).To(Equal("team-a"))
+	})
+
+	It("should keep an explicitly given owner", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionName: "test",
+				Owner:                    "team-b",
+				APIKeyName:               "does-not-exist",
+			},
+		}
+
+		By("run the webhook")
+		Expect(webhook.Default(ctx, &instance)).To(Succeed())
+
+		By("verify all postconditions")
+		Expect(instance.Spec.Owner).To(Equal("team-b"))
+	})
+
+	It("should reject an unknown API key", func(ctx SpecContext) {
+		By("prepare test with all preconditions")
+		instance := v1alpha1.ChallengeInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "test-",
+				Namespace:    corev1.NamespaceDefault,
+			},
+			Spec: v1alpha1.ChallengeInstanceSpec{
+				ChallengeDescriptionName: "test",
+				APIKeyName:               "does-not-exist",
+			},
+		}
+
+		By("run the webhook")
+		err := webhook.Default(ctx, &instance)
+
+		By("verify all postconditions")
+		Expect(apierrors.IsBadRequest(err)).To(BeTrue())
+	})
+})
@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/internal/utils"
+	"github.com/backbone81/ctf-challenge-operator/internal/webhook/apikey"
+	"github.com/backbone81/ctf-challenge-operator/internal/webhook/challengedescription"
+	"github.com/backbone81/ctf-challenge-operator/internal/webhook/challengeinstance"
+)
+
+// Webhook is the main admission webhook of this operator. It is responsible for registering all admission webhooks
+// with the webhook server.
+type Webhook struct {
+	client      client.Client
+	subWebhooks []SubWebhook
+}
+
+// NewWebhook creates a new webhook instance. The webhook is initialized with the given client and applies the
+// provided options to the webhook.
+func NewWebhook(client client.Client, options ...WebhookOption) *Webhook {
+	result := &Webhook{
+		client: client,
+	}
+	for _, option := range options {
+		option(result)
+	}
+	return result
+}
+
+// SetupWithManager registers all enabled sub-webhooks with the given manager.
+func (w *Webhook) SetupWithManager(mgr ctrl.Manager) error {
+	for _, subWebhook := range w.subWebhooks {
+		if err := subWebhook.SetupWithManager(mgr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SubWebhook is the interface all sub-webhooks need to implement.
+type SubWebhook interface {
+	SetupWithManager(mgr ctrl.Manager) error
+}
+
+// WebhookOption is an option which can be applied to the webhook.
+type WebhookOption func(webhook *Webhook)
+
+// WithDefaultWebhooks returns a webhook option which enables the default sub-webhooks.
+func WithDefaultWebhooks(
+	maxInstancesPerOwner int,
+	allowedManifestKinds []schema.GroupKind,
+	challengeInstanceExpirationBounds utils.ExpirationBounds,
+	apiKeyExpirationBounds utils.ExpirationBounds,
+) WebhookOption {
+	return func(webhook *Webhook) {
+		WithAPIKeyWebhook(apiKeyExpirationBounds)(webhook)
+		WithChallengeDescriptionWebhook(allowedManifestKinds)(webhook)
+		WithChallengeInstanceWebhook(maxInstancesPerOwner, challengeInstanceExpirationBounds)(webhook)
+	}
+}
+
+// WithAPIKeyWebhook returns a webhook option which enables the APIKey sub-webhook. The expiration of API keys is
+// defaulted and validated with the given bounds.
+func WithAPIKeyWebhook(expirationBounds utils.ExpirationBounds) WebhookOption {
+	return func(webhook *Webhook) {
+		webhook.subWebhooks = append(
+			webhook.subWebhooks,
+			apikey.NewWebhook(webhook.client, expirationBounds),
+		)
+	}
+}
+
+// WithChallengeDescriptionWebhook returns a webhook option which enables the ChallengeDescription sub-webhook. Only
+// manifests of the given kinds are accepted. An empty list accepts all namespaced kinds.
+func WithChallengeDescriptionWebhook(allowedManifestKinds []schema.GroupKind) WebhookOption {
+	return func(webhook *Webhook) {
+		webhook.subWebhooks = append(
+			webhook.subWebhooks,
+			challengedescription.NewWebhook(webhook.client, allowedManifestKinds),
+		)
+	}
+}
+
+// WithChallengeInstanceWebhook returns a webhook option which enables the ChallengeInstance sub-webhook. The given
+// number limits the concurrently running instances per owner across all challenges. Zero disables the limit. The
+// expiration of challenge instances is defaulted and validated with the given bounds.
+func WithChallengeInstanceWebhook(maxInstancesPerOwner int, expirationBounds utils.ExpirationBounds) WebhookOption {
+	return func(webhook *Webhook) {
+		webhook.subWebhooks = append(
+			webhook.subWebhooks,
+			challengeinstance.NewWebhook(webhook.client, maxInstancesPerOwner, expirationBounds),
+		)
+	}
+}
@@ -0,0 +1,13 @@
+package webproxy_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestWebProxy(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Web Proxy Suite")
+}
@@ -0,0 +1,435 @@
+// Package webproxy implements an HTTP reverse proxy which routes players to the exposed web service of their challenge
+// instance. Only players of the owner of the challenge instance are let through, so other players can not interfere
+// with the challenge instance. Every request is logged, which gives a single place to audit web traffic.
+//
+// The challenge instance is selected by the host name of the request, which is the name of its namespace followed by
+// the domain of the proxy. Players authenticate with the key of an APIKey in the X-API-Key header. Browsers can pass
+// the key once in the api_key query parameter instead, which is exchanged for a session cookie.
+package webproxy
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/access"
+	"github.com/backbone81/ctf-challenge-operator/internal/description"
+)
+
+const (
+	// DefaultSessionDuration is the time a session cookie is valid for, unless the APIKey expires earlier.
+	DefaultSessionDuration = 12 * time.Hour
+
+	// SessionCookieName is the name of the cookie which carries the session of a player.
+	SessionCookieName = "ctf-session"
+
+	// APIKeyHeader is the header which carries the key of an APIKey.
+	APIKeyHeader = "X-API-Key"
+
+	// APIKeyQueryParameter is the query parameter which carries the key of an APIKey. It is exchanged for a session
+	// cookie.
+	APIKeyQueryParameter = "api_key"
+
+	// sessionKeySize is the size of the random session key which is generated when none is configured.
+	sessionKeySize = 32
+)
+
+// errInvalidSession is returned when the session cookie was not issued by the proxy, is expired or belongs to another
+// challenge instance.
+var errInvalidSession = errors.New("invalid session")
+
+// Options configure the web proxy.
+type Options struct {
+	// BindAddress is the address the proxy listens on, for example ":8443".
+	BindAddress string
+
+	// Domain is the domain of the host names the challenge instances are reachable under. The host name of a challenge
+	// instance is the name of its namespace followed by the domain.
+	Domain string
+
+	// CertDir is the directory which contains the certificate tls.crt and the private key tls.key. The certificate
+	// should be a wildcard certificate for the domain. Without a directory, the proxy serves plain HTTP and expects
+	// TLS to be terminated in front of it.
+	CertDir string
+
+	// SessionKey is the key the session cookies are signed with. Without a key, a random key is generated, and the
+	// sessions do not survive a restart and are not shared between replicas.
+	SessionKey []byte
+
+	// SessionDuration is the time a session cookie is valid for.
+	SessionDuration time.Duration
+}
+
+// Proxy forwards requests of players to the web service of the challenge instance they ask for, once the player is
+// authorized.
+type Proxy struct {
+	client       client.Client
+	logger       logr.Logger
+	options      Options
+	tlsConfig    *tls.Config
+	reverseProxy *httputil.ReverseProxy
+}
+
+// New returns a new proxy with the given options. The certificate is loaded from the certificate directory if one is
+// configured.
+func New(client client.Client, logger logr.Logger, options Options) (*Proxy, error) {
+	if options.SessionDuration == 0 {
+		options.SessionDuration = DefaultSessionDuration
+	}
+	if len(options.SessionKey) == 0 {
+		options.SessionKey = make([]byte, sessionKeySize)
+		if _, err := rand.Read(options.SessionKey); err != nil {
+			return nil, fmt.Errorf("generating session key: %w", err)
+		}
+	}
+	result := &Proxy{
+		client:  client,
+		logger:  logger,
+		options: options,
+	}
+	if len(options.CertDir) != 0 {
+		certificate, err := tls.LoadX509KeyPair(
+			filepath.Join(options.CertDir, "tls.crt"),
+			filepath.Join(options.CertDir, "tls.key"),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("loading web proxy certificate: %w", err)
+		}
+		result.tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+	result.reverseProxy = &httputil.ReverseProxy{
+		Rewrite:      rewrite,
+		ErrorHandler: handleBackendError,
+	}
+	return result, nil
+}
+
+// NeedLeaderElection returns false, because every replica of the operator can serve players.
+func (p *Proxy) NeedLeaderElection() bool {
+	return false
+}
+
+// Start listens on the bind address and serves players until the context is done.
+func (p *Proxy) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              p.options.BindAddress,
+		Handler:           p,
+		TLSConfig:         p.tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	p.logger.Info("Web proxy listening", "address", p.options.BindAddress, "tls", p.tlsConfig != nil)
+	var err error
+	if p.tlsConfig != nil {
+		// The certificate is already part of the TLS config.
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// ServeHTTP authorizes the request and forwards it to the challenge instance. Every request is logged.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	recorder := &responseRecorder{
+		ResponseWriter: w,
+		status:         http.StatusOK,
+	}
+	logger := p.logger.WithValues(
+		"remote-address", r.RemoteAddr,
+		"method", r.Method,
+		"host", r.Host,
+		"path", r.URL.Path,
+	)
+	logger = p.serve(recorder, r, logger)
+	logger.Info("Web proxy request",
+		"status", recorder.status,
+		"bytes", recorder.bytes,
+		"duration", time.Since(start).String(),
+	)
+}
+
+// serve handles a single request. It returns the logger enriched with everything which was learned about the request.
+func (p *Proxy) serve(w http.ResponseWriter, r *http.Request, logger logr.Logger) logr.Logger {
+	ctx := r.Context()
+	namespace, ok := access.ParseHost(getHostname(r.Host), p.options.Domain)
+	if !ok {
+		http.Error(w, "unknown challenge instance", http.StatusNotFound)
+		return logger
+	}
+	challengeInstance, err := access.FindChallengeInstance(ctx, p.client, namespace)
+	if err != nil {
+		if errors.Is(err, access.ErrUnknownInstance) {
+			http.Error(w, "unknown challenge instance", http.StatusNotFound)
+			return logger
+		}
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return logger.WithValues("error", err.Error())
+	}
+	logger = logger.WithValues("challenge-instance", client.ObjectKeyFromObject(challengeInstance))
+
+	apiKey, fromQuery, err := p.authenticate(ctx, r, challengeInstance)
+	if err != nil {
+		if errors.Is(err, access.ErrInvalidAPIKey) || errors.Is(err, errInvalidSession) {
+			http.Error(w, "access denied", http.StatusUnauthorized)
+			return logger.WithValues("error", err.Error())
+		}
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return logger.WithValues("error", err.Error())
+	}
+	logger = logger.WithValues("api-key", apiKey.Name, "owner", apiKey.Spec.Owner)
+
+	if err := access.Authorize(challengeInstance, apiKey); err != nil {
+		http.Error(w, "access denied", http.StatusForbidden)
+		return logger.WithValues("error", err.Error())
+	}
+	if !access.IsActive(challengeInstance, time.Now()) {
+		http.Error(w, access.ErrInactive.Error(), http.StatusForbidden)
+		return logger
+	}
+
+	if fromQuery {
+		// Browsers receive a session cookie and are sent to the same URL without the key, so that the key does not
+		// remain in the address bar or the history.
+		http.SetCookie(w, p.newSessionCookie(challengeInstance, apiKey, time.Now()))
+		redirectURL := *r.URL
+		query := redirectURL.Query()
+		query.Del(APIKeyQueryParameter)
+		redirectURL.RawQuery = query.Encode()
+		http.Redirect(w, r, redirectURL.RequestURI(), http.StatusSeeOther)
+		return logger
+	}
+
+	target, err := p.getBackendURL(ctx, challengeInstance)
+	if err != nil {
+		http.Error(w, "the challenge instance does not expose a web service", http.StatusBadGateway)
+		return logger.WithValues("error", err.Error())
+	}
+	p.reverseProxy.ServeHTTP(w, r.WithContext(context.WithValue(ctx, targetContextKey{}, target)))
+	return logger
+}
+
+// authenticate returns the APIKey the request was made with. The key is taken from the header, the query parameter
+// or the session cookie, in that order. The second return value is true if the key was taken from the query
+// parameter.
+func (p *Proxy) authenticate(ctx context.Context, r *http.Request, challengeInstance *v1alpha1.ChallengeInstance) (*v1alpha1.APIKey, bool, error) {
+	if key := r.Header.Get(APIKeyHeader); len(key) != 0 {
+		apiKey, err := access.FindAPIKey(ctx, p.client, challengeInstance.Namespace, key)
+		return apiKey, false, err
+	}
+	if key := r.URL.Query().Get(APIKeyQueryParameter); len(key) != 0 {
+		apiKey, err := access.FindAPIKey(ctx, p.client, challengeInstance.Namespace, key)
+		return apiKey, true, err
+	}
+
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil {
+		return nil, false, access.ErrInvalidAPIKey
+	}
+	apiKeyName, err := p.verifySession(cookie.Value, challengeInstance, time.Now())
+	if err != nil {
+		return nil, false, err
+	}
+	var apiKey v1alpha1.APIKey
+	if err := p.client.Get(ctx, client.ObjectKey{
+		Namespace: challengeInstance.Namespace,
+		Name:      apiKeyName,
+	}, &apiKey); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, false, access.ErrInvalidAPIKey
+		}
+		return nil, false, err
+	}
+	// The APIKey is checked again, so that sessions end when the APIKey is deleted or expires.
+	if err := access.CheckAPIKey(&apiKey, time.Now()); err != nil {
+		return nil, false, err
+	}
+	return &apiKey, false, nil
+}
+
+// newSessionCookie returns a session cookie for the given APIKey, which is only valid for the given challenge
+// instance. The cookie does not outlive the APIKey.
+func (p *Proxy) newSessionCookie(challengeInstance *v1alpha1.ChallengeInstance, apiKey *v1alpha1.APIKey, now time.Time) *http.Cookie {
+	expires := now.Add(p.options.SessionDuration)
+	if !apiKey.Status.ExpirationTimestamp.IsZero() && apiKey.Status.ExpirationTimestamp.Time.Before(expires) {
+		expires = apiKey.Status.ExpirationTimestamp.Time
+	}
+	payload := strings.Join([]string{
+		challengeInstance.Status.Namespace,
+		apiKey.Name,
+		strconv.FormatInt(expires.Unix(), 10),
+	}, "\n")
+	return &http.Cookie{
+		Name: SessionCookieName,
+		Value: base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+			base64.RawURLEncoding.EncodeToString(p.sign(payload)),
+		Path:     "/",
+		Expires:  expires,
+		Secure:   p.tlsConfig != nil,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// verifySession returns the name of the APIKey the given session cookie was issued for. errInvalidSession is returned
+// when the signature does not match, the session is expired or was issued for another challenge instance.
+func (p *Proxy) verifySession(value string, challengeInstance *v1alpha1.ChallengeInstance, now time.Time) (string, error) {
+	encodedPayload, encodedSignature, found := strings.Cut(value, ".")
+	if !found {
+		return "", errInvalidSession
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return "", errInvalidSession
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return "", errInvalidSession
+	}
+	if !hmac.Equal(signature, p.sign(string(payload))) {
+		return "", errInvalidSession
+	}
+
+	fields := strings.Split(string(payload), "\n")
+	if len(fields) != 3 || fields[0] != challengeInstance.Status.Namespace {
+		return "", errInvalidSession
+	}
+	expires, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil || !now.Before(time.Unix(expires, 0)) {
+		return "", errInvalidSession
+	}
+	return fields[1], nil
+}
+
+func (p *Proxy) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, p.options.SessionKey)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// getBackendURL returns the cluster internal URL of the service the challenge description exposes.
+func (p *Proxy) getBackendURL(ctx context.Context, challengeInstance *v1alpha1.ChallengeInstance) (*url.URL, error) {
+	challengeDescription, err := description.Get(ctx, p.client, challengeInstance)
+	if err != nil {
+		return nil, err
+	}
+	exposure := challengeDescription.Spec.Exposure
+	if exposure == nil {
+		return nil, errors.New("the challenge description does not request an exposure")
+	}
+
+	var service corev1.Service
+	if err := p.client.Get(ctx, client.ObjectKey{
+		Namespace: challengeInstance.Status.Namespace,
+		Name:      exposure.ServiceName,
+	}, &service); err != nil {
+		return nil, err
+	}
+	if len(service.Spec.ClusterIP) == 0 || service.Spec.ClusterIP == corev1.ClusterIPNone {
+		return nil, fmt.Errorf("the service %s/%s has no cluster IP", service.Namespace, service.Name)
+	}
+	return &url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(service.Spec.ClusterIP, strconv.Itoa(int(exposure.Port))),
+	}, nil
+}
+
+// targetContextKey is the key of the backend URL in the context of the request.
+type targetContextKey struct{}
+
+// rewrite prepares the request which is sent to the challenge instance. The host name of the player is kept, so that
+// the web service generates correct links. The credentials of the player are removed, so that they can not be stolen
+// by the challenge instance.
+func rewrite(r *httputil.ProxyRequest) {
+	r.SetURL(r.In.Context().Value(targetContextKey{}).(*url.URL))
+	r.SetXForwarded()
+	r.Out.Host = r.In.Host
+
+	r.Out.Header.Del(APIKeyHeader)
+	query := r.Out.URL.Query()
+	if query.Has(APIKeyQueryParameter) {
+		query.Del(APIKeyQueryParameter)
+		r.Out.URL.RawQuery = query.Encode()
+	}
+	cookies := r.Out.Cookies()
+	r.Out.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if cookie.Name == SessionCookieName {
+			continue
+		}
+		r.Out.AddCookie(cookie)
+	}
+}
+
+func handleBackendError(w http.ResponseWriter, _ *http.Request, _ error) {
+	http.Error(w, "the challenge instance is not reachable", http.StatusBadGateway)
+}
+
+// getHostname returns the host name of the given host, which might include a port.
+func getHostname(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		return hostname
+	}
+	return host
+}
+
+// responseRecorder records the status and the size of the response for logging.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(data)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap gives the reverse proxy access to the underlying response writer, which is required for flushing and for
+// upgrading connections to web sockets.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,191 @@
+package webproxy_test
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/backbone81/ctf-challenge-operator/api/v1alpha1"
+	"github.com/backbone81/ctf-challenge-operator/internal/access"
+	"github.com/backbone81/ctf-challenge-operator/internal/webproxy"
+)
+
+var _ = Describe("Proxy", func() {
+	var k8sClient client.Client
+	var proxy *webproxy.Proxy
+	var backendRequests chan *http.Request
+
+	BeforeEach(func() {
+		backendRequests = make(chan *http.Request, 1)
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			backendRequests <- r
+			_, _ = fmt.Fprint(w, "hello")
+		}))
+		DeferCleanup(backend.Close)
+		host, port, err := net.SplitHostPort(backend.Listener.Addr().String())
+		Expect(err).ToNot(HaveOccurred())
+		backendPort, err := strconv.Atoi(port)
+		Expect(err).ToNot(HaveOccurred())
+
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+		k8sClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithIndex(&v1alpha1.ChallengeInstance{}, access.StatusNamespaceField, access.IndexStatusNamespace).
+			WithStatusSubresource(&v1alpha1.ChallengeInstance{}).
+			WithObjects(
+				newAPIKey("team-a", "key-a", time.Now().Add(time.Hour)),
+				newAPIKey("team-b", "key-b", time.Now().Add(time.Hour)),
+				&v1alpha1.ChallengeDescription{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "default",
+						Name:      "web",
+					},
+					Spec: v1alpha1.ChallengeDescriptionSpec{
+						Exposure: &v1alpha1.Exposure{
+							ServiceName: "web",
+							Port:        int32(backendPort),
+						},
+					},
+				},
+				&v1alpha1.ChallengeInstance{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "default",
+						Name:      "test",
+					},
+					Spec: v1alpha1.ChallengeInstanceSpec{
+						ChallengeDescriptionName: "web",
+						Owner:                    "team-a",
+					},
+					Status: v1alpha1.ChallengeInstanceStatus{
+						Namespace:           "ctf-instance-abc",
+						ExpirationTimestamp: metav1.NewTime(time.Now().Add(time.Hour)),
+					},
+				},
+				&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "ctf-instance-abc",
+						Name:      "web",
+					},
+					Spec: corev1.ServiceSpec{
+						ClusterIP: host,
+					},
+				},
+			).
+			Build()
+
+		proxy, err = webproxy.New(k8sClient, logr.Discard(), webproxy.Options{
+			Domain: "ctf.example.com",
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should forward requests with a valid API key", func() {
+		request := httptest.NewRequest(http.MethodGet, "http://ctf-instance-abc.ctf.example.com/index.html", nil)
+		request.Header.Set(webproxy.APIKeyHeader, "key-a")
+		response := serve(proxy, request)
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Body.String()).To(Equal("hello"))
+
+		var backendRequest *http.Request
+		Expect(backendRequests).To(Receive(&backendRequest))
+		Expect(backendRequest.Host).To(Equal("ctf-instance-abc.ctf.example.com"))
+		Expect(backendRequest.URL.Path).To(Equal("/index.html"))
+		Expect(backendRequest.Header.Get(webproxy.APIKeyHeader)).To(BeEmpty())
+	})
+
+	It("should exchange the API key in the query for a session cookie", func() {
+		request := httptest.NewRequest(http.MethodGet, "http://ctf-instance-abc.ctf.example.com/index.html?api_key=key-a&page=1", nil)
+		response := serve(proxy, request)
+		Expect(response.Code).To(Equal(http.StatusSeeOther))
+		Expect(response.Header().Get("Location")).To(Equal("/index.html?page=1"))
+		cookies := response.Result().Cookies()
+		Expect(cookies).To(HaveLen(1))
+		Expect(cookies[0].Name).To(Equal(webproxy.SessionCookieName))
+		Expect(cookies[0].HttpOnly).To(BeTrue())
+
+		request = httptest.NewRequest(http.MethodGet, "http://ctf-instance-abc.ctf.example.com/index.html?page=1", nil)
+		request.AddCookie(cookies[0])
+		request.AddCookie(&http.Cookie{Name: "app", Value: "value"})
+		response = serve(proxy, request)
+		Expect(response.Code).To(Equal(http.StatusOK))
+
+		var backendRequest *http.Request
+		Expect(backendRequests).To(Receive(&backendRequest))
+		Expect(backendRequest.Cookies()).To(HaveLen(1))
+		Expect(backendRequest.Cookies()[0].Name).To(Equal("app"))
+	})
+
+	It("should reject requests without credentials", func() {
+		request := httptest.NewRequest(http.MethodGet, "http://ctf-instance-abc.ctf.example.com/", nil)
+		Expect(serve(proxy, request).Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should reject forged session cookies", func() {
+		request := httptest.NewRequest(http.MethodGet, "http://ctf-instance-abc.ctf.example.com/", nil)
+		request.AddCookie(&http.Cookie{Name: webproxy.SessionCookieName, Value: "dGVzdA.dGVzdA"})
+		Expect(serve(proxy, request).Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should reject API keys of other owners", func() {
+		request := httptest.NewRequest(http.MethodGet, "http://ctf-instance-abc.ctf.example.com/", nil)
+		request.Header.Set(webproxy.APIKeyHeader, "key-b")
+		Expect(serve(proxy, request).Code).To(Equal(http.StatusForbidden))
+		Expect(backendRequests).ToNot(Receive())
+	})
+
+	It("should reject expired challenge instances", func(ctx SpecContext) {
+		var instance v1alpha1.ChallengeInstance
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "test"}, &instance)).To(Succeed())
+		instance.Status.ExpirationTimestamp = metav1.NewTime(time.Now().Add(-time.Minute))
+		Expect(k8sClient.Status().Update(ctx, &instance)).To(Succeed())
+
+		request := httptest.NewRequest(http.MethodGet, "http://ctf-instance-abc.ctf.example.com/", nil)
+		request.Header.Set(webproxy.APIKeyHeader, "key-a")
+		Expect(serve(proxy, request).Code).To(Equal(http.StatusForbidden))
+	})
+
+	It("should reject unknown challenge instances", func() {
+		request := httptest.NewRequest(http.MethodGet, "http://ctf-instance-xyz.ctf.example.com/", nil)
+		request.Header.Set(webproxy.APIKeyHeader, "key-a")
+		Expect(serve(proxy, request).Code).To(Equal(http.StatusNotFound))
+	})
+})
+
+func serve(proxy *webproxy.Proxy, request *http.Request) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	proxy.ServeHTTP(response, request)
+	return response
+}
+
+func newAPIKey(owner string, key string, expirationTimestamp time.Time) *v1alpha1.APIKey {
+	return &v1alpha1.APIKey{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      strings.ToLower(owner),
+		},
+		Spec: v1alpha1.APIKeySpec{
+			Owner: owner,
+		},
+		Status: v1alpha1.APIKeyStatus{
+			Key:                 key,
+			ExpirationTimestamp: metav1.NewTime(expirationTimestamp),
+		},
+	}
+}